@@ -0,0 +1,126 @@
+package blockchain
+
+import (
+	"bytes"
+
+	"github.com/dgraph-io/badger"
+)
+
+// BadgerStore keeps the chain in a badger database on disk
+type BadgerStore struct {
+	db *badger.DB
+}
+
+// OpenBadgerStore opens the badger database at path, it is created when it
+// doesn't exist
+func OpenBadgerStore(path string) (*BadgerStore, error) {
+	opts := badger.DefaultOptions(path)
+	opts.Logger = nil
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BadgerStore{db: db}, nil
+}
+
+// View runs fn in a read only transaction
+func (store *BadgerStore) View(fn func(txn StoreTxn) error) error {
+	return store.db.View(func(txn *badger.Txn) error {
+		return fn(badgerTxn{txn})
+	})
+}
+
+// Update runs fn in a read write transaction
+func (store *BadgerStore) Update(fn func(txn StoreTxn) error) error {
+	err := store.db.Update(func(txn *badger.Txn) error {
+		return fn(badgerTxn{txn})
+	})
+	if err == badger.ErrConflict {
+		return ErrTxnConflict
+	}
+	return err
+}
+
+// GetBlock returns the block stored under hash
+func (store *BadgerStore) GetBlock(hash []byte) (*Block, error) {
+	return getBlock(store, hash)
+}
+
+// PutBlock stores block under its hash
+func (store *BadgerStore) PutBlock(block *Block) error {
+	return putBlock(store, block)
+}
+
+// Tips returns the tips of all device chains in address order
+func (store *BadgerStore) Tips() ([]CheckpointTip, error) {
+	return storeTips(store)
+}
+
+// Iterate calls fn with every key starting with prefix in key order
+func (store *BadgerStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return iterate(store, prefix, fn)
+}
+
+// Close closes the database
+func (store *BadgerStore) Close() error {
+	return store.db.Close()
+}
+
+// badgerTxn wraps a badger transaction, values are copied out of it
+type badgerTxn struct {
+	txn *badger.Txn
+}
+
+func (txn badgerTxn) Get(key []byte) ([]byte, error) {
+	item, err := txn.txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+func (txn badgerTxn) Set(key, value []byte) error {
+	return txn.txn.Set(key, value)
+}
+
+func (txn badgerTxn) Delete(key []byte) error {
+	return txn.txn.Delete(key)
+}
+
+func (txn badgerTxn) Iterate(prefix []byte, reverse bool, fn func(key, value []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = reverse
+	it := txn.txn.NewIterator(opts)
+	defer it.Close()
+
+	if !reverse {
+		it.Seek(prefix)
+	} else if end := prefixEnd(prefix); end == nil {
+		it.Rewind()
+	} else {
+		// a reverse seek stops at the last key up to end
+		it.Seek(end)
+		if it.Valid() && bytes.Equal(it.Item().Key(), end) {
+			it.Next()
+		}
+	}
+	for ; it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		err = fn(item.KeyCopy(nil), value)
+		if err == errStopIteration {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
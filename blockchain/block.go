@@ -2,88 +2,206 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/gob"
 	"fmt"
 	"io"
-	"math/big"
 	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
 )
 
 const (
-	checksumLength = 4
-	version        = byte(0x00)
+	// BlockVersion is the header version of newly created blocks
+	BlockVersion = 1
+
+	blockSignatureDomain = "iotchain/block-signature/v1"
+	blockSealDomain      = "iotchain/block-seal/v1"
 )
 
 // Block strcut
 type Block struct {
+	Version      int
 	PrevHash     []byte
 	Hash         []byte
+	MerkleRoot   []byte
+	Timestamp    int64
+	Height       int64
+	Difficulty   int
 	Nonce        int
 	Signature    []byte
 	Token        []byte
 	PublicKey    []byte
+	Sealer       []byte
+	Seal         []byte
 	Transactions []*Transaction
 }
 
-// Sign signs block
+// Sign signs the block header digest with privateKey
 func (block *Block) Sign(privateKey *ecdsa.PrivateKey) error {
-	var data []byte
-	for _, tx := range block.Transactions {
-		data = append(data, tx.Data...)
-	}
-
-	hash := sha256.Sum256(data)
-
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	signature, err := SignDigest(privateKey, block.SigningDigest())
 	if err != nil {
 		return err
 	}
 
-	block.Signature = append(r.Bytes(), s.Bytes()...)
+	block.Signature = signature
 	return nil
 }
 
 // VerifySignature verfies signature of the block
 func (block *Block) VerifySignature() bool {
-	r := big.Int{}
-	s := big.Int{}
-	r.SetBytes(block.Signature[:len(block.Signature)/2])
-	s.SetBytes(block.Signature[len(block.Signature)/2:])
+	return VerifyDigest(block.PublicKey, block.SigningDigest(), block.Signature)
+}
+
+// SigningDigest returns the digest a block signature commits to. It is
+// domain separated and bound to the network ID, so a signature is only valid
+// for this exact header on this chain.
+func (block *Block) SigningDigest() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, []byte(blockSignatureDomain))
+	writeField(&buffer, []byte(Params.NetworkID))
+	buffer.Write(block.unsignedHeaderBytes())
+
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
 
-	x := big.Int{}
-	y := big.Int{}
-	keyLen := len(block.PublicKey)
-	x.SetBytes(block.PublicKey[:(keyLen / 2)])
-	y.SetBytes(block.PublicKey[(keyLen / 2):])
+// SealDigest returns the digest a proof of authority seal commits to, the
+// signed header together with the sealer public key
+func (block *Block) SealDigest() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, []byte(blockSealDomain))
+	writeField(&buffer, []byte(Params.NetworkID))
+	buffer.Write(block.unsignedHeaderBytes())
+	writeField(&buffer, block.Signature)
+	writeField(&buffer, block.Sealer)
 
-	rawPublicKey := ecdsa.PublicKey{elliptic.P256(), &x, &y}
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
 
-	var data []byte
+// CheckLimits checks the block against the consensus size limits
+func CheckLimits(block *Block) error {
+	if len(block.Transactions) > Params.MaxTransactions {
+		return &ChainError{
+			StatusCode: ErrorTooManyTransactions,
+			Err:        fmt.Errorf("Block has %d transactions, at most %d allowed", len(block.Transactions), Params.MaxTransactions),
+		}
+	}
 	for _, tx := range block.Transactions {
-		data = append(data, tx.Data...)
+		if size := len(tx.Serialize()); size > Params.MaxTransactionBytes {
+			return &ChainError{
+				StatusCode: ErrorTransactionTooLarge,
+				Err:        fmt.Errorf("Transaction %X is %d bytes, at most %d allowed", tx.ID, size, Params.MaxTransactionBytes),
+			}
+		}
+	}
+	data, err := block.Serialize()
+	if err != nil {
+		return err
+	}
+	if len(data) > Params.MaxBlockBytes {
+		return &ChainError{
+			StatusCode: ErrorBlockTooLarge,
+			Err:        fmt.Errorf("Block is %d bytes, at most %d allowed", len(data), Params.MaxBlockBytes),
+		}
 	}
-	hash := sha256.Sum256(data)
+	return nil
+}
+
+// HeaderBytes returns the canonical encoding of the block header. It covers
+// every header field except Nonce and Hash, variable length fields are
+// prefixed with their length so two different headers never share an encoding.
+func (block *Block) HeaderBytes() []byte {
+	var buffer bytes.Buffer
+
+	buffer.Write(block.unsignedHeaderBytes())
+	writeField(&buffer, block.Signature)
+	writeField(&buffer, block.Sealer)
+	writeField(&buffer, block.Seal)
+
+	return buffer.Bytes()
+}
+
+// ComputeHash returns the hash the block must carry, the hash of the
+// header followed by the nonce
+func (block *Block) ComputeHash() []byte {
+	hash := sha256.Sum256(append(block.HeaderBytes(), ToHex(int64(block.Nonce))...))
+	return hash[:]
+}
 
-	validity := ecdsa.Verify(&rawPublicKey, hash[:], &r, &s)
-	return validity
+// unsignedHeaderBytes returns the canonical encoding of the header fields
+// covered by the block signature
+func (block *Block) unsignedHeaderBytes() []byte {
+	var buffer bytes.Buffer
+
+	buffer.Write(ToHex(int64(block.Version)))
+	writeField(&buffer, block.PrevHash)
+	writeField(&buffer, block.MerkleRoot)
+	buffer.Write(ToHex(block.Timestamp))
+	buffer.Write(ToHex(block.Height))
+	buffer.Write(ToHex(int64(block.Difficulty)))
+	writeField(&buffer, block.Token)
+	writeField(&buffer, block.PublicKey)
+
+	return buffer.Bytes()
+}
+
+// writeField writes a length prefixed byte field into buffer
+func writeField(buffer *bytes.Buffer, field []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+	buffer.Write(length[:])
+	buffer.Write(field)
 }
 
-// HashTransactions hashes all transaction using merkle tree
+// HashTransactions hashes all transaction IDs using merkle tree
 func (block *Block) HashTransactions() []byte {
 	var txHashes [][]byte
 	for _, tx := range block.Transactions {
-		txHashes = append(txHashes, tx.Serialize())
+		txHashes = append(txHashes, tx.ID)
 	}
 	tree := NewMerkleTree(txHashes)
 	return tree.RootNode.Data
 }
 
+// TransactionProof returns the index of the transaction with txID and its
+// merkle audit path up to the block merkle root
+func (block *Block) TransactionProof(txID []byte) (int, MerkleProof, error) {
+	var txHashes [][]byte
+	index := -1
+	for idx, tx := range block.Transactions {
+		txHashes = append(txHashes, tx.ID)
+		if bytes.Equal(tx.ID, txID) {
+			index = idx
+		}
+	}
+	if index == -1 {
+		return 0, nil, fmt.Errorf("Transaction %X not found in block %X", txID, block.Hash)
+	}
+	proof, err := NewMerkleTree(txHashes).GenerateProof(index)
+	if err != nil {
+		return 0, nil, err
+	}
+	return index, proof, nil
+}
+
+// Header returns a copy of the block without its transactions
+func (block *Block) Header() *Block {
+	header := *block
+	header.Transactions = nil
+	return &header
+}
+
 // Encrypt encrypts all transaction
 func (block *Block) Encrypt(passphrase []byte) {
 	cipherBlock, _ := aes.NewCipher([]byte(Hash(passphrase)))
@@ -121,20 +239,24 @@ func (block *Block) Decrypt(passphrase []byte) {
 	}
 }
 
-// Serialize serialiszes block
+// Serialize serialiszes block into its protobuf message
 func (block *Block) Serialize() ([]byte, error) {
-	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
+	return marshalDeterministic(block.Proto())
+}
+
+// Deserialize deserializes block from its protobuf message
+func Deserialize(data []byte) (*Block, error) {
+	var msg BlockMessage
 
-	err := encoder.Encode(block)
+	err := proto.Unmarshal(data, &msg)
 	if err != nil {
-		return []byte{}, err
+		return &Block{}, err
 	}
-	return buffer.Bytes(), nil
+	return BlockFromProto(&msg), nil
 }
 
-// Deserialize deserializes block
-func Deserialize(data []byte) (*Block, error) {
+// deserializeGob deserializes a block stored in the legacy gob encoding
+func deserializeGob(data []byte) (*Block, error) {
 	var block Block
 
 	decoder := gob.NewDecoder(bytes.NewReader(data))
@@ -151,7 +273,7 @@ func Address(token []byte) ([]byte, error) {
 	if err != nil {
 		return []byte{}, err
 	}
-	versionedHash := append([]byte{version}, pubHash...)
+	versionedHash := append([]byte{Params.AddressVersion}, pubHash...)
 	checksum := Checksum(versionedHash)
 
 	fullHash := append(versionedHash, checksum...)
@@ -168,23 +290,32 @@ func (block *Block) IsGenesis() bool {
 	return false
 }
 
-// NewGenesisBlock crreates and returns a new genesis block
-func NewGenesisBlock(token []byte, privateKey *ecdsa.PrivateKey) (*Block, error) {
-	trans := Transaction{
-		Data: []byte("Genesis Transaction"),
-	}
+// NewGenesisBlock crreates and returns a new genesis block sealed by the
+// consensus engine of chain
+func NewGenesisBlock(ctx context.Context, chain *BlockChain, token []byte, privateKey *ecdsa.PrivateKey) (*Block, error) {
+	trans := NewTransaction(TransactionTypeGenesis, ContentTypeRaw, []byte("Genesis Transaction"), nil)
 	block := Block{
-		Transactions: []*Transaction{&trans},
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Height:       0,
+		Transactions: []*Transaction{trans},
 		Token:        token,
+		PublicKey:    EncodePublicKey(&privateKey.PublicKey),
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	err := chain.Engine.Prepare(chain, &block)
+	if err != nil {
+		return nil, err
+	}
+	err = block.Sign(privateKey)
+	if err != nil {
+		return nil, err
 	}
-	err := block.Sign(privateKey)
+	err = chain.Engine.Seal(ctx, chain, &block)
 	if err != nil {
 		return nil, err
 	}
-	pow := NewProof(&block)
-	nonce, hash := pow.Run()
-	block.Nonce = nonce
-	block.Hash = hash
 
 	return &block, nil
 }
@@ -194,16 +325,25 @@ func (block Block) String() string {
 	var values []string
 
 	values = append(values, fmt.Sprintf("\n----Block: "))
+	values = append(values, fmt.Sprintf(" Version   : %d", block.Version))
+	values = append(values, fmt.Sprintf(" Height    : %d", block.Height))
+	values = append(values, fmt.Sprintf(" Timestamp : %s", time.Unix(0, block.Timestamp).UTC().Format(time.RFC3339Nano)))
 	values = append(values, fmt.Sprintf(" PrevHash  : %X", block.PrevHash))
 	values = append(values, fmt.Sprintf(" Hash      : %X", block.Hash))
+	values = append(values, fmt.Sprintf(" Merkle    : %X", block.MerkleRoot))
+	values = append(values, fmt.Sprintf(" Difficulty: %d", block.Difficulty))
 	values = append(values, fmt.Sprintf(" Nounce    : %d", block.Nonce))
 	values = append(values, fmt.Sprintf(" Signature : %X", block.Signature))
 	values = append(values, fmt.Sprintf(" Token     : %X", block.Token))
 	values = append(values, fmt.Sprintf(" PublicKey : %X", block.PublicKey))
+	if len(block.Sealer) != 0 {
+		values = append(values, fmt.Sprintf(" Sealer    : %X", block.Sealer))
+		values = append(values, fmt.Sprintf(" Seal      : %X", block.Seal))
+	}
 
-	// for idx := range block.Transactions {
-	// 	values = append(values, fmt.Sprintf("   ├──Transaction  : %s", string(block.Transactions[idx].Data)))
-	// }
 	values = append(values, fmt.Sprintf(" Transactions(count): %v", len(block.Transactions)))
+	for _, tx := range block.Transactions {
+		values = append(values, fmt.Sprintf("   ├──Transaction  : %s", tx))
+	}
 	return strings.Join(values, "\n")
 }
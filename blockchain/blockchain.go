@@ -1,12 +1,13 @@
 package blockchain
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"os"
 	"time"
 
-	"github.com/dgraph-io/badger"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,40 +18,111 @@ var (
 
 // BlockChain structure
 type BlockChain struct {
-	Database *badger.DB
+	Store  ChainStore
+	Engine Engine
+	// NodeKey signs evidence of equivocating devices, nil on clients
+	NodeKey *ecdsa.PrivateKey
 }
 
-//Iterator structure
-type Iterator struct {
-	CurrentHash []byte
-	Database    *badger.DB
+// NewBlockChain returns a chain kept in store, sealed with proof of work
+func NewBlockChain(store ChainStore) *BlockChain {
+	return &BlockChain{Store: store, Engine: ProofOfWorkEngine{}}
 }
 
-var (
-	// Chain holds chain in memory
-	Chain *BlockChain
-)
-
-// InitBlockChain initiates blockchain
+// InitBlockChain initiates blockchain in the badger database at dbPath, a
+// database with an older key layout is migrated first
 func InitBlockChain(dbPath string) (*BlockChain, error) {
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
-
-	db, err := badger.Open(opts)
+	store, err := OpenBadgerStore(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	err = MigrateSchema(store)
 	if err != nil {
+		store.Close()
 		return nil, err
 	}
+	return NewBlockChain(store), nil
+}
 
-	chain := BlockChain{Database: db}
+// Close closes the store of the chain
+func (chain *BlockChain) Close() error {
+	return chain.Store.Close()
+}
 
-	return &chain, nil
+// checkHeader checks the header fields that don't depend on the parent block
+func checkHeader(block *Block) error {
+	if block.Version != BlockVersion {
+		return &ChainError{
+			StatusCode: ErrorInvalidVersion,
+			Err:        fmt.Errorf("Unsupported block version %d", block.Version),
+		}
+	}
+	if block.Timestamp <= 0 {
+		return &ChainError{
+			StatusCode: ErrorInvalidTimestamp,
+			Err:        errors.New("Block timestamp is not set"),
+		}
+	}
+	// the merkle tree pads odd levels with their last node, a repeated
+	// trailing transaction would keep the merkle root
+	seen := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		if err := tx.Validate(); err != nil {
+			return &ChainError{
+				StatusCode: ErrorInvalidTransaction,
+				Err:        fmt.Errorf("Transaction %X: %v", tx.ID, err),
+			}
+		}
+		if seen[string(tx.ID)] {
+			return &ChainError{
+				StatusCode: ErrorInvalidTransaction,
+				Err:        fmt.Errorf("Transaction %X appears twice", tx.ID),
+			}
+		}
+		seen[string(tx.ID)] = true
+	}
+	if !bytes.Equal(block.MerkleRoot, block.HashTransactions()) {
+		return &ChainError{
+			StatusCode: ErrorInvalidMerkleRoot,
+			Err:        errors.New("Merkle root doesn't match transactions"),
+		}
+	}
+	return nil
+}
+
+// checkParent checks the header fields of block against its parent block
+func checkParent(block, parent *Block) error {
+	if block.Height != parent.Height+1 {
+		return &ChainError{
+			StatusCode: ErrorInvalidHeight,
+			Err:        fmt.Errorf("Block height %d doesn't follow parent height %d", block.Height, parent.Height),
+		}
+	}
+	if block.Timestamp < parent.Timestamp {
+		return &ChainError{
+			StatusCode: ErrorInvalidTimestamp,
+			Err:        errors.New("Block timestamp is earlier than parent timestamp"),
+		}
+	}
+	return nil
 }
 
 // AddBlock adds a block to the chain
-// 1. chekcs signature
-// 2. checks pow
-// 3. checks existance of previous hash
+// 1. checks size limits
+// 2. chekcs signature
+// 3. checks header and that the timestamp isn't too far in the future
+// 4. checks existance of previous hash and position after it, and that the
+// timestamp isn't earlier than its parent or the median of the parent chain
+// 5. checks the seal with the consensus engine
+// 6. moves the tip of the device chain when the block wins the fork choice
+// A sibling signed by the same device key with different content is
+// reported as equivocation.
 func (chain *BlockChain) AddBlock(block *Block) error {
+	err := CheckLimits(block)
+	if err != nil {
+		return err
+	}
+
 	valid := block.VerifySignature()
 	if !valid {
 		return &ChainError{
@@ -59,18 +131,19 @@ func (chain *BlockChain) AddBlock(block *Block) error {
 		}
 	}
 
-	pow := NewProof(block)
-	valid = pow.Validate()
-	if !valid {
-		logrus.Warn("pow is not valid")
-		return &ChainError{
-			StatusCode: ErrorInvalidProofOfWork,
-			Err:        errors.New("Invalid proof of work"),
-		}
+	err = checkHeader(block)
+	if err != nil {
+		return err
 	}
+	err = checkFutureTimestamp(block)
+	if err != nil {
+		return err
+	}
+
+	var conflict *Block
 	for {
-		err := chain.Database.View(func(txn *badger.Txn) error {
-			if _, err := txn.Get(block.Hash); err == badger.ErrKeyNotFound {
+		err := chain.Store.View(func(txn StoreTxn) error {
+			if _, err := txn.Get(blockKey(block.Hash)); err == ErrKeyNotFound {
 				return nil
 			}
 
@@ -79,14 +152,33 @@ func (chain *BlockChain) AddBlock(block *Block) error {
 		if err != nil {
 			return err
 		}
-		err = chain.Database.Update(func(txn *badger.Txn) error {
+		err = chain.Store.Update(func(txn StoreTxn) error {
 			address, err := Address(block.Token)
 			if err != nil {
 				return err
 			}
 
-			_, err = txn.Get(block.PrevHash)
-			if err == badger.ErrKeyNotFound {
+			reader := blockGetter(func(hash []byte) (*Block, error) {
+				return getBlockTxn(txn, hash)
+			})
+			parent, err := reader.GetBlock(block.PrevHash)
+			if err != nil {
+				return err
+			}
+			err = checkParent(block, parent)
+			if err != nil {
+				return err
+			}
+			err = checkMedianTime(reader, block, parent)
+			if err != nil {
+				return err
+			}
+			err = chain.Engine.Verify(reader, block)
+			if err != nil {
+				return err
+			}
+			err = checkFinalityTxn(txn, address, block)
+			if err != nil {
 				return err
 			}
 
@@ -95,29 +187,39 @@ func (chain *BlockChain) AddBlock(block *Block) error {
 				return err
 			}
 
-			err = txn.Set(block.Hash, data)
+			err = txn.Set(blockKey(block.Hash), data)
 			if err != nil {
 				return err
 			}
-
-			err = txn.Set(address, block.Hash)
+			err = countBlockTxn(txn, address, len(data))
+			if err != nil {
+				return err
+			}
+			err = indexBlockTxn(txn, address, block)
 			if err != nil {
 				return err
 			}
 
-			return nil
+			conflict, err = findEquivocationTxn(txn, block)
+			if err != nil {
+				return err
+			}
+			return connectBlockTxn(txn, address, block)
 		})
 
-		if err == badger.ErrConflict {
+		if err == ErrTxnConflict {
 			time.Sleep(time.Duration(time.Millisecond * 500))
 			continue
 		}
-		if err == badger.ErrKeyNotFound {
+		if err == ErrKeyNotFound {
 			return &ChainError{
 				StatusCode: ErrorPreviousHashNotFound,
 				Err:        errors.New("Previous hash not found"),
 			}
 		}
+		if cErr, ok := err.(*ChainError); ok {
+			return cErr
+		}
 		if err != nil {
 			return &ChainError{
 				StatusCode: ErrorUnknown,
@@ -127,6 +229,9 @@ func (chain *BlockChain) AddBlock(block *Block) error {
 		break
 	}
 	logrus.Infoln("Added block to local chain")
+	if conflict != nil {
+		chain.reportEquivocation(conflict, block)
+	}
 	return nil
 }
 
@@ -138,23 +243,37 @@ func (chain *BlockChain) AddGenesis(genesis *Block) error {
 			Err:        errors.New("Block is not genesis"),
 		}
 	}
-	pow := NewProof(genesis)
-	valid := pow.Validate()
-	if !valid {
+	if genesis.Height != 0 {
 		return &ChainError{
-			StatusCode: ErrorInvalidProofOfWork,
-			Err:        errors.New("Invalid proof of work"),
+			StatusCode: ErrorInvalidHeight,
+			Err:        fmt.Errorf("Genesis height must be 0, got %d", genesis.Height),
 		}
 	}
+	err := CheckLimits(genesis)
+	if err != nil {
+		return err
+	}
+	err = checkHeader(genesis)
+	if err != nil {
+		return err
+	}
+	err = checkFutureTimestamp(genesis)
+	if err != nil {
+		return err
+	}
+	err = chain.Engine.Verify(chain, genesis)
+	if err != nil {
+		return err
+	}
 
 	for {
-		err := chain.Database.Update(func(txn *badger.Txn) error {
+		err := chain.Store.Update(func(txn StoreTxn) error {
 			address, err := Address(genesis.Token)
 			if err != nil {
 				return err
 			}
 
-			_, err = txn.Get(address)
+			_, err = txn.Get(tipKey(address))
 			if err == nil {
 				return &ChainError{
 					StatusCode: ErrorGenesisExists,
@@ -167,24 +286,29 @@ func (chain *BlockChain) AddGenesis(genesis *Block) error {
 				return err
 			}
 
-			err = txn.Set(genesis.Hash, data)
+			err = txn.Set(blockKey(genesis.Hash), data)
 			if err != nil {
 				return err
 			}
-
-			err = txn.Set(address, genesis.Hash)
+			err = countBlockTxn(txn, address, len(data))
+			if err != nil {
+				return err
+			}
+			err = indexBlockTxn(txn, address, genesis)
 			if err != nil {
 				return err
 			}
 
-			return nil
+			return connectBlockTxn(txn, address, genesis)
 		})
 
-		if err == badger.ErrConflict {
+		if err == ErrTxnConflict {
 			time.Sleep(time.Duration(time.Millisecond * 500))
 			continue
 		}
-
+		if cErr, ok := err.(*ChainError); ok {
+			return cErr
+		}
 		if err != nil {
 			return &ChainError{
 				StatusCode: ErrorUnknown,
@@ -196,123 +320,36 @@ func (chain *BlockChain) AddGenesis(genesis *Block) error {
 	return nil
 }
 
-// FullHeight returns blockchain height
+// FullHeight returns the number of stored blocks
 func (chain *BlockChain) FullHeight() int64 {
-	height := int64(0)
-
-	chain.Database.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			height++
-		}
-		return nil
-	})
-	return height
-}
-
-// Height retunrs height of a chain
-func (chain *BlockChain) Height(token []byte) (int64, error) {
-	height := int64(0)
-	addr, err := Address(token)
+	stats, err := chain.Stats(nil)
 	if err != nil {
-		return 0, err
+		return 0
 	}
+	return stats.Blocks
+}
 
-	keyfound := true
-	var lastHash []byte
-
-	err = chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(addr)
-		if err == badger.ErrKeyNotFound {
-			keyfound = false
-			return nil
-		}
-		err = item.Value(func(val []byte) error {
-			lastHash = val
-			return nil
-		})
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+// Height retunrs height of a chain, read from the counters kept with the
+// device chain
+func (chain *BlockChain) Height(token []byte) (int64, error) {
+	stats, err := chain.Stats(token)
 	if err != nil {
 		return 0, err
 	}
-	if keyfound == false {
-		return 0, nil
-	}
-
-	itr := Iterator{CurrentHash: lastHash, Database: chain.Database}
-
-	for {
-		block := itr.Next()
-		if block == nil {
-			break
-		}
-
-		height++
-
-		if len(block.PrevHash) == 0 {
-			break
-		}
-	}
-	return height, nil
+	return stats.Height, nil
 }
 
-// Chain retunrs chain of a token
+// Chain retunrs chain of a token, from the tip back to genesis
 func (chain *BlockChain) Chain(token []byte) ([]*Block, error) {
 	blockList := []*Block{}
-	addr, err := Address(token)
-	if err != nil {
-		return blockList, err
-	}
-
-	keyfound := true
-	var lastHash []byte
-
-	err = chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(addr)
-		if err == badger.ErrKeyNotFound {
-			keyfound = false
-			return nil
-		}
-		err = item.Value(func(val []byte) error {
-			lastHash = val
-			return nil
-		})
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+	itr, err := chain.NewIterator(token, Backward)
 	if err != nil {
 		return blockList, err
 	}
-	if keyfound == false {
-		return blockList, nil
+	for itr.Next() {
+		blockList = append(blockList, itr.Block())
 	}
-
-	itr := Iterator{CurrentHash: lastHash, Database: chain.Database}
-
-	for {
-		block := itr.Next()
-		if block == nil {
-			break
-		}
-
-		blockList = append(blockList, block)
-
-		if len(block.PrevHash) == 0 {
-			break
-		}
-	}
-	return blockList, nil
+	return blockList, itr.Err()
 }
 
 // LastHash returns last hash
@@ -322,19 +359,13 @@ func (chain *BlockChain) LastHash(token []byte) ([]byte, error) {
 		return []byte{}, err
 	}
 	var lastHash []byte
-	err = chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(addr)
-		if err == badger.ErrKeyNotFound {
+	err = chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		lastHash, err = txn.Get(tipKey(addr))
+		if err == ErrKeyNotFound {
 			return errors.New("Last Hash not found, you need to sync your localchain from a miner")
 		}
-		err = item.Value(func(val []byte) error {
-			lastHash = val
-			return nil
-		})
-		if err != nil {
-			return err
-		}
-		return nil
+		return err
 	})
 	if err != nil {
 		return []byte{}, err
@@ -342,42 +373,57 @@ func (chain *BlockChain) LastHash(token []byte) ([]byte, error) {
 	return lastHash, nil
 }
 
-// ClearDB clear Blockchain Database
-func ClearDB() error {
-	err := os.Remove(DBPATH + "MANIFEST")
+// GetBlock returns the block stored under hash
+func (chain *BlockChain) GetBlock(hash []byte) (*Block, error) {
+	return chain.Store.GetBlock(hash)
+}
+
+// getBlockTxn returns the block stored under hash within txn
+func getBlockTxn(txn StoreTxn, hash []byte) (*Block, error) {
+	data, err := txn.Get(blockKey(hash))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return Deserialize(data)
 }
 
-// Next returns next block
-func (itr *Iterator) Next() *Block {
-	var block *Block
+// MigrateEncoding rewrites blocks stored in the legacy gob encoding into
+// their protobuf message and returns the number of migrated blocks. Blocks
+// already in protobuf and tip pointers are left untouched.
+func (chain *BlockChain) MigrateEncoding() (int, error) {
+	var legacy []*Block
 
-	err := itr.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(itr.CurrentHash)
-		if err != nil {
-			return err
+	err := chain.Store.Iterate(blockPrefix, func(key, value []byte) error {
+		block, err := deserializeGob(value)
+		if err == nil && bytes.Equal(blockKey(block.Hash), key) {
+			legacy = append(legacy, block)
 		}
-		var encodedBlock []byte
-		err = item.Value(func(val []byte) error {
-			encodedBlock = val
-			return nil
-		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, block := range legacy {
+		err = chain.Store.PutBlock(block)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		block, err = Deserialize(encodedBlock)
+	}
+	if len(legacy) > 0 {
+		err = chain.RebuildStats()
 		if err != nil {
-			return err
+			return 0, err
 		}
-		return nil
-	})
+	}
+	return len(legacy), nil
+}
 
+// ClearDB clear Blockchain Database
+func ClearDB() error {
+	err := os.Remove(DBPATH + "MANIFEST")
 	if err != nil {
-		return nil
+		return err
 	}
-	itr.CurrentHash = block.PrevHash
-	return block
+	return nil
 }
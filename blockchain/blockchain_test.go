@@ -2,13 +2,25 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
 )
 
 func TestSerializeDeserialize(t *testing.T) {
@@ -59,6 +71,53 @@ func TestKey(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error Not expected! Error: %v\n", err)
 	}
+
+	loadedKey, err := LoadKey(keyPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if loadedKey.PrivateKey.D.Cmp(key.PrivateKey.D) != 0 || !bytes.Equal(loadedKey.PublicKey, key.PublicKey) {
+		t.Fatal("Loaded key should match saved key")
+	}
+
+	// key files written as a gob of Key are read and rewritten
+	legacy := *key
+	legacyPrivateKey := *key.PrivateKey
+	legacyPrivateKey.Curve = legacyCurve{elliptic.P256().Params()}
+	legacy.PrivateKey = &legacyPrivateKey
+	legacy.Token = []byte("legacy token")
+	gob.RegisterName("elliptic.p256Curve", legacyCurve{})
+	var buffer bytes.Buffer
+	err = gob.NewEncoder(&buffer).Encode(&legacy)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = ioutil.WriteFile(keyPath, buffer.Bytes(), 0644)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	for i := 0; i < 2; i++ {
+		loadedKey, err = LoadKey(keyPath)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if loadedKey.PrivateKey.D.Cmp(key.PrivateKey.D) != 0 || !bytes.Equal(loadedKey.Token, legacy.Token) {
+			t.Fatal("Loaded legacy key should match saved key")
+		}
+	}
+	var file keyFile
+	content, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&file); err != nil {
+		t.Fatalf("Legacy key file should be rewritten, got %v", err)
+	}
+}
+
+// legacyCurve stands in for the curve type older key files were written with
+type legacyCurve struct {
+	*elliptic.CurveParams
 }
 
 func TestProof(t *testing.T) {
@@ -67,6 +126,7 @@ func TestProof(t *testing.T) {
 	}
 
 	block := Block{
+		Difficulty:   Params.Difficulty,
 		Transactions: []*Transaction{&trans},
 	}
 	pow := NewProof(&block)
@@ -171,28 +231,33 @@ func TestAddBlock(t *testing.T) {
 
 	key.Token = token
 
-	trans := Transaction{
-		Data: []byte("Hello Transaction"),
-	}
-	genesisTransaction := Transaction{
-		Data: []byte("Genesis Transaction"),
-	}
+	trans := NewTransaction(TransactionTypeReading, ContentTypeJSON, []byte(`{"temperature":21.5}`), map[string]string{"unit": "celsius"})
+	genesisTransaction := NewTransaction(TransactionTypeGenesis, ContentTypeRaw, []byte("Genesis Transaction"), nil)
 
 	genesisBlock := Block{
-		Transactions: []*Transaction{&genesisTransaction},
+		Difficulty:   Params.Difficulty,
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: []*Transaction{genesisTransaction},
 		Token:        key.Token,
 	}
+	genesisBlock.MerkleRoot = genesisBlock.HashTransactions()
 	pow := NewProof(&genesisBlock)
 	nonce, hash := pow.Run()
 	genesisBlock.Nonce = nonce
 	genesisBlock.Hash = hash
 
 	block := Block{
-		Transactions: []*Transaction{&trans},
+		Difficulty:   Params.Difficulty,
+		Version:      BlockVersion,
+		Timestamp:    genesisBlock.Timestamp + 1,
+		Height:       1,
+		Transactions: []*Transaction{trans},
 		Token:        key.Token,
 		PublicKey:    key.PublicKey,
 		PrevHash:     hash,
 	}
+	block.MerkleRoot = block.HashTransactions()
 	block.Sign(key.PrivateKey)
 	pow = NewProof(&block)
 	nonce, hash = pow.Run()
@@ -203,7 +268,7 @@ func TestAddBlock(t *testing.T) {
 	if err != nil {
 		t.Fatal("Init chain Error is unexpected ", err)
 	}
-	defer chain.Database.Close()
+	defer chain.Close()
 
 	err = chain.AddGenesis(&genesisBlock)
 	if err != nil {
@@ -216,3 +281,2760 @@ func TestAddBlock(t *testing.T) {
 		t.Fatalf("Error is unexpected: %v\n", err.Error())
 	}
 }
+
+func TestProofCoversHeader(t *testing.T) {
+	trans := Transaction{
+		Data: []byte("Hello World"),
+	}
+
+	block := Block{
+		Difficulty:   Params.Difficulty,
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: []*Transaction{&trans},
+		Token:        []byte("token"),
+		PublicKey:    []byte("pubkey"),
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	pow := NewProof(&block)
+	nonce, hash := pow.Run()
+	block.Nonce = nonce
+	block.Hash = hash
+
+	if !bytes.Equal(pow.Hash(), block.Hash) {
+		t.Fatal("Mined hash should match block header")
+	}
+
+	block.Token = []byte("other token")
+	if bytes.Equal(pow.Hash(), block.Hash) {
+		t.Fatal("Changing token should change the header hash")
+	}
+	block.Token = []byte("token")
+
+	block.PublicKey = []byte("other pubkey")
+	if bytes.Equal(pow.Hash(), block.Hash) {
+		t.Fatal("Changing public key should change the header hash")
+	}
+}
+
+func newSignedBlock(t *testing.T) (*Block, *Key) {
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	trans := Transaction{
+		Data: []byte("Hello Transaction"),
+	}
+	block := Block{
+		Difficulty:   Params.Difficulty,
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Height:       1,
+		PrevHash:     []byte("prevhash"),
+		Transactions: []*Transaction{&trans},
+		Token:        []byte("token"),
+		PublicKey:    key.PublicKey,
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	err = block.Sign(key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	return &block, key
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		block, _ := newSignedBlock(t)
+
+		if len(block.Signature) != SignatureLength {
+			t.Fatalf("Signature length %d expected %d", len(block.Signature), SignatureLength)
+		}
+		if len(block.PublicKey) != PublicKeyLength {
+			t.Fatalf("Public key length %d expected %d", len(block.PublicKey), PublicKeyLength)
+		}
+		if !block.VerifySignature() {
+			t.Fatal("Signature should be valid")
+		}
+	}
+}
+
+func TestSignatureCoversHeader(t *testing.T) {
+	block, _ := newSignedBlock(t)
+
+	block.PrevHash = []byte("otherhash")
+	if block.VerifySignature() {
+		t.Fatal("Signature should not be valid for another PrevHash")
+	}
+	block.PrevHash = []byte("prevhash")
+
+	block.Timestamp++
+	if block.VerifySignature() {
+		t.Fatal("Signature should not be valid for another timestamp")
+	}
+	block.Timestamp--
+
+	block.MerkleRoot = make([]byte, 32)
+	if block.VerifySignature() {
+		t.Fatal("Signature should not be valid for another merkle root")
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	defer func(networkID string) { Params.NetworkID = networkID }(Params.NetworkID)
+	Params.NetworkID = "other-chain"
+	if block.VerifySignature() {
+		t.Fatal("Signature should not be valid on another chain")
+	}
+}
+
+func TestMalformedSignature(t *testing.T) {
+	block, _ := newSignedBlock(t)
+	signature := block.Signature
+
+	for _, length := range []int{0, 1, SignatureLength/2 - 1, SignatureLength - 1, SignatureLength + 1, 2 * SignatureLength} {
+		malformed := make([]byte, length)
+		copy(malformed, signature)
+		block.Signature = malformed
+		if block.VerifySignature() {
+			t.Fatalf("Signature of length %d should not be valid", length)
+		}
+	}
+
+	block.Signature = nil
+	if block.VerifySignature() {
+		t.Fatal("Missing signature should not be valid")
+	}
+
+	// the high s twin of a valid signature is rejected
+	n := elliptic.P256().Params().N
+	s := new(big.Int).SetBytes(signature[SignatureLength/2:])
+	highS := fixedBytes(new(big.Int).Sub(n, s), SignatureLength/2)
+	block.Signature = append(append([]byte{}, signature[:SignatureLength/2]...), highS...)
+	if block.VerifySignature() {
+		t.Fatal("High s signature should not be valid")
+	}
+
+	block.Signature = signature
+	if !block.VerifySignature() {
+		t.Fatal("Signature should be valid")
+	}
+}
+
+func TestMalformedPublicKey(t *testing.T) {
+	block, key := newSignedBlock(t)
+
+	for _, length := range []int{0, 1, PublicKeyLength/2 - 1, PublicKeyLength - 1, PublicKeyLength + 1, 2 * PublicKeyLength} {
+		malformed := make([]byte, length)
+		copy(malformed, key.PublicKey)
+		if _, err := DecodePublicKey(malformed); err == nil {
+			t.Fatalf("Public key of length %d should not decode", length)
+		}
+		if VerifyDigest(malformed, block.SigningDigest(), block.Signature) {
+			t.Fatalf("Public key of length %d should not verify", length)
+		}
+	}
+
+	offCurve := append([]byte{}, key.PublicKey...)
+	offCurve[PublicKeyLength-1] ^= 0x01
+	if _, err := DecodePublicKey(offCurve); err == nil {
+		t.Fatal("Public key off the curve should not decode")
+	}
+	if VerifyDigest(offCurve, block.SigningDigest(), block.Signature) {
+		t.Fatal("Public key off the curve should not verify")
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	tx := NewTransaction(TransactionTypeEvent, ContentTypeJSON, []byte(`{"door":"open"}`), map[string]string{"room": "lab", "floor": "2"})
+
+	if err := tx.Validate(); err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	copied := *tx
+	copied.Metadata = map[string]string{"floor": "2", "room": "lab"}
+	if !bytes.Equal(copied.Hash(), tx.ID) {
+		t.Fatal("Transaction ID should not depend on metadata order")
+	}
+
+	copied.Metadata = map[string]string{"floor": "3", "room": "lab"}
+	if err := copied.Validate(); err == nil {
+		t.Fatal("Tampered metadata should not validate")
+	}
+
+	copied = *tx
+	copied.Data = []byte(`{"door":"closed"}`)
+	if err := copied.Validate(); err == nil {
+		t.Fatal("Tampered data should not validate")
+	}
+
+	copied = *tx
+	copied.ContentType = "xml"
+	copied.ID = copied.Hash()
+	if err := copied.Validate(); err == nil {
+		t.Fatal("Unknown content type should not validate")
+	}
+
+	// a repeated trailing transaction keeps the merkle root but is refused
+	var txs []*Transaction
+	for i := 0; i < 3; i++ {
+		txs = append(txs, NewTransaction(TransactionTypeReading, ContentTypeRaw, []byte(fmt.Sprint(i)), nil))
+	}
+	block := Block{Version: BlockVersion, Timestamp: time.Now().UnixNano(), Transactions: txs}
+	block.MerkleRoot = block.HashTransactions()
+	if err := checkHeader(&block); err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	padded := block
+	padded.Transactions = append(append([]*Transaction{}, txs...), txs[2])
+	if !bytes.Equal(padded.HashTransactions(), block.MerkleRoot) {
+		t.Fatal("Expected the padded transactions to share the merkle root")
+	}
+	err := checkHeader(&padded)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidTransaction {
+		t.Fatalf("Repeated transaction should be rejected, got %v", err)
+	}
+}
+
+func TestMerkleProof(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		var leaves [][]byte
+		for i := 0; i < size; i++ {
+			leaves = append(leaves, []byte(fmt.Sprintf("leaf %d", i)))
+		}
+		tree := NewMerkleTree(leaves)
+
+		for index, leaf := range leaves {
+			proof, err := tree.GenerateProof(index)
+			if err != nil {
+				t.Fatalf("Error Not expected! Error: %v\n", err)
+			}
+			if !VerifyProof(tree.RootNode.Data, leaf, index, size, proof) {
+				t.Fatalf("Proof of leaf %d in tree of %d should verify", index, size)
+			}
+			if VerifyProof(tree.RootNode.Data, []byte("other leaf"), index, size, proof) {
+				t.Fatalf("Proof of leaf %d in tree of %d should not verify another leaf", index, size)
+			}
+			if size > 1 && VerifyProof(tree.RootNode.Data, leaf, (index+1)%size, size, proof) {
+				t.Fatalf("Proof of leaf %d in tree of %d should not verify at another index", index, size)
+			}
+			if VerifyProof(tree.RootNode.Data, leaf, index, 2*size, proof) {
+				t.Fatalf("Proof of leaf %d in tree of %d should not verify in a deeper tree", index, size)
+			}
+			if len(proof) > 0 {
+				if VerifyProof(tree.RootNode.Data, leaf, index, size, proof[:len(proof)-1]) {
+					t.Fatalf("Truncated proof of leaf %d in tree of %d should not verify", index, size)
+				}
+				proof[0].Hash = make([]byte, len(proof[0].Hash))
+				if VerifyProof(tree.RootNode.Data, leaf, index, size, proof) {
+					t.Fatalf("Tampered proof of leaf %d in tree of %d should not verify", index, size)
+				}
+			}
+		}
+
+		if _, err := tree.GenerateProof(size); err == nil {
+			t.Fatalf("Proof of leaf %d in tree of %d should fail", size, size)
+		}
+	}
+
+	// an inner node doesn't hash like a leaf holding its children
+	left := NewMerkleNode(nil, nil, []byte("left"))
+	right := NewMerkleNode(nil, nil, []byte("right"))
+	inner := NewMerkleNode(left, right, nil)
+	if bytes.Equal(inner.Data, NewMerkleNode(nil, nil, append(append([]byte{}, left.Data...), right.Data...)).Data) {
+		t.Fatal("Inner node should not hash like a leaf")
+	}
+}
+
+func TestSerializeDeterministic(t *testing.T) {
+	metadata := make(map[string]string)
+	for i := 0; i < MaxMetadataEntries; i++ {
+		metadata[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	block, _ := newSignedBlock(t)
+	block.Transactions = []*Transaction{NewTransaction(TransactionTypeReading, ContentTypeRaw, []byte("data"), metadata)}
+
+	first, err := block.Serialize()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	for i := 0; i < 16; i++ {
+		data, err := block.Serialize()
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if !bytes.Equal(first, data) {
+			t.Fatal("Serialized block should be deterministic")
+		}
+	}
+
+	blockPrime, err := Deserialize(first)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(blockPrime.HeaderBytes(), block.HeaderBytes()) {
+		t.Fatal("Deserialized header should match")
+	}
+	if !bytes.Equal(blockPrime.Transactions[0].Hash(), block.Transactions[0].ID) {
+		t.Fatal("Deserialized transaction should match its ID")
+	}
+}
+
+func TestMigrateEncoding(t *testing.T) {
+	dbPath := "tmp_migrate"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	block, _ := newSignedBlock(t)
+	block.Hash = []byte("legacy block hash")
+
+	var legacy bytes.Buffer
+	err := gob.NewEncoder(&legacy).Encode(block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		return txn.Set(blockKey(block.Hash), legacy.Bytes())
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	migrated, err := chain.MigrateEncoding()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if migrated != 1 {
+		t.Fatalf("Migrated %d blocks expected 1", migrated)
+	}
+
+	stored, err := chain.GetBlock(block.Hash)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(stored.HeaderBytes(), block.HeaderBytes()) {
+		t.Fatal("Migrated block should match legacy block")
+	}
+
+	migrated, err = chain.MigrateEncoding()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if migrated != 0 {
+		t.Fatalf("Migrated %d blocks expected 0", migrated)
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	block, _ := newSignedBlock(t)
+	if err := CheckLimits(block); err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	expectStatus := func(err error, status int) {
+		cErr, ok := err.(*ChainError)
+		if !ok || cErr.StatusCode != status {
+			t.Fatalf("Expected status %d got %v", status, err)
+		}
+	}
+
+	defer func(params ChainParams) { *Params = params }(*Params)
+
+	Params.MaxTransactions = 0
+	expectStatus(CheckLimits(block), ErrorTooManyTransactions)
+	Params.MaxTransactions = 1
+
+	Params.MaxTransactionBytes = 8
+	expectStatus(CheckLimits(block), ErrorTransactionTooLarge)
+	Params.MaxTransactionBytes = 1 << 20
+
+	Params.MaxBlockBytes = 64
+	expectStatus(CheckLimits(block), ErrorBlockTooLarge)
+}
+
+func TestChainParams(t *testing.T) {
+	paramsPath := "params_test.json"
+	defer func() {
+		os.Remove(paramsPath)
+	}()
+
+	err := ioutil.WriteFile(paramsPath, []byte(`{"networkId": "test-net", "difficulty": 8}`), 0644)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	params, err := LoadChainParams(paramsPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if params.NetworkID != "test-net" || params.Difficulty != 8 {
+		t.Fatal("Loaded parameters should match the file")
+	}
+	if params.MaxBlockBytes != DefaultChainParams().MaxBlockBytes {
+		t.Fatal("Missing parameters should keep their default")
+	}
+	if bytes.Equal(params.Hash(), DefaultChainParams().Hash()) {
+		t.Fatal("Different parameters should have different hashes")
+	}
+
+	err = params.SaveFile(paramsPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	reloaded, err := LoadChainParams(paramsPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(reloaded.Hash(), params.Hash()) {
+		t.Fatal("Saved parameters should load back unchanged")
+	}
+
+	err = ioutil.WriteFile(paramsPath, []byte(`{"networkId": "", "difficulty": 8}`), 0644)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if _, err := LoadChainParams(paramsPath); err == nil {
+		t.Fatal("Parameters without network ID should not load")
+	}
+}
+
+func TestNextDifficulty(t *testing.T) {
+	window := Params.RetargetWindow
+	interval := Params.TargetBlockInterval * int64(time.Millisecond)
+
+	buildChain := func(spacing int64) (blockGetter, *Block) {
+		blocks := make(map[string]*Block)
+		var parent *Block
+		for height := 0; height < window; height++ {
+			block := &Block{
+				Height:     int64(height),
+				Timestamp:  int64(height) * spacing,
+				Difficulty: Params.Difficulty,
+				Hash:       []byte(fmt.Sprintf("block %d", height)),
+			}
+			if parent != nil {
+				block.PrevHash = parent.Hash
+			}
+			blocks[string(block.Hash)] = block
+			parent = block
+		}
+		getBlock := func(hash []byte) (*Block, error) {
+			block, ok := blocks[string(hash)]
+			if !ok {
+				return nil, ErrKeyNotFound
+			}
+			return block, nil
+		}
+		return getBlock, parent
+	}
+
+	cases := []struct {
+		spacing  int64
+		expected int
+	}{
+		{interval, Params.Difficulty},
+		{interval / 4, Params.Difficulty + 1},
+		{interval * 4, Params.Difficulty - 1},
+	}
+	for _, c := range cases {
+		getBlock, parent := buildChain(c.spacing)
+		difficulty, err := nextDifficulty(getBlock, parent)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if difficulty != c.expected {
+			t.Fatalf("Spacing %d: difficulty %d expected %d", c.spacing, difficulty, c.expected)
+		}
+	}
+
+	// between retargets the parent difficulty is kept
+	getBlock, parent := buildChain(interval / 4)
+	grandParent, err := getBlock(parent.PrevHash)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	difficulty, err := nextDifficulty(getBlock, grandParent)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if difficulty != Params.Difficulty {
+		t.Fatalf("Difficulty %d expected %d", difficulty, Params.Difficulty)
+	}
+
+	// retargets stay within bounds
+	parent.Difficulty = Params.MaxDifficulty
+	difficulty, err = nextDifficulty(getBlock, parent)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if difficulty != Params.MaxDifficulty {
+		t.Fatalf("Difficulty %d expected %d", difficulty, Params.MaxDifficulty)
+	}
+}
+
+func TestMineParallel(t *testing.T) {
+	block, _ := newSignedBlock(t)
+	pow := NewProof(block)
+
+	nonce, hash, err := pow.Mine(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	block.Nonce = nonce
+	block.Hash = hash
+
+	if !bytes.Equal(pow.Hash(), block.Hash) || !pow.Validate() {
+		t.Fatal("Mined block should be valid")
+	}
+}
+
+func TestMineCancel(t *testing.T) {
+	block, _ := newSignedBlock(t)
+	block.Difficulty = 200
+	pow := NewProof(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := pow.Mine(ctx, 4)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected deadline exceeded got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Mining took %v to stop", elapsed)
+	}
+}
+
+func TestProofOfWorkEngine(t *testing.T) {
+	engine := ProofOfWorkEngine{}
+	genesisTransaction := NewTransaction(TransactionTypeGenesis, ContentTypeRaw, []byte("Genesis Transaction"), nil)
+	genesis := Block{
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: []*Transaction{genesisTransaction},
+		Token:        []byte("token"),
+	}
+	genesis.MerkleRoot = genesis.HashTransactions()
+
+	err := engine.Prepare(nil, &genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if genesis.Difficulty != Params.Difficulty {
+		t.Fatalf("Genesis difficulty %d, expected %d", genesis.Difficulty, Params.Difficulty)
+	}
+	err = engine.Seal(context.Background(), nil, &genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = engine.Verify(nil, &genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	chain := blockGetter(func(hash []byte) (*Block, error) {
+		if bytes.Equal(hash, genesis.Hash) {
+			return &genesis, nil
+		}
+		return nil, ErrKeyNotFound
+	})
+	block := Block{
+		Version:   BlockVersion,
+		Timestamp: genesis.Timestamp + 1,
+		Height:    1,
+		PrevHash:  genesis.Hash,
+		Token:     []byte("token"),
+	}
+	block.MerkleRoot = block.HashTransactions()
+	err = engine.Prepare(nil, &block)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorPreviousHashNotFound {
+		t.Fatalf("Preparing without the parent should fail, got %v", err)
+	}
+	err = engine.Prepare(chain, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	block.Difficulty++
+	err = engine.Seal(context.Background(), chain, &block)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidDifficulty {
+		t.Fatalf("Expected invalid difficulty, got %v", err)
+	}
+	block.Difficulty--
+	err = engine.Seal(context.Background(), chain, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = engine.Verify(chain, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	block.Seal = []byte("seal")
+	err = engine.Verify(chain, &block)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidHash {
+		t.Fatalf("Seal fields should be covered by the block hash, got %v", err)
+	}
+}
+
+func newSealKey(t *testing.T) *Key {
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	return key
+}
+
+func newUnsealedBlock(t *testing.T, engine Engine, height int64) *Block {
+	device := newSealKey(t)
+	trans := NewTransaction(TransactionTypeReading, ContentTypeRaw, []byte("reading"), nil)
+	block := Block{
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Height:       height,
+		Transactions: []*Transaction{trans},
+		Token:        []byte("token"),
+		PublicKey:    device.PublicKey,
+	}
+	if height > 0 {
+		block.PrevHash = []byte("prevhash")
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	err := engine.Prepare(nil, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = block.Sign(device.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	return &block
+}
+
+func expectStatus(t *testing.T, err error, statusCode int) {
+	cErr, ok := err.(*ChainError)
+	if !ok || cErr.StatusCode != statusCode {
+		t.Fatalf("Expected status %d, got %v", statusCode, err)
+	}
+}
+
+func TestProofOfAuthority(t *testing.T) {
+	first, second, outsider := newSealKey(t), newSealKey(t), newSealKey(t)
+	authorities := [][]byte{first.PublicKey, second.PublicKey}
+
+	firstEngine := NewProofOfAuthority(authorities, first.PrivateKey)
+	secondEngine := NewProofOfAuthority(authorities, second.PrivateKey)
+	outsiderEngine := NewProofOfAuthority(authorities, outsider.PrivateKey)
+	verifier := NewProofOfAuthority(authorities, nil)
+
+	// height 3 is the turn of the second authority
+	block := newUnsealedBlock(t, verifier, 3)
+	expectStatus(t, firstEngine.Seal(context.Background(), nil, block), ErrorNotInTurn)
+	expectStatus(t, verifier.Seal(context.Background(), nil, block), ErrorNotInTurn)
+
+	err := secondEngine.Seal(context.Background(), nil, block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = verifier.Verify(nil, block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !block.VerifySignature() {
+		t.Fatal("Sealing should keep the device signature valid")
+	}
+
+	forged := *block
+	forged.Sealer = first.PublicKey
+	expectStatus(t, verifier.Verify(nil, &forged), ErrorNotInTurn)
+
+	forged = *block
+	forged.Seal = append([]byte{}, block.Seal...)
+	forged.Seal[0] ^= 0xff
+	expectStatus(t, verifier.Verify(nil, &forged), ErrorInvalidSeal)
+
+	forged = *block
+	forged.Timestamp++
+	expectStatus(t, verifier.Verify(nil, &forged), ErrorInvalidSeal)
+
+	// any authority seals a genesis block, outsiders never do
+	genesis := newUnsealedBlock(t, verifier, 0)
+	expectStatus(t, outsiderEngine.Seal(context.Background(), nil, genesis), ErrorNotInTurn)
+	err = firstEngine.Seal(context.Background(), nil, genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = verifier.Verify(nil, genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+}
+
+func TestNewEngine(t *testing.T) {
+	params := DefaultChainParams()
+	engine, err := NewEngine(params, nil)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if _, ok := engine.(ProofOfWorkEngine); !ok {
+		t.Fatalf("Expected proof of work engine, got %T", engine)
+	}
+
+	authority := newSealKey(t)
+	params.Consensus = ConsensusProofOfAuthority
+	params.Authorities = []string{hex.EncodeToString(authority.PublicKey)}
+	err = params.Validate()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	engine, err = NewEngine(params, authority.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if _, ok := engine.(*ProofOfAuthority); !ok {
+		t.Fatalf("Expected proof of authority engine, got %T", engine)
+	}
+
+	params.Authorities = []string{"not a key"}
+	if params.Validate() == nil {
+		t.Fatal("Invalid authority should be rejected")
+	}
+	params.Consensus = "pos"
+	if _, err := NewEngine(params, nil); err == nil {
+		t.Fatal("Unknown consensus should be rejected")
+	}
+}
+
+// mineBlock builds, signs and mines a block on parent
+func mineBlock(t *testing.T, chain *BlockChain, parent *Block, key *Key, data string) *Block {
+	return mineBlockAt(t, chain, parent, key, data, parent.Timestamp+1)
+}
+
+// mineBlockAt builds, signs and mines a block on parent with timestamp
+func mineBlockAt(t *testing.T, chain *BlockChain, parent *Block, key *Key, data string, timestamp int64) *Block {
+	trans := NewTransaction(TransactionTypeReading, ContentTypeRaw, []byte(data), nil)
+	block := Block{
+		Version:      BlockVersion,
+		Timestamp:    timestamp,
+		Height:       parent.Height + 1,
+		PrevHash:     parent.Hash,
+		Transactions: []*Transaction{trans},
+		Token:        parent.Token,
+		PublicKey:    key.PublicKey,
+	}
+	block.MerkleRoot = block.HashTransactions()
+
+	err := chain.Engine.Prepare(chain, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = block.Sign(key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.Engine.Seal(context.Background(), chain, &block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	return &block
+}
+
+func TestForkChoice(t *testing.T) {
+	dbPath := "tmp_fork"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	first := mineBlock(t, chain, genesis, key, "first")
+	second := mineBlock(t, chain, genesis, key, "second")
+	low, high := first, second
+	if bytes.Compare(low.Hash, high.Hash) > 0 {
+		low, high = high, low
+	}
+
+	// equal work, the lowest hash wins whatever the arrival order
+	for _, block := range []*Block{high, low} {
+		err = chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+	tip, err := chain.LastHash(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(tip, low.Hash) {
+		t.Fatalf("Tip %X, expected lowest hash %X", tip, low.Hash)
+	}
+	children, err := chain.Children(genesis.Hash)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if len(children) != 2 {
+		t.Fatalf("Genesis has %d children, expected 2", len(children))
+	}
+
+	// extending the side branch gives it more work and reorganizes the chain
+	extension := mineBlock(t, chain, high, key, "extension")
+	err = chain.AddBlock(extension)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	tip, err = chain.LastHash(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(tip, extension.Hash) {
+		t.Fatalf("Tip %X, expected heaviest chain tip %X", tip, extension.Hash)
+	}
+	work, err := chain.ChainWork(extension.Hash)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expected := new(big.Int).Add(BlockWork(genesis), BlockWork(high))
+	expected.Add(expected, BlockWork(extension))
+	if work.Cmp(expected) != 0 {
+		t.Fatalf("Chain work %v, expected %v", work, expected)
+	}
+	height, err := chain.Height(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if height != 3 {
+		t.Fatalf("Chain height %d, expected 3", height)
+	}
+}
+
+func TestEquivocationEvidence(t *testing.T) {
+	dbPath := "tmp_evidence"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	minerKey, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	chain.NodeKey = minerKey.PrivateKey
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	deviceKey, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	first := mineBlock(t, chain, genesis, deviceKey, "first reading")
+	second := mineBlock(t, chain, genesis, deviceKey, "second reading")
+	for _, block := range []*Block{first, second} {
+		err = chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+
+	evidenceList, err := chain.Evidence(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if len(evidenceList) != 1 {
+		t.Fatalf("Expected 1 evidence record, got %d", len(evidenceList))
+	}
+	evidence := evidenceList[0]
+	err = evidence.Verify()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(evidence.Reporter, minerKey.PublicKey) {
+		t.Fatal("Evidence should be reported by the node key")
+	}
+	otherList, err := chain.Evidence([]byte("other token"))
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if len(otherList) != 0 {
+		t.Fatal("Evidence should be filtered by token")
+	}
+
+	// a second report of the same conflict is not stored again
+	again, err := NewEvidence(second, first, minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	added, err := chain.AddEvidence(again)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if added {
+		t.Fatal("Known evidence should not be added again")
+	}
+	resealedSecond := *second.Header()
+	resealedSecond.Nonce++
+	resealedSecond.Hash = resealedSecond.ComputeHash()
+	again, err = NewEvidence(first, &resealedSecond, minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	added, err = chain.AddEvidence(again)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if added {
+		t.Fatal("Known conflict with another seal should not be added again")
+	}
+
+	forged := *evidence
+	forged.Timestamp++
+	if forged.Verify() == nil {
+		t.Fatal("Changing evidence should break the reporter signature")
+	}
+	forged = *evidence
+	forged.Second = first.Header()
+	forged.First = first.Header()
+	if forged.Verify() == nil {
+		t.Fatal("A block can't conflict with itself")
+	}
+	resealed := *first.Header()
+	resealed.Nonce++
+	resealed.Hash = resealed.ComputeHash()
+	reseal, err := NewEvidence(first, &resealed, minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if reseal.Verify() == nil {
+		t.Fatal("Two seals of the same signed header are not equivocation")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	dbPath := "tmp_checkpoint"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+	defer func(params ChainParams) {
+		*Params = params
+	}(*Params)
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	minerKey, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	outsiderKey, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	Params.Miners = []string{hex.EncodeToString(minerKey.PublicKey)}
+	var tokens [][]byte
+	for _, user := range []string{"alice", "bob"} {
+		token, err := generateToken(user, "pass")
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		genesis, err := NewGenesisBlock(context.Background(), chain, token, minerKey.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		err = chain.AddGenesis(genesis)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	first, err := chain.CreateCheckpoint(minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if first.Height != 0 || first.TipCount != 2 {
+		t.Fatalf("Checkpoint height %d with %d tips, expected 0 with 2", first.Height, first.TipCount)
+	}
+	outsider, err := chain.CreateCheckpoint(outsiderKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddCheckpoint(outsider)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidCheckpoint {
+		t.Fatalf("Checkpoint of an unknown miner should be rejected, got %v", err)
+	}
+	added, err := chain.AddCheckpoint(first)
+	if err != nil || !added {
+		t.Fatalf("Checkpoint should be added, added %v error %v", added, err)
+	}
+	added, err = chain.AddCheckpoint(first)
+	if err != nil || added {
+		t.Fatalf("Known checkpoint should be skipped, added %v error %v", added, err)
+	}
+	other := NewBlockChain(NewMemoryStore())
+	defer other.Close()
+	_, err = other.AddCheckpoint(first)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockNotFound {
+		t.Fatalf("Checkpoint over unknown blocks should be rejected, got %v", err)
+	}
+
+	last, err := chain.Checkpoint(-1)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	address, err := Address(tokens[1])
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	index, tip, proof, err := last.TipProof(address)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	lastHash, err := chain.LastHash(tokens[1])
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	header := last.Header()
+	if header.VerifyHeader() != nil || !bytes.Equal(tip.Hash, lastHash) || !VerifyTipProof(header, index, tip, proof) {
+		t.Fatal("Device tip should be proven by the checkpoint header")
+	}
+	tip.Hash = first.Hash
+	if VerifyTipProof(header, index, tip, proof) {
+		t.Fatal("Forged tip should not be proven")
+	}
+
+	second, err := chain.CreateCheckpoint(minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if second.Height != 1 || !bytes.Equal(second.PrevHash, first.Hash) {
+		t.Fatal("Checkpoint should follow the last one")
+	}
+	rival, err := chain.CreateCheckpoint(minerKey.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// the lower hash wins whichever checkpoint arrives first
+	low, high := second, rival
+	if bytes.Compare(low.Hash, high.Hash) > 0 {
+		low, high = high, low
+	}
+	_, err = chain.AddCheckpoint(high)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	added, err = chain.AddCheckpoint(low)
+	if err != nil || !added {
+		t.Fatalf("Lower rival should replace the checkpoint, added %v error %v", added, err)
+	}
+	_, err = chain.AddCheckpoint(high)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidCheckpoint {
+		t.Fatalf("Higher rival should be rejected, got %v", err)
+	}
+	last, err = chain.Checkpoint(1)
+	if err != nil || last == nil || !bytes.Equal(last.Hash, low.Hash) {
+		t.Fatalf("Lower rival should be kept, got %v error %v", last, err)
+	}
+
+	forged := *second
+	forged.Tips = forged.Tips[:1]
+	if forged.Verify() == nil {
+		t.Fatal("Dropping a tip should break the checkpoint")
+	}
+}
+
+func TestFinality(t *testing.T) {
+	dbPath := "tmp_finality"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+	defer func(params ChainParams) {
+		*Params = params
+	}(*Params)
+
+	var miners []*Key
+	Params.Miners = nil
+	for i := 0; i < 3; i++ {
+		key, err := GenerateKey("key.data")
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		miners = append(miners, key)
+		Params.Miners = append(Params.Miners, hex.EncodeToString(key.PublicKey))
+	}
+	Params.FinalityQuorum = 2
+	err := Params.Validate()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+	chain.NodeKey = miners[0].PrivateKey
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	block := mineBlock(t, chain, genesis, key, "final")
+	err = chain.AddBlock(block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// the node votes for its own checkpoint, one vote is short of the quorum
+	first, err := chain.CreateCheckpoint(miners[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddCheckpoint(first)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	chain.endorseCheckpoint(first)
+	final, _, err := chain.FinalCheckpoint()
+	if err != nil || final != nil {
+		t.Fatalf("No checkpoint should be final yet, got %v error %v", final, err)
+	}
+
+	outsider, err := NewCheckpointVote(first, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddVote(outsider)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidVote {
+		t.Fatalf("Vote of an unknown miner should be rejected, got %v", err)
+	}
+
+	vote, err := NewCheckpointVote(first, miners[1].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	added, err := chain.AddVote(vote)
+	if err != nil || !added {
+		t.Fatalf("Vote should be added, added %v error %v", added, err)
+	}
+	added, err = chain.AddVote(vote)
+	if err != nil || added {
+		t.Fatalf("Known vote should be skipped, added %v error %v", added, err)
+	}
+	final, votes, err := chain.FinalCheckpoint()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if final == nil || !bytes.Equal(final.Hash, first.Hash) {
+		t.Fatal("Checkpoint with a quorum of votes should be final")
+	}
+	err = VerifyQuorum(final.Header(), votes)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = VerifyQuorum(final.Header(), votes[:1])
+	if err == nil {
+		t.Fatal("A single vote should not make a quorum")
+	}
+
+	// final blocks can't be reorganized, the chain can only grow past them
+	fork := mineBlock(t, chain, genesis, key, "fork")
+	err = chain.AddBlock(fork)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorConflictsFinality {
+		t.Fatalf("Fork below a final block should be rejected, got %v", err)
+	}
+	next := mineBlock(t, chain, block, key, "next")
+	err = chain.AddBlock(next)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// a rival voted by a quorum replaces a checkpoint that isn't final
+	second, err := chain.CreateCheckpoint(miners[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	rival, err := chain.CreateCheckpoint(miners[1].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddCheckpoint(second)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	for _, miner := range miners[1:] {
+		vote, err := NewCheckpointVote(rival, miner.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		_, err = chain.AddVote(vote)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+	added, err = chain.AddCheckpoint(rival)
+	if err != nil || !added {
+		t.Fatalf("Rival with a quorum should replace the checkpoint, added %v error %v", added, err)
+	}
+	final, _, err = chain.FinalCheckpoint()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if final == nil || !bytes.Equal(final.Hash, rival.Hash) {
+		t.Fatal("Rival checkpoint should be final")
+	}
+	_, err = chain.AddCheckpoint(second)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidCheckpoint {
+		t.Fatalf("Final checkpoint should not be replaced, got %v", err)
+	}
+
+	// the node voted before the lower rival arrived, the votes split and the
+	// height is never final
+	third, err := chain.CreateCheckpoint(miners[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	rival, err = chain.CreateCheckpoint(miners[2].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	low, high := third, rival
+	if bytes.Compare(low.Hash, high.Hash) > 0 {
+		low, high = high, low
+	}
+	_, err = chain.AddCheckpoint(high)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	chain.endorseCheckpoint(high)
+	_, err = chain.AddCheckpoint(low)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	chain.endorseCheckpoint(low)
+	vote, err = NewCheckpointVote(low, miners[1].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddVote(vote)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	final, _, err = chain.FinalCheckpoint()
+	if err != nil || final == nil || final.Height != 1 {
+		t.Fatalf("Split height should not be final, got %v error %v", final, err)
+	}
+
+	// the next checkpoint gets fresh votes and is final
+	fourth, err := chain.CreateCheckpoint(miners[1].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddCheckpoint(fourth)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	chain.endorseCheckpoint(fourth)
+	vote, err = NewCheckpointVote(fourth, miners[1].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddVote(vote)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	final, _, err = chain.FinalCheckpoint()
+	if err != nil || final == nil || !bytes.Equal(final.Hash, fourth.Hash) || !bytes.Equal(final.PrevHash, low.Hash) {
+		t.Fatalf("Checkpoint after the split should be final, got %v error %v", final, err)
+	}
+}
+
+func TestFinalityReorg(t *testing.T) {
+	defer func(params ChainParams) {
+		*Params = params
+	}(*Params)
+
+	var miners []*Key
+	Params.Miners = nil
+	for i := 0; i < 2; i++ {
+		key, err := GenerateKey("key.data")
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		miners = append(miners, key)
+		Params.Miners = append(Params.Miners, hex.EncodeToString(key.PublicKey))
+	}
+	Params.FinalityQuorum = 2
+	err := Params.Validate()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	chain, token, key, genesis := newTestChain(t)
+	defer chain.Close()
+	rival := NewBlockChain(NewMemoryStore())
+	defer rival.Close()
+	err = rival.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// the rival node only saw the side branch, this node follows a heavier one
+	side := mineBlock(t, chain, genesis, key, "side")
+	first := mineBlock(t, chain, genesis, key, "first")
+	for _, block := range []*Block{side, first} {
+		err = chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+	second := mineBlock(t, chain, first, key, "second")
+	err = chain.AddBlock(second)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = rival.AddBlock(side)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// the checkpoint of the rival node is final here, the device chain moves
+	// to the side branch with its stats and indexes
+	checkpoint, err := rival.CreateCheckpoint(miners[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = chain.AddCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	for _, miner := range miners {
+		vote, err := NewCheckpointVote(checkpoint, miner.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		_, err = chain.AddVote(vote)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+	final, _, err := chain.FinalCheckpoint()
+	if err != nil || final == nil || !bytes.Equal(final.Hash, checkpoint.Hash) {
+		t.Fatalf("Rival checkpoint should be final, got %v error %v", final, err)
+	}
+	tip, err := chain.LastHash(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(tip, side.Hash) {
+		t.Fatalf("Tip %X, expected final tip %X", tip, side.Hash)
+	}
+	stats, err := chain.Stats(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if stats.Height != 2 || stats.Blocks != 4 {
+		t.Fatalf("Stats %+v, expected height 2 over 4 blocks", stats)
+	}
+	total, err := chain.Stats(nil)
+	if err != nil || total.Height != 2 {
+		t.Fatalf("Total stats %+v error %v, expected height 2", total, err)
+	}
+	block, err := chain.BlockAtHeight(token, 1)
+	if err != nil || !bytes.Equal(block.Hash, side.Hash) {
+		t.Fatalf("Final block should be at height 1, error %v", err)
+	}
+	_, err = chain.BlockAtHeight(token, 2)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockNotFound {
+		t.Fatalf("Replaced block should not be indexed, got %v", err)
+	}
+
+	// the replaced branch can't grow back, the final one can
+	err = chain.AddBlock(mineBlock(t, chain, second, key, "third"))
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorConflictsFinality {
+		t.Fatalf("Block on the replaced branch should be rejected, got %v", err)
+	}
+	next := mineBlock(t, chain, side, key, "next")
+	err = chain.AddBlock(next)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	tip, err = chain.LastHash(token)
+	if err != nil || !bytes.Equal(tip, next.Hash) {
+		t.Fatalf("Final branch should grow, error %v", err)
+	}
+}
+
+// stallEngine never finds a seal, mining only stops when it is cancelled
+type stallEngine struct {
+	ProofOfWorkEngine
+}
+
+func (engine stallEngine) Seal(ctx context.Context, chain ChainReader, block *Block) error {
+	<-ctx.Done()
+	return &ChainError{StatusCode: ErrorMiningAborted, Err: ctx.Err()}
+}
+
+func TestMiningJobs(t *testing.T) {
+	dbPath := "tmp_jobs"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	newBlock := func(data string) *Block {
+		trans := NewTransaction(TransactionTypeReading, ContentTypeRaw, []byte(data), nil)
+		block := Block{
+			Version:      BlockVersion,
+			Timestamp:    genesis.Timestamp + 1,
+			Height:       1,
+			PrevHash:     genesis.Hash,
+			Transactions: []*Transaction{trans},
+			Token:        token,
+			PublicKey:    key.PublicKey,
+		}
+		block.MerkleRoot = block.HashTransactions()
+		err := chain.Engine.Prepare(chain, &block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		err = block.Sign(key.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		return &block
+	}
+
+	jobs := NewMiningJobs(1, 8)
+	block := newBlock("job")
+	id, err := jobs.Submit(chain, block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	status, err := jobs.Wait(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if status.Block == nil || chain.Engine.Verify(chain, status.Block) != nil {
+		t.Fatalf("Job should return a sealed block, got %s", status)
+	}
+	err = jobs.Watch(context.Background(), id, func(status *JobStatus) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	status, err = jobs.Status(id)
+	if err != nil || status.State != JobPropagated {
+		t.Fatalf("Job should be propagated, got %v error %v", status, err)
+	}
+
+	// a retried submission returns the finished job instead of mining again
+	again, err := jobs.Submit(chain, block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	status, err = jobs.Status(again)
+	if err != nil || !bytes.Equal(again, id) || status.State != JobPropagated {
+		t.Fatalf("Resubmitted block should map to job %X, got %X in state %v", id, again, status)
+	}
+
+	_, err = jobs.Status([]byte("unknown"))
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorJobNotFound {
+		t.Fatalf("Unknown job should not be found, got %v", err)
+	}
+
+	// a peer that never answers doesn't hold the worker
+	defer func(timeout time.Duration) {
+		PropagateTimeout = timeout
+	}(PropagateTimeout)
+	PropagateTimeout = 5 * time.Second
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			if _, err := listener.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	defer conn.Close()
+	addConnectedNode(conn)
+	defer func() {
+		connectedMutex.Lock()
+		delete(ConnectedNodes, conn.Target())
+		connectedMutex.Unlock()
+	}()
+
+	slow, err := jobs.Submit(chain, newBlock("slow peer"))
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = jobs.Wait(context.Background(), slow)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	next, err := jobs.Submit(chain, newBlock("next"))
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = jobs.Wait(context.Background(), next)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	status, err = jobs.Status(slow)
+	if err != nil || status.State != JobMined {
+		t.Fatalf("Job should still be propagating, got %v error %v", status, err)
+	}
+
+	chain.Engine = stallEngine{}
+	id, err = jobs.Submit(chain, newBlock("stall"))
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	cancelled, err := jobs.Cancel(id)
+	if err != nil || !cancelled {
+		t.Fatalf("Job should be cancelled, cancelled %v error %v", cancelled, err)
+	}
+	status, err = jobs.Wait(context.Background(), id)
+	if status == nil || status.State != JobCancelled || err == nil {
+		t.Fatalf("Job should end cancelled, got %v error %v", status, err)
+	}
+	cancelled, err = jobs.Cancel(id)
+	if err != nil || cancelled {
+		t.Fatalf("Done job can't be cancelled, cancelled %v error %v", cancelled, err)
+	}
+
+	// a job nobody waits for anymore is cancelled
+	id, err = jobs.Submit(chain, newBlock("abandoned"))
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	waitCtx, stopWaiting := context.WithCancel(context.Background())
+	waited := make(chan error)
+	go func() {
+		_, err := jobs.Wait(waitCtx, id)
+		waited <- err
+	}()
+	for {
+		job, _, _, err := jobs.get(id)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		jobs.mutex.Lock()
+		waiters := job.waiters
+		jobs.mutex.Unlock()
+		if waiters == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	abandoned, err := jobs.Abandon(id)
+	if err != nil || abandoned {
+		t.Fatalf("Watched job should keep mining, cancelled %v error %v", abandoned, err)
+	}
+	stopWaiting()
+	<-waited
+	abandoned, err = jobs.Abandon(id)
+	if err != nil || !abandoned {
+		t.Fatalf("Unwatched job should be cancelled, cancelled %v error %v", abandoned, err)
+	}
+	status, err = jobs.Wait(context.Background(), id)
+	if status == nil || status.State != JobCancelled {
+		t.Fatalf("Job should end cancelled, got %v error %v", status, err)
+	}
+
+	// so is the job of a mine request that goes away
+	srv := NewServer(chain, jobs)
+	gone := newBlock("gone")
+	id = gone.SigningDigest()
+	ctx, cancel := context.WithCancel(context.Background())
+	mined := make(chan error)
+	go func() {
+		_, err := srv.Mine(ctx, &MineRequest{Block: gone.Proto()})
+		mined <- err
+	}()
+	for {
+		if status, err := jobs.Status(id); err == nil && status.State == JobMining {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-mined; err == nil {
+		t.Fatal("Mine should fail once its request is gone")
+	}
+	status, err = jobs.Wait(context.Background(), id)
+	if status == nil || status.State != JobCancelled {
+		t.Fatalf("Job of a gone request should end cancelled, got %v error %v", status, err)
+	}
+}
+
+func TestMiningQueue(t *testing.T) {
+	chain := &BlockChain{Engine: stallEngine{}}
+	deviceBlock := func(token string) *Block {
+		block, key := newSignedBlock(t)
+		block.Token = []byte(token)
+		err := block.Sign(key.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		return block
+	}
+	expectState := func(jobs *MiningJobs, id []byte, state string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			status, err := jobs.Status(id)
+			if err != nil {
+				t.Fatalf("Error Not expected! Error: %v\n", err)
+			}
+			if status.State == state {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Job %X is %s, expected %s", id, status.State, state)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	jobs := NewMiningJobs(1, 3)
+	var ids [][]byte
+	for _, token := range []string{"alice", "alice", "alice", "bob"} {
+		id, err := jobs.Submit(chain, deviceBlock(token))
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		ids = append(ids, id)
+	}
+	first, second, third, other := ids[0], ids[1], ids[2], ids[3]
+	defer func() {
+		for _, id := range ids {
+			jobs.Cancel(id)
+		}
+	}()
+
+	load := jobs.Load()
+	if load.Running != 1 || load.Queued != 3 || !load.Busy() {
+		t.Fatalf("Expected 1 running and 3 queued jobs, got %+v", load)
+	}
+	_, err := jobs.Submit(chain, deviceBlock("carol"))
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorMinerBusy {
+		t.Fatalf("Full queue should answer busy, got %v", err)
+	}
+	if load.RetryAfter < time.Second {
+		t.Fatalf("Retry after hint %v is too short", load.RetryAfter)
+	}
+
+	// devices take turns, bob's job runs before alice's third
+	expectState(jobs, first, JobMining)
+	jobs.Cancel(first)
+	expectState(jobs, second, JobMining)
+	jobs.Cancel(second)
+	expectState(jobs, other, JobMining)
+	expectState(jobs, third, JobQueued)
+
+	cancelled, err := jobs.Cancel(third)
+	if err != nil || !cancelled {
+		t.Fatalf("Queued job should be cancelled, cancelled %v error %v", cancelled, err)
+	}
+	expectState(jobs, third, JobCancelled)
+	load = jobs.Load()
+	if load.Running != 1 || load.Queued != 0 {
+		t.Fatalf("Expected 1 running and no queued jobs, got %+v", load)
+	}
+}
+
+func TestMineShares(t *testing.T) {
+	defer func(size int64) {
+		ShareSize = size
+	}(ShareSize)
+	ShareSize = 64
+
+	block, _ := newSignedBlock(t)
+	block.Difficulty = 10
+	pow := NewProof(block)
+
+	_, _, err := pow.MineRange(context.Background(), 2, 0, 0)
+	if err == nil {
+		t.Fatal("Empty nonce range should be rejected")
+	}
+	nonce, _, err := pow.MineRange(context.Background(), 2, 128, 1<<20)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if nonce < 128 {
+		t.Fatalf("Nonce %d is outside of the range", nonce)
+	}
+
+	var mutex sync.Mutex
+	served := make(map[string]int)
+	serve := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		served[name]++
+	}
+	miners := map[string]shareMiner{
+		"local": func(ctx context.Context, start, count int64) (int, []byte, error) {
+			serve("local")
+			return pow.MineRange(ctx, 1, start, count)
+		},
+		"broken": func(ctx context.Context, start, count int64) (int, []byte, error) {
+			serve("broken")
+			return 0, nil, errors.New("connection lost")
+		},
+		"cheat": func(ctx context.Context, start, count int64) (int, []byte, error) {
+			serve("cheat")
+			return int(start), []byte("hash"), nil
+		},
+	}
+	nonce, hash, err := mineShares(context.Background(), pow, miners)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	block.Nonce = nonce
+	if !pow.Validate() || !bytes.Equal(hash, pow.Hash()) {
+		t.Fatal("Pooled mining should return a valid solution")
+	}
+	if served["broken"] != 1 || served["cheat"] > 1 {
+		t.Fatalf("Failing miners should be dropped after one share, got %v", served)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = mineShares(ctx, pow, miners)
+	if err != context.Canceled {
+		t.Fatalf("Expected context canceled got %v", err)
+	}
+
+	jobs := NewMiningJobs(1, 0)
+	srv := NewServer(nil, jobs)
+	_, err = srv.MineShare(context.Background(), &MineShareRequest{Block: block.Proto(), Start: 0, Count: ShareSize + 1})
+	if err == nil {
+		t.Fatal("Share larger than ShareSize should be rejected")
+	}
+	_, err = srv.MineShare(context.Background(), &MineShareRequest{Block: block.Proto(), Start: 0, Count: ShareSize})
+	if err == nil {
+		t.Fatal("Share of an unknown node should be rejected")
+	}
+	err = jobs.startShare()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = jobs.startShare()
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorMinerBusy {
+		t.Fatalf("Share without a free worker should be busy, got %v", err)
+	}
+	jobs.endShare()
+	if jobs.Load().Running != 0 {
+		t.Fatal("Finished share should free its worker")
+	}
+}
+
+func TestCheckMinedBlock(t *testing.T) {
+	dbPath := "tmp_mined"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	mined := mineBlock(t, chain, genesis, key, "mined")
+	submitted := *mined
+	submitted.Nonce = 0
+	submitted.Hash = nil
+	err = checkMinedBlock(chain, &submitted, mined)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	other := mineBlock(t, chain, genesis, key, "other")
+	tampered := *mined
+	tampered.Transactions = other.Transactions
+	tampered.MerkleRoot = other.MerkleRoot
+	err = checkMinedBlock(chain, &submitted, &tampered)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockTampered {
+		t.Fatalf("Changed transactions should be detected, got %v", err)
+	}
+	tampered = *mined
+	tampered.PrevHash = other.Hash
+	err = checkMinedBlock(chain, &submitted, &tampered)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockTampered {
+		t.Fatalf("Changed parent should be detected, got %v", err)
+	}
+
+	// a miner handing back an unsealed block passes the comparison only
+	unsealed := submitted
+	err = checkMinedBlock(chain, &submitted, &unsealed)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidHash {
+		t.Fatalf("Unsealed block should be rejected, got %v", err)
+	}
+
+	// the job command checks against the block kept at submission
+	defer func(path string) {
+		JOBPATH = path
+	}(JOBPATH)
+	JOBPATH = filepath.Join(dbPath, "jobs")
+	network := Network{Chain: chain}
+	jobID := submitted.SigningDigest()
+	err = network.AddJobBlock(jobID, mined)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorJobNotFound {
+		t.Fatalf("Job submitted elsewhere should not be trusted, got %v", err)
+	}
+	err = savePendingJob(jobID, &submitted)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = network.newBlock(token, nil)
+	if err == nil {
+		t.Fatal("No block should be built while a block of the device is pending")
+	}
+	tampered = *mined
+	tampered.PrevHash = other.Hash
+	err = network.AddJobBlock(jobID, &tampered)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockTampered {
+		t.Fatalf("Changed block should be rejected, got %v", err)
+	}
+	pending, err := pendingJobFor(token)
+	if err != nil || pending != nil {
+		t.Fatalf("Rejected job should not be pending, got %X error %v", pending, err)
+	}
+
+	err = savePendingJob(jobID, &submitted)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = network.AddJobBlock(jobID, mined)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	lastHash, err := chain.LastHash(token)
+	if err != nil || !bytes.Equal(lastHash, mined.Hash) {
+		t.Fatalf("Mined block should be added to the local chain, error %v", err)
+	}
+	pending, err = pendingJobFor(token)
+	if err != nil || pending != nil {
+		t.Fatalf("Added job should not be pending, got %X error %v", pending, err)
+	}
+
+	// a block the chain rejects isn't kept pending
+	err = savePendingJob(jobID, &submitted)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	empty := Network{Chain: NewBlockChain(NewMemoryStore())}
+	err = empty.AddJobBlock(jobID, mined)
+	if err == nil {
+		t.Fatal("Block the chain rejects should fail")
+	}
+	if _, err := loadPendingJob(jobID); err == nil {
+		t.Fatal("Rejected block should not be pending")
+	}
+
+	// a corrupt file doesn't hold the device, a timed out job is dropped
+	err = ioutil.WriteFile(pendingJobPath([]byte("corrupt")), []byte("corrupt"), 0644)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = savePendingJob(jobID, &submitted)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	pending, err = pendingJobFor(token)
+	if err != nil || !bytes.Equal(pending, jobID) {
+		t.Fatalf("Pending job %X expected, got %X error %v", jobID, pending, err)
+	}
+	expired := time.Now().Add(-(MineTimeout + JobRetention + time.Minute))
+	err = os.Chtimes(pendingJobPath(jobID), expired, expired)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	pending, err = pendingJobFor(token)
+	if err != nil || pending != nil {
+		t.Fatalf("Timed out job should not be pending, got %X error %v", pending, err)
+	}
+	if _, err := loadPendingJob(jobID); err == nil {
+		t.Fatal("Timed out job should be dropped")
+	}
+}
+
+func TestTimestampRules(t *testing.T) {
+	dbPath := "tmp_timestamp"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+	defer func(params ChainParams) {
+		*Params = params
+	}(*Params)
+	Params.MedianTimeBlocks = 3
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	start := genesis.Timestamp
+	parent := genesis
+	for i := int64(1); i <= 3; i++ {
+		block := mineBlockAt(t, chain, parent, key, fmt.Sprint(i), start+i*1000)
+		err = chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		parent = block
+	}
+
+	// last 3 blocks are start+1000, start+2000, start+3000
+	median, err := MedianTime(chain, parent)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if median != start+2000 {
+		t.Fatalf("Median expected %d, got %d", start+2000, median)
+	}
+
+	block := mineBlockAt(t, chain, parent, key, "behind parent", start+2500)
+	err = chain.AddBlock(block)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorInvalidTimestamp {
+		t.Fatalf("Block earlier than its parent should be rejected, got %v", err)
+	}
+
+	// the parent rule keeps the median behind the parent, so the median rule
+	// is checked on its own
+	block = mineBlockAt(t, chain, parent, key, "behind median", start+1500)
+	err = checkMedianTime(chain, block, parent)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorTimestampTooEarly {
+		t.Fatalf("Block earlier than the median should be rejected, got %v", err)
+	}
+
+	future := time.Now().Add(time.Duration(Params.MaxFutureDrift)*time.Millisecond + time.Minute)
+	block = mineBlockAt(t, chain, parent, key, "future", future.UnixNano())
+	err = chain.AddBlock(block)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorTimestampInFuture {
+		t.Fatalf("Block in the future should be rejected, got %v", err)
+	}
+}
+
+func TestChainStore(t *testing.T) {
+	dbPath := "tmp_store"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	badgerStore, err := OpenBadgerStore(dbPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	defer badgerStore.Close()
+
+	stores := map[string]ChainStore{"badger": badgerStore, "memory": NewMemoryStore()}
+	for name, store := range stores {
+		err := store.Update(func(txn StoreTxn) error {
+			for _, key := range []string{"a-2", "b-1", "a-1", "a-3"} {
+				if err := txn.Set([]byte(key), []byte("value "+key)); err != nil {
+					return err
+				}
+			}
+			return txn.Delete([]byte("a-3"))
+		})
+		if err != nil {
+			t.Fatalf("%s: Error Not expected! Error: %v\n", name, err)
+		}
+
+		// a failed update leaves the store untouched
+		failed := errors.New("failed")
+		err = store.Update(func(txn StoreTxn) error {
+			txn.Set([]byte("a-4"), []byte("value a-4"))
+			return failed
+		})
+		if err != failed {
+			t.Fatalf("%s: Update error expected %v, got %v", name, failed, err)
+		}
+
+		err = store.View(func(txn StoreTxn) error {
+			value, err := txn.Get([]byte("a-1"))
+			if err != nil {
+				return err
+			}
+			if string(value) != "value a-1" {
+				t.Fatalf("%s: Unexpected value %q", name, value)
+			}
+			for _, key := range []string{"a-3", "a-4"} {
+				if _, err := txn.Get([]byte(key)); err != ErrKeyNotFound {
+					t.Fatalf("%s: Key %v should be missing, got %v", name, key, err)
+				}
+			}
+
+			var keys []string
+			err = txn.Iterate([]byte("a-"), true, func(key, value []byte) error {
+				keys = append(keys, string(key))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if fmt.Sprint(keys) != "[a-2 a-1]" {
+				t.Fatalf("%s: Reverse iteration expected [a-2 a-1], got %v", name, keys)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: Error Not expected! Error: %v\n", name, err)
+		}
+
+		var keys []string
+		err = store.Iterate([]byte("a-"), func(key, value []byte) error {
+			keys = append(keys, string(key))
+			return errStopIteration
+		})
+		if err != nil {
+			t.Fatalf("%s: Error Not expected! Error: %v\n", name, err)
+		}
+		if fmt.Sprint(keys) != "[a-1]" {
+			t.Fatalf("%s: Stopped iteration expected [a-1], got %v", name, keys)
+		}
+	}
+}
+
+// newTestChain returns a chain in memory holding the genesis block of a new
+// device, with the device token and key
+func newTestChain(t *testing.T) (*BlockChain, []byte, *Key, *Block) {
+	chain := NewBlockChain(NewMemoryStore())
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), chain, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	return chain, token, key, genesis
+}
+
+func TestMemoryChain(t *testing.T) {
+	chain, token, key, genesis := newTestChain(t)
+	defer chain.Close()
+	other := NewBlockChain(NewMemoryStore())
+	defer other.Close()
+
+	block := mineBlock(t, chain, genesis, key, "first")
+	err := chain.AddBlock(block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	tips, err := chain.Store.Tips()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if len(tips) != 1 || !bytes.Equal(tips[0].Hash, block.Hash) {
+		t.Fatalf("Tip expected %X, got %v", block.Hash, tips)
+	}
+
+	// the chain is served from memory and doesn't leak into other chains
+	srv := NewServer(chain, NewMiningJobs(1, 4))
+	resp, err := srv.Height(context.Background(), &HeightRequest{Token: token})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if resp.Height != 2 {
+		t.Fatalf("Height expected 2, got %d", resp.Height)
+	}
+	height, err := other.Height(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if height != 0 {
+		t.Fatalf("Other chain height expected 0, got %d", height)
+	}
+	_, err = other.GetBlock(block.Hash)
+	if err != ErrKeyNotFound {
+		t.Fatalf("Block shouldn't be in other chain, got %v", err)
+	}
+}
+
+func TestMigrateSchema(t *testing.T) {
+	dbPath := "tmp_schema"
+	defer func() {
+		os.RemoveAll(dbPath)
+	}()
+
+	memory := NewBlockChain(NewMemoryStore())
+	token, err := generateToken("admin", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	key, err := GenerateKey("key.data")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	genesis, err := NewGenesisBlock(context.Background(), memory, token, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = memory.AddGenesis(genesis)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	block := mineBlock(t, memory, genesis, key, "legacy")
+	err = memory.AddBlock(block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// write the chain in the flat layout of unversioned stores
+	store, err := OpenBadgerStore(dbPath)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	flat := map[string]string{string(blockPrefix): "", string(tipPrefix): "", string(childPrefix): "child-", string(workPrefix): "work-"}
+	err = memory.Store.Iterate(nil, func(key, value []byte) error {
+		for namespace, legacy := range flat {
+			if bytes.HasPrefix(key, []byte(namespace)) {
+				return store.Update(func(txn StoreTxn) error {
+					return txn.Set(append([]byte(legacy), key[len(namespace):]...), value)
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = store.Update(func(txn StoreTxn) error {
+		return txn.Set([]byte("unknown"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	store.Close()
+
+	chain, err := InitBlockChain(dbPath)
+	if err != nil {
+		t.Fatal("Init chain Error is unexpected ", err)
+	}
+	defer chain.Close()
+
+	height, err := chain.Height(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if height != 2 || chain.FullHeight() != 2 {
+		t.Fatalf("Height and full height expected 2, got %d and %d", height, chain.FullHeight())
+	}
+	children, err := chain.Children(genesis.Hash)
+	if err != nil || len(children) != 1 || !bytes.Equal(children[0], block.Hash) {
+		t.Fatalf("Child index should be migrated, got %X %v", children, err)
+	}
+	version, err := storedSchema(chain.Store)
+	if err != nil || version != SchemaVersion {
+		t.Fatalf("Schema version expected %d, got %d %v", SchemaVersion, version, err)
+	}
+	err = chain.Store.View(func(txn StoreTxn) error {
+		_, err := txn.Get([]byte("unknown"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unknown key should be left alone, got %v", err)
+	}
+
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		return txn.Set(schemaKey, ToHex(SchemaVersion+1))
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = MigrateSchema(chain.Store)
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorUnsupportedSchema {
+		t.Fatalf("Newer schema should be refused, got %v", err)
+	}
+}
+
+func TestChainStats(t *testing.T) {
+	chain, token, key, genesis := newTestChain(t)
+	defer chain.Close()
+	first := mineBlock(t, chain, genesis, key, "first")
+	err := chain.AddBlock(first)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	second := mineBlock(t, chain, first, key, "second")
+	err = chain.AddBlock(second)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	// a side branch is stored but doesn't add to the height
+	side := mineBlock(t, chain, genesis, key, "side")
+	err = chain.AddBlock(side)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	size := int64(0)
+	for _, block := range []*Block{genesis, first, second, side} {
+		data, err := block.Serialize()
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		size += int64(len(data))
+	}
+	expected := ChainStats{Height: 3, Blocks: 4, Bytes: size}
+
+	height, err := chain.Height(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if height != 3 {
+		t.Fatalf("Height expected 3, got %d", height)
+	}
+	if chain.FullHeight() != 4 {
+		t.Fatalf("Full height expected 4, got %d", chain.FullHeight())
+	}
+	for _, tok := range [][]byte{token, nil} {
+		stats, err := chain.Stats(tok)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if stats != expected {
+			t.Fatalf("Stats expected %+v, got %+v", expected, stats)
+		}
+	}
+
+	// counting again from the stored blocks gives the same stats
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		return txn.Delete(statsKey)
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.RebuildStats()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	stats, err := chain.Stats(nil)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if stats != expected {
+		t.Fatalf("Rebuilt stats expected %+v, got %+v", expected, stats)
+	}
+
+	other, err := generateToken("other", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	height, err = chain.Height(other)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if height != 0 {
+		t.Fatalf("Unknown device height expected 0, got %d", height)
+	}
+}
+
+func TestChainIndexes(t *testing.T) {
+	defer func(batch int) {
+		iteratorBatch = batch
+	}(iteratorBatch)
+	iteratorBatch = 2
+
+	chain, token, key, genesis := newTestChain(t)
+	defer chain.Close()
+	first := mineBlockAt(t, chain, genesis, key, "first", genesis.Timestamp+10)
+	err := chain.AddBlock(first)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	second := mineBlockAt(t, chain, first, key, "second", genesis.Timestamp+20)
+	err = chain.AddBlock(second)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	check := func(main []*Block) {
+		for height, expected := range main {
+			block, err := chain.BlockAtHeight(token, int64(height))
+			if err != nil {
+				t.Fatalf("Error Not expected! Error: %v\n", err)
+			}
+			if !bytes.Equal(block.Hash, expected.Hash) {
+				t.Fatalf("Block at height %d expected %X, got %X", height, expected.Hash, block.Hash)
+			}
+			block, err = chain.TransactionBlock(expected.Transactions[0].ID)
+			if err != nil {
+				t.Fatalf("Error Not expected! Error: %v\n", err)
+			}
+			if !bytes.Equal(block.Hash, expected.Hash) {
+				t.Fatalf("Block of transaction expected %X, got %X", expected.Hash, block.Hash)
+			}
+		}
+		_, err := chain.BlockAtHeight(token, int64(len(main)))
+		if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockNotFound {
+			t.Fatalf("Block above the tip should be missing, got %v", err)
+		}
+
+		var found []*Block
+		err = chain.BlocksBetween(token, genesis.Timestamp, genesis.Timestamp+100, func(block *Block) error {
+			found = append(found, block)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if len(found) != len(main) {
+			t.Fatalf("Expected %d blocks in range, got %d", len(main), len(found))
+		}
+		for i := range found {
+			if !bytes.Equal(found[i].Hash, main[i].Hash) {
+				t.Fatalf("Block %d in range expected %X, got %X", i, main[i].Hash, found[i].Hash)
+			}
+		}
+	}
+	check([]*Block{genesis, first, second})
+
+	var found []*Block
+	err = chain.BlocksBetween(token, first.Timestamp, first.Timestamp, func(block *Block) error {
+		found = append(found, block)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if len(found) != 1 || !bytes.Equal(found[0].Hash, first.Hash) {
+		t.Fatalf("Expected only block %X in range, got %d blocks", first.Hash, len(found))
+	}
+
+	// a longer side branch takes over the heights it replaces
+	side := []*Block{genesis}
+	for i := 1; i <= 3; i++ {
+		block := mineBlockAt(t, chain, side[i-1], key, fmt.Sprintf("side %d", i), side[i-1].Timestamp+15)
+		err = chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		side = append(side, block)
+	}
+	check(side)
+	block, err := chain.TransactionBlock(first.Transactions[0].ID)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if !bytes.Equal(block.Hash, first.Hash) {
+		t.Fatalf("Block of a side branch transaction expected %X, got %X", first.Hash, block.Hash)
+	}
+
+	// building the indexes again gives the same lookups and drops stale keys
+	stale := heightIndexKey([]byte("stale"), 0)
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		return txn.Set(stale, genesis.Hash)
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		address, err := Address(token)
+		if err != nil {
+			return err
+		}
+		return txn.Delete(heightIndexKey(address, 1))
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	err = chain.Reindex()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	check(side)
+	err = chain.Store.View(func(txn StoreTxn) error {
+		_, err := txn.Get(stale)
+		return err
+	})
+	if err != ErrKeyNotFound {
+		t.Fatalf("Stale index key should be dropped, got %v", err)
+	}
+
+	_, err = chain.TransactionBlock([]byte("short"))
+	if err == nil {
+		t.Fatal("Malformed transaction ID should be refused")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	defer func(batch int) {
+		iteratorBatch = batch
+	}(iteratorBatch)
+	iteratorBatch = 2
+
+	chain, token, key, genesis := newTestChain(t)
+	defer chain.Close()
+	blocks := []*Block{genesis}
+	for i := 1; i <= 4; i++ {
+		block := mineBlock(t, chain, blocks[i-1], key, fmt.Sprintf("block %d", i))
+		err := chain.AddBlock(block)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		blocks = append(blocks, block)
+	}
+	side := mineBlock(t, chain, genesis, key, "side")
+	err := chain.AddBlock(side)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	walk := func(direction Direction, seek func(itr *Iterator)) ([]*Block, error) {
+		itr, err := chain.NewIterator(token, direction)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		if seek != nil {
+			seek(itr)
+		}
+		var walked []*Block
+		for itr.Next() {
+			walked = append(walked, itr.Block())
+		}
+		return walked, itr.Err()
+	}
+	expect := func(walked []*Block, expected ...*Block) {
+		if len(walked) != len(expected) {
+			t.Fatalf("Expected %d blocks, got %d", len(expected), len(walked))
+		}
+		for i := range expected {
+			if !bytes.Equal(walked[i].Hash, expected[i].Hash) {
+				t.Fatalf("Block %d expected %X, got %X", i, expected[i].Hash, walked[i].Hash)
+			}
+		}
+	}
+
+	walked, err := walk(Backward, nil)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(walked, blocks[4], blocks[3], blocks[2], blocks[1], blocks[0])
+
+	walked, err = walk(Forward, nil)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(walked, blocks...)
+
+	walked, err = walk(Forward, func(itr *Iterator) { itr.SeekHeight(2) })
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(walked, blocks[2:]...)
+
+	walked, err = walk(Backward, func(itr *Iterator) { itr.SeekHash(blocks[2].Hash) })
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(walked, blocks[2], blocks[1], blocks[0])
+
+	// a side branch can be walked back but not forward
+	walked, err = walk(Backward, func(itr *Iterator) { itr.SeekHash(side.Hash) })
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(walked, side, genesis)
+	_, err = walk(Forward, func(itr *Iterator) { itr.SeekHash(side.Hash) })
+	if err == nil {
+		t.Fatal("Walking forward from a side branch should fail")
+	}
+
+	_, err = walk(Forward, func(itr *Iterator) { itr.SeekHeight(10) })
+	if cErr, ok := err.(*ChainError); !ok || cErr.StatusCode != ErrorBlockNotFound {
+		t.Fatalf("Seeking above the tip should fail, got %v", err)
+	}
+
+	list, err := chain.Chain(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	expect(list, blocks[4], blocks[3], blocks[2], blocks[1], blocks[0])
+
+	other, err := generateToken("other", "pass")
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	itr, err := chain.NewIterator(other, Forward)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	if itr.Next() || itr.Err() != nil {
+		t.Fatalf("Unknown device chain should be empty, got error %v", itr.Err())
+	}
+
+	// a missing block ends the walk with an error after the blocks before it
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		return txn.Delete(blockKey(blocks[1].Hash))
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	walked, err = walk(Backward, nil)
+	if err == nil {
+		t.Fatal("Missing block should be reported")
+	}
+	expect(walked, blocks[4], blocks[3], blocks[2])
+}
+
+func TestImportChain(t *testing.T) {
+	defer func(params ChainParams) {
+		*Params = params
+	}(*Params)
+
+	var miners []*Key
+	Params.Miners = nil
+	for i := 0; i < 3; i++ {
+		key, err := GenerateKey("key.data")
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		miners = append(miners, key)
+		Params.Miners = append(Params.Miners, hex.EncodeToString(key.PublicKey))
+	}
+	Params.FinalityQuorum = 2
+
+	source, token, key, genesis := newTestChain(t)
+	defer source.Close()
+	block := mineBlock(t, source, genesis, key, "first")
+	err := source.AddBlock(block)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	tip := mineBlock(t, source, block, key, "second")
+	err = source.AddBlock(tip)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	checkpoint, err := source.CreateCheckpoint(miners[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	_, err = source.AddCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	for _, miner := range miners[:2] {
+		vote, err := NewCheckpointVote(checkpoint, miner.PrivateKey)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+		_, err = source.AddVote(vote)
+		if err != nil {
+			t.Fatalf("Error Not expected! Error: %v\n", err)
+		}
+	}
+
+	type entry struct{ key, value []byte }
+	var entries []entry
+	err = exportChain(source.Store, func(key, value []byte) error {
+		entries = append(entries, entry{key, value})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	// forged records a node must not take on trust
+	data, err := tip.Serialize()
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	outsider, err := NewCheckpointVote(checkpoint, key.PrivateKey)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	outsiderData, err := marshalDeterministic(outsider.Proto())
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	address, err := Address(token)
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+	entries = append(entries,
+		entry{blockKey([]byte("forged")), data},
+		entry{tipKey(address), genesis.Hash},
+		entry{finalKey, ToHex(5)},
+		entry{voteKey(checkpoint.Height, outsider.Signer), outsiderData},
+	)
+
+	chain := NewBlockChain(NewMemoryStore())
+	defer chain.Close()
+	err = chain.importChain(func() ([]byte, []byte, error) {
+		if len(entries) == 0 {
+			return nil, nil, io.EOF
+		}
+		next := entries[0]
+		entries = entries[1:]
+		return next.key, next.value, nil
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+
+	lastHash, err := chain.LastHash(token)
+	if err != nil || !bytes.Equal(lastHash, tip.Hash) {
+		t.Fatalf("Tip should be computed from the replayed blocks, got %X error %v", lastHash, err)
+	}
+	children, err := chain.Children(genesis.Hash)
+	if err != nil || len(children) != 1 || !bytes.Equal(children[0], block.Hash) {
+		t.Fatalf("Child index should be computed, got %X %v", children, err)
+	}
+	final, votes, err := chain.FinalCheckpoint()
+	if err != nil || final == nil || !bytes.Equal(final.Hash, checkpoint.Hash) {
+		t.Fatalf("Checkpoint should be final with its verified votes, got %v error %v", final, err)
+	}
+	if len(votes) != 2 {
+		t.Fatalf("Only the votes of miners should be kept, got %d", len(votes))
+	}
+	err = chain.Store.View(func(txn StoreTxn) error {
+		return txn.Iterate(syncPrefix, false, func(key, value []byte) error {
+			return fmt.Errorf("Staged key %X left behind", key)
+		})
+	})
+	if err != nil {
+		t.Fatalf("Error Not expected! Error: %v\n", err)
+	}
+}
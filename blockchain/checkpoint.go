@@ -0,0 +1,487 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checkpointSignatureDomain = "iotchain/checkpoint-signature/v1"
+)
+
+var (
+	checkpointPrefix = []byte("checkpoint/")
+)
+
+// CheckpointTip is the tip of one device chain at a checkpoint
+type CheckpointTip struct {
+	Address []byte
+	Hash    []byte
+}
+
+// Checkpoint commits to the tips of all device chains at a point in time
+// with a merkle root over them. Checkpoints form their own chain, each one
+// links to the previous by hash, and are signed by the miner that created
+// them. A checkpoint header leaves out the tips.
+type Checkpoint struct {
+	Height    int64
+	PrevHash  []byte
+	Timestamp int64
+	TipsRoot  []byte
+	TipCount  int64
+	Tips      []CheckpointTip
+	Creator   []byte
+	Signature []byte
+	Hash      []byte
+}
+
+// leaf returns the merkle leaf of the tip
+func (tip CheckpointTip) leaf() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, tip.Address)
+	writeField(&buffer, tip.Hash)
+
+	return buffer.Bytes()
+}
+
+// hashTips returns the merkle root over tips
+func hashTips(tips []CheckpointTip) []byte {
+	var leaves [][]byte
+	for _, tip := range tips {
+		leaves = append(leaves, tip.leaf())
+	}
+	return NewMerkleTree(leaves).RootNode.Data
+}
+
+// HeaderBytes returns the canonical encoding of the checkpoint header
+// without its signature
+func (checkpoint *Checkpoint) HeaderBytes() []byte {
+	var buffer bytes.Buffer
+
+	buffer.Write(ToHex(checkpoint.Height))
+	writeField(&buffer, checkpoint.PrevHash)
+	buffer.Write(ToHex(checkpoint.Timestamp))
+	writeField(&buffer, checkpoint.TipsRoot)
+	buffer.Write(ToHex(checkpoint.TipCount))
+	writeField(&buffer, checkpoint.Creator)
+
+	return buffer.Bytes()
+}
+
+// ComputeHash returns the hash the checkpoint must carry
+func (checkpoint *Checkpoint) ComputeHash() []byte {
+	hash := sha256.Sum256(checkpoint.HeaderBytes())
+	return hash[:]
+}
+
+// SigningDigest returns the digest the creator signs
+func (checkpoint *Checkpoint) SigningDigest() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, []byte(checkpointSignatureDomain))
+	writeField(&buffer, []byte(Params.NetworkID))
+	writeField(&buffer, checkpoint.ComputeHash())
+
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
+
+// Header returns a copy of the checkpoint without its tips
+func (checkpoint *Checkpoint) Header() *Checkpoint {
+	header := *checkpoint
+	header.Tips = nil
+	return &header
+}
+
+// VerifyHeader checks the hash and creator signature of the checkpoint
+func (checkpoint *Checkpoint) VerifyHeader() error {
+	if checkpoint.Height < 0 || checkpoint.TipCount < 0 {
+		return errors.New("Checkpoint height and tip count can't be negative")
+	}
+	if !bytes.Equal(checkpoint.ComputeHash(), checkpoint.Hash) {
+		return errors.New("Checkpoint hash doesn't match checkpoint header")
+	}
+	if !VerifyDigest(checkpoint.Creator, checkpoint.SigningDigest(), checkpoint.Signature) {
+		return errors.New("Checkpoint signature can't be verified")
+	}
+	return nil
+}
+
+// Verify checks the header and that the tips are well formed, in address
+// order and match the tips root
+func (checkpoint *Checkpoint) Verify() error {
+	err := checkpoint.VerifyHeader()
+	if err != nil {
+		return err
+	}
+	if int64(len(checkpoint.Tips)) != checkpoint.TipCount {
+		return fmt.Errorf("Checkpoint has %d tips, header says %d", len(checkpoint.Tips), checkpoint.TipCount)
+	}
+	for idx, tip := range checkpoint.Tips {
+		if !ValidateAddress(tip.Address) {
+			return fmt.Errorf("Checkpoint tip address %s is invalid", tip.Address)
+		}
+		if idx > 0 && bytes.Compare(checkpoint.Tips[idx-1].Address, tip.Address) >= 0 {
+			return errors.New("Checkpoint tips are not in address order")
+		}
+	}
+	if !bytes.Equal(hashTips(checkpoint.Tips), checkpoint.TipsRoot) {
+		return errors.New("Checkpoint tips root doesn't match tips")
+	}
+	return nil
+}
+
+// TipProof returns the tip of the device chain at address with its merkle
+// audit path up to the tips root
+func (checkpoint *Checkpoint) TipProof(address []byte) (int, CheckpointTip, MerkleProof, error) {
+	index := sort.Search(len(checkpoint.Tips), func(i int) bool {
+		return bytes.Compare(checkpoint.Tips[i].Address, address) >= 0
+	})
+	if index == len(checkpoint.Tips) || !bytes.Equal(checkpoint.Tips[index].Address, address) {
+		return 0, CheckpointTip{}, nil, fmt.Errorf("Device %s not in checkpoint %d", address, checkpoint.Height)
+	}
+
+	var leaves [][]byte
+	for _, tip := range checkpoint.Tips {
+		leaves = append(leaves, tip.leaf())
+	}
+	proof, err := NewMerkleTree(leaves).GenerateProof(index)
+	if err != nil {
+		return 0, CheckpointTip{}, nil, err
+	}
+	return index, checkpoint.Tips[index], proof, nil
+}
+
+// VerifyTipProof verifies that tip is committed to by the checkpoint header
+// as its tip at index
+func VerifyTipProof(header *Checkpoint, index int, tip CheckpointTip, proof MerkleProof) bool {
+	return VerifyProof(header.TipsRoot, tip.leaf(), index, int(header.TipCount), proof)
+}
+
+// Proto converts the checkpoint to its wire and storage message
+func (checkpoint *Checkpoint) Proto() *CheckpointMessage {
+	var tips []*CheckpointTipMessage
+	for idx := range checkpoint.Tips {
+		tips = append(tips, checkpoint.Tips[idx].Proto())
+	}
+	return &CheckpointMessage{
+		Height:    checkpoint.Height,
+		PrevHash:  checkpoint.PrevHash,
+		Timestamp: checkpoint.Timestamp,
+		TipsRoot:  checkpoint.TipsRoot,
+		TipCount:  checkpoint.TipCount,
+		Tips:      tips,
+		Creator:   checkpoint.Creator,
+		Signature: checkpoint.Signature,
+		Hash:      checkpoint.Hash,
+	}
+}
+
+// CheckpointFromProto converts a wire or storage message to a checkpoint
+func CheckpointFromProto(msg *CheckpointMessage) *Checkpoint {
+	var tips []CheckpointTip
+	for _, tip := range msg.GetTips() {
+		tips = append(tips, CheckpointTipFromProto(tip))
+	}
+	return &Checkpoint{
+		Height:    msg.GetHeight(),
+		PrevHash:  msg.GetPrevHash(),
+		Timestamp: msg.GetTimestamp(),
+		TipsRoot:  msg.GetTipsRoot(),
+		TipCount:  msg.GetTipCount(),
+		Tips:      tips,
+		Creator:   msg.GetCreator(),
+		Signature: msg.GetSignature(),
+		Hash:      msg.GetHash(),
+	}
+}
+
+// Proto converts the tip to its wire message
+func (tip CheckpointTip) Proto() *CheckpointTipMessage {
+	return &CheckpointTipMessage{Address: tip.Address, Hash: tip.Hash}
+}
+
+// CheckpointTipFromProto converts a wire message to a tip
+func CheckpointTipFromProto(msg *CheckpointTipMessage) CheckpointTip {
+	return CheckpointTip{Address: msg.GetAddress(), Hash: msg.GetHash()}
+}
+
+// String prints the checkpoint
+func (checkpoint Checkpoint) String() string {
+	var values []string
+
+	values = append(values, fmt.Sprintf("\n----Checkpoint: "))
+	values = append(values, fmt.Sprintf(" Height    : %d", checkpoint.Height))
+	values = append(values, fmt.Sprintf(" Timestamp : %s", time.Unix(0, checkpoint.Timestamp).UTC().Format(time.RFC3339Nano)))
+	values = append(values, fmt.Sprintf(" PrevHash  : %X", checkpoint.PrevHash))
+	values = append(values, fmt.Sprintf(" Hash      : %X", checkpoint.Hash))
+	values = append(values, fmt.Sprintf(" TipsRoot  : %X", checkpoint.TipsRoot))
+	values = append(values, fmt.Sprintf(" TipCount  : %d", checkpoint.TipCount))
+	values = append(values, fmt.Sprintf(" Creator   : %X", checkpoint.Creator))
+	values = append(values, fmt.Sprintf(" Signature : %X", checkpoint.Signature))
+	for _, tip := range checkpoint.Tips {
+		values = append(values, fmt.Sprintf("   ├──Tip  : %s %X", tip.Address, tip.Hash))
+	}
+	return strings.Join(values, "\n")
+}
+
+// checkpointKey holds the checkpoint at height
+func checkpointKey(height int64) []byte {
+	return append(append([]byte{}, checkpointPrefix...), ToHex(height)...)
+}
+
+// getCheckpointTxn returns the checkpoint at height within txn
+func getCheckpointTxn(txn StoreTxn, height int64) (*Checkpoint, error) {
+	data, err := txn.Get(checkpointKey(height))
+	if err != nil {
+		return nil, err
+	}
+	var msg CheckpointMessage
+	err = proto.Unmarshal(data, &msg)
+	if err != nil {
+		return nil, err
+	}
+	return CheckpointFromProto(&msg), nil
+}
+
+// lastCheckpointTxn returns the checkpoint with the greatest height, nil when
+// there is none
+func lastCheckpointTxn(txn StoreTxn) (*Checkpoint, error) {
+	var checkpoint *Checkpoint
+	err := txn.Iterate(checkpointPrefix, true, func(key, value []byte) error {
+		var msg CheckpointMessage
+		err := proto.Unmarshal(value, &msg)
+		if err != nil {
+			return err
+		}
+		checkpoint = CheckpointFromProto(&msg)
+		return errStopIteration
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// tipsTxn returns the tips of all device chains in address order
+func tipsTxn(txn StoreTxn) ([]CheckpointTip, error) {
+	var tips []CheckpointTip
+	err := txn.Iterate(tipPrefix, false, func(key, value []byte) error {
+		tips = append(tips, CheckpointTip{Address: key[len(tipPrefix):], Hash: value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tips, nil
+}
+
+// Checkpoint returns the checkpoint at height, the last one when height is
+// negative. It returns nil when there is no such checkpoint.
+func (chain *BlockChain) Checkpoint(height int64) (*Checkpoint, error) {
+	var checkpoint *Checkpoint
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		if height < 0 {
+			checkpoint, err = lastCheckpointTxn(txn)
+			return err
+		}
+		checkpoint, err = getCheckpointTxn(txn, height)
+		if err == ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// CreateCheckpoint creates the checkpoint following the last one over the
+// current tips of all device chains, signed by key
+func (chain *BlockChain) CreateCheckpoint(key *ecdsa.PrivateKey) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	err := chain.Store.View(func(txn StoreTxn) error {
+		last, err := lastCheckpointTxn(txn)
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			checkpoint.Height = last.Height + 1
+			checkpoint.PrevHash = last.Hash
+		}
+		checkpoint.Tips, err = tipsTxn(txn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint.Timestamp = time.Now().UnixNano()
+	checkpoint.TipsRoot = hashTips(checkpoint.Tips)
+	checkpoint.TipCount = int64(len(checkpoint.Tips))
+	checkpoint.Creator = EncodePublicKey(&key.PublicKey)
+	checkpoint.Hash = checkpoint.ComputeHash()
+	checkpoint.Signature, err = SignDigest(key, checkpoint.SigningDigest())
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// AddCheckpoint verifies and stores a checkpoint that follows the last one.
+// It returns false when the checkpoint is already stored. Only miners listed
+// in the chain parameters create checkpoints, see CanCheckpoint. Of two
+// checkpoints at the same height every node keeps the same one, see
+// replaceCheckpointTxn. Every tip must be a block of the device chain it is
+// listed for, a checkpoint over blocks this node doesn't hold is rejected
+// until they are synced.
+func (chain *BlockChain) AddCheckpoint(checkpoint *Checkpoint) (bool, error) {
+	err := checkpoint.Verify()
+	if err != nil {
+		return false, &ChainError{
+			StatusCode: ErrorInvalidCheckpoint,
+			Err:        err,
+		}
+	}
+	if !Params.CanCheckpoint(checkpoint.Creator) {
+		return false, &ChainError{
+			StatusCode: ErrorInvalidCheckpoint,
+			Err:        fmt.Errorf("Creator %X is not a known miner", checkpoint.Creator),
+		}
+	}
+	data, err := marshalDeterministic(checkpoint.Proto())
+	if err != nil {
+		return false, err
+	}
+
+	added := false
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		stored, err := getCheckpointTxn(txn, checkpoint.Height)
+		if err == nil {
+			if bytes.Equal(stored.Hash, checkpoint.Hash) {
+				return nil
+			}
+			err = replaceCheckpointTxn(txn, stored, checkpoint)
+			if err != nil {
+				return err
+			}
+		} else if err != ErrKeyNotFound {
+			return err
+		}
+
+		if checkpoint.Height == 0 {
+			if len(checkpoint.PrevHash) != 0 {
+				return &ChainError{
+					StatusCode: ErrorInvalidCheckpoint,
+					Err:        errors.New("First checkpoint can't have a previous checkpoint"),
+				}
+			}
+		} else {
+			prev, err := getCheckpointTxn(txn, checkpoint.Height-1)
+			if err == ErrKeyNotFound {
+				return &ChainError{
+					StatusCode: ErrorInvalidCheckpoint,
+					Err:        fmt.Errorf("Previous checkpoint %d not found", checkpoint.Height-1),
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(prev.Hash, checkpoint.PrevHash) || prev.Timestamp >= checkpoint.Timestamp {
+				return &ChainError{
+					StatusCode: ErrorInvalidCheckpoint,
+					Err:        fmt.Errorf("Checkpoint %d doesn't follow checkpoint %X", checkpoint.Height, prev.Hash),
+				}
+			}
+		}
+
+		for _, tip := range checkpoint.Tips {
+			block, err := getBlockTxn(txn, tip.Hash)
+			if err == ErrKeyNotFound {
+				return blockNotFound("Tip %X of %s is not known here", tip.Hash, tip.Address)
+			}
+			if err != nil {
+				return err
+			}
+			address, err := Address(block.Token)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(address, tip.Address) {
+				return &ChainError{
+					StatusCode: ErrorInvalidCheckpoint,
+					Err:        fmt.Errorf("Tip %X doesn't belong to device %s", tip.Hash, tip.Address),
+				}
+			}
+		}
+
+		added = true
+		return txn.Set(checkpointKey(checkpoint.Height), data)
+	})
+	if err != nil {
+		return false, err
+	}
+	if added {
+		logrus.Infof("Added checkpoint %d over %d device chains", checkpoint.Height, checkpoint.TipCount)
+		_, err = chain.finalize(checkpoint.Height, checkpoint.Hash)
+		if err != nil {
+			return true, err
+		}
+	}
+	return added, nil
+}
+
+// replaceCheckpointTxn drops stored and every checkpoint after it in favour of
+// a conflicting checkpoint at the same height. The choice doesn't depend on
+// the order checkpoints arrive in, so all nodes keep the same one: a final
+// checkpoint is never replaced, a checkpoint a quorum of miners voted for
+// wins, otherwise the lower hash wins.
+func replaceCheckpointTxn(txn StoreTxn, stored, checkpoint *Checkpoint) error {
+	conflict := &ChainError{
+		StatusCode: ErrorInvalidCheckpoint,
+		Err:        fmt.Errorf("Conflicting checkpoint %X at height %d, kept %X", checkpoint.Hash, checkpoint.Height, stored.Hash),
+	}
+	finalHeight, err := finalHeightTxn(txn)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Height <= finalHeight {
+		return conflict
+	}
+	voted := false
+	if Params.FinalityQuorum > 0 {
+		votes, err := votesTxn(txn, checkpoint.Height, checkpoint.Hash)
+		if err != nil {
+			return err
+		}
+		voted = len(votes) >= Params.FinalityQuorum
+	}
+	if !voted && bytes.Compare(checkpoint.Hash, stored.Hash) > 0 {
+		return conflict
+	}
+
+	last, err := lastCheckpointTxn(txn)
+	if err != nil {
+		return err
+	}
+	for height := last.Height; height >= stored.Height; height-- {
+		err = txn.Delete(checkpointKey(height))
+		if err != nil {
+			return err
+		}
+	}
+	logrus.Warnf("Replaced checkpoint %X at height %d with %X", stored.Hash, stored.Height, checkpoint.Hash)
+	return nil
+}
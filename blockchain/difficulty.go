@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"errors"
+	"time"
+)
+
+// blockGetter loads a block by its hash
+type blockGetter func(hash []byte) (*Block, error)
+
+// GetBlock calls get, so a plain function can serve as a ChainReader
+func (get blockGetter) GetBlock(hash []byte) (*Block, error) {
+	return get(hash)
+}
+
+// RequiredDifficulty returns the difficulty required of block at its
+// position in chain
+func RequiredDifficulty(chain ChainReader, block *Block) (int, error) {
+	if block.IsGenesis() {
+		return Params.Difficulty, nil
+	}
+	if chain == nil {
+		return 0, &ChainError{
+			StatusCode: ErrorPreviousHashNotFound,
+			Err:        errors.New("Parent block is needed for the difficulty"),
+		}
+	}
+	parent, err := chain.GetBlock(block.PrevHash)
+	if err != nil {
+		return 0, err
+	}
+	return nextDifficulty(chain, parent)
+}
+
+// nextDifficulty retargets the difficulty every RetargetWindow blocks. The
+// time the last window took is compared with TargetBlockInterval, the
+// difficulty goes up by one bit when blocks came in more than twice as fast
+// as targeted and down by one bit when they came in more than twice as slow.
+// In between, and between retargets, the parent difficulty is kept.
+func nextDifficulty(chain ChainReader, parent *Block) (int, error) {
+	height := parent.Height + 1
+	window := int64(Params.RetargetWindow)
+
+	difficulty := parent.Difficulty
+	if height >= window && height%window == 0 {
+		first := parent
+		for first.Height > height-window {
+			block, err := chain.GetBlock(first.PrevHash)
+			if err != nil {
+				return 0, err
+			}
+			first = block
+		}
+
+		actual := parent.Timestamp - first.Timestamp
+		expected := Params.TargetBlockInterval * int64(time.Millisecond) * (parent.Height - first.Height)
+		switch {
+		case actual < expected/2:
+			difficulty++
+		case actual > expected*2:
+			difficulty--
+		}
+	}
+
+	if difficulty < Params.MinDifficulty {
+		difficulty = Params.MinDifficulty
+	}
+	if difficulty > Params.MaxDifficulty {
+		difficulty = Params.MaxDifficulty
+	}
+	return difficulty, nil
+}
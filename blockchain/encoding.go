@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Proto converts the block to its wire and storage message
+func (block *Block) Proto() *BlockMessage {
+	var transactions []*TransactionMessage
+	for _, tx := range block.Transactions {
+		transactions = append(transactions, tx.Proto())
+	}
+	return &BlockMessage{
+		Version:      int64(block.Version),
+		PrevHash:     block.PrevHash,
+		Hash:         block.Hash,
+		MerkleRoot:   block.MerkleRoot,
+		Timestamp:    block.Timestamp,
+		Height:       block.Height,
+		Difficulty:   int64(block.Difficulty),
+		Nonce:        int64(block.Nonce),
+		Signature:    block.Signature,
+		Token:        block.Token,
+		PublicKey:    block.PublicKey,
+		Sealer:       block.Sealer,
+		Seal:         block.Seal,
+		Transactions: transactions,
+	}
+}
+
+// BlockFromProto converts a wire or storage message to a block
+func BlockFromProto(msg *BlockMessage) *Block {
+	var transactions []*Transaction
+	for _, tx := range msg.GetTransactions() {
+		transactions = append(transactions, TransactionFromProto(tx))
+	}
+	return &Block{
+		Version:      int(msg.GetVersion()),
+		PrevHash:     msg.GetPrevHash(),
+		Hash:         msg.GetHash(),
+		MerkleRoot:   msg.GetMerkleRoot(),
+		Timestamp:    msg.GetTimestamp(),
+		Height:       msg.GetHeight(),
+		Difficulty:   int(msg.GetDifficulty()),
+		Nonce:        int(msg.GetNonce()),
+		Signature:    msg.GetSignature(),
+		Token:        msg.GetToken(),
+		PublicKey:    msg.GetPublicKey(),
+		Sealer:       msg.GetSealer(),
+		Seal:         msg.GetSeal(),
+		Transactions: transactions,
+	}
+}
+
+// Proto converts the transaction to its wire and storage message
+func (tx *Transaction) Proto() *TransactionMessage {
+	return &TransactionMessage{
+		Id:          tx.ID,
+		Type:        tx.Type,
+		Timestamp:   tx.Timestamp,
+		ContentType: tx.ContentType,
+		Metadata:    tx.Metadata,
+		Data:        tx.Data,
+	}
+}
+
+// TransactionFromProto converts a wire or storage message to a transaction
+func TransactionFromProto(msg *TransactionMessage) *Transaction {
+	return &Transaction{
+		ID:          msg.GetId(),
+		Type:        msg.GetType(),
+		Timestamp:   msg.GetTimestamp(),
+		ContentType: msg.GetContentType(),
+		Metadata:    msg.GetMetadata(),
+		Data:        msg.GetData(),
+	}
+}
+
+// marshalDeterministic marshals msg with map entries in key order, so equal
+// messages always encode to equal bytes
+func marshalDeterministic(msg proto.Message) ([]byte, error) {
+	var buffer proto.Buffer
+	buffer.SetDeterministic(true)
+
+	err := buffer.Marshal(msg)
+	if err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
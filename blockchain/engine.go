@@ -0,0 +1,132 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ChainReader gives consensus engines read access to the chain
+type ChainReader interface {
+	GetBlock(hash []byte) (*Block, error)
+}
+
+// Engine is a consensus engine. A block is built by the client, filled in
+// by Prepare, signed by the device, then sealed by a miner with Seal. Every
+// node checks the seal of a block it receives with Verify.
+type Engine interface {
+	// Prepare sets the consensus fields of the header the device signs
+	Prepare(chain ChainReader, block *Block) error
+	// Seal seals a signed block and sets its hash
+	Seal(ctx context.Context, chain ChainReader, block *Block) error
+	// Verify checks the seal of block. A nil chain only checks what can be
+	// checked without the parent block.
+	Verify(chain ChainReader, block *Block) error
+}
+
+// NewEngine returns the engine selected by params. key is the sealing key of
+// this node, it is only used under proof of authority and may be nil on
+// nodes that don't seal blocks.
+func NewEngine(params *ChainParams, key *ecdsa.PrivateKey) (Engine, error) {
+	switch params.Consensus {
+	case ConsensusProofOfWork:
+		return ProofOfWorkEngine{}, nil
+	case ConsensusProofOfAuthority:
+		var authorities [][]byte
+		for _, authority := range params.Authorities {
+			publicKey, err := hex.DecodeString(authority)
+			if err != nil {
+				return nil, err
+			}
+			authorities = append(authorities, publicKey)
+		}
+		return NewProofOfAuthority(authorities, key), nil
+	}
+	return nil, fmt.Errorf("Unknown consensus %q", params.Consensus)
+}
+
+// ProofOfWorkEngine seals blocks with a proof of work at the difficulty
+// their position in the chain requires
+type ProofOfWorkEngine struct{}
+
+// Prepare sets the required difficulty
+func (engine ProofOfWorkEngine) Prepare(chain ChainReader, block *Block) error {
+	difficulty, err := RequiredDifficulty(chain, block)
+	if err != nil {
+		return err
+	}
+	block.Difficulty = difficulty
+	return nil
+}
+
+// Seal searches a nonce with MiningWorkers goroutines until one meets the
+// target or ctx is done
+func (engine ProofOfWorkEngine) Seal(ctx context.Context, chain ChainReader, block *Block) error {
+	err := engine.checkDifficulty(chain, block)
+	if err != nil {
+		return err
+	}
+
+	nonce, hash, err := NewProof(block).Mine(ctx, MiningWorkers)
+	if err != nil {
+		return &ChainError{
+			StatusCode: ErrorMiningAborted,
+			Err:        fmt.Errorf("Mining aborted: %v", err),
+		}
+	}
+	block.Nonce = nonce
+	block.Hash = hash
+	return nil
+}
+
+// Verify checks the difficulty, block hash and proof of work
+func (engine ProofOfWorkEngine) Verify(chain ChainReader, block *Block) error {
+	err := engine.checkDifficulty(chain, block)
+	if err != nil {
+		return err
+	}
+
+	pow := NewProof(block)
+	if !bytes.Equal(pow.Hash(), block.Hash) {
+		return &ChainError{
+			StatusCode: ErrorInvalidHash,
+			Err:        errors.New("Block hash doesn't match block header"),
+		}
+	}
+	if !pow.Validate() {
+		return &ChainError{
+			StatusCode: ErrorInvalidProofOfWork,
+			Err:        errors.New("Invalid proof of work"),
+		}
+	}
+	return nil
+}
+
+// checkDifficulty checks the block difficulty against the one its position
+// requires, or against the allowed range when chain is nil
+func (engine ProofOfWorkEngine) checkDifficulty(chain ChainReader, block *Block) error {
+	if chain == nil {
+		if block.Difficulty < Params.MinDifficulty || block.Difficulty > Params.MaxDifficulty {
+			return &ChainError{
+				StatusCode: ErrorInvalidDifficulty,
+				Err:        fmt.Errorf("Block difficulty %d out of range [%d, %d]", block.Difficulty, Params.MinDifficulty, Params.MaxDifficulty),
+			}
+		}
+		return nil
+	}
+
+	difficulty, err := RequiredDifficulty(chain, block)
+	if err != nil {
+		return err
+	}
+	if block.Difficulty != difficulty {
+		return &ChainError{
+			StatusCode: ErrorInvalidDifficulty,
+			Err:        fmt.Errorf("Block difficulty %d, required %d", block.Difficulty, difficulty),
+		}
+	}
+	return nil
+}
@@ -13,7 +13,58 @@ const (
 	ErrorPreviousHashNotFound = 403
 	// ErrorGenesisExists status code
 	ErrorGenesisExists = 404
-	// ErrorUnknown status code
+	// ErrorInvalidVersion status code
+	ErrorInvalidVersion = 405
+	// ErrorInvalidMerkleRoot status code
+	ErrorInvalidMerkleRoot = 406
+	// ErrorInvalidHeight status code
+	ErrorInvalidHeight = 407
+	// ErrorInvalidTimestamp status code
+	ErrorInvalidTimestamp = 408
+	// ErrorInvalidHash status code
+	ErrorInvalidHash = 409
+	// ErrorInvalidTransaction status code
+	ErrorInvalidTransaction = 410
+	// ErrorBlockTooLarge status code
+	ErrorBlockTooLarge = 411
+	// ErrorTooManyTransactions status code
+	ErrorTooManyTransactions = 412
+	// ErrorTransactionTooLarge status code
+	ErrorTransactionTooLarge = 413
+	// ErrorParamsMismatch status code
+	ErrorParamsMismatch = 414
+	// ErrorInvalidDifficulty status code
+	ErrorInvalidDifficulty = 415
+	// ErrorMiningAborted status code
+	ErrorMiningAborted = 416
+	// ErrorInvalidSeal status code
+	ErrorInvalidSeal = 417
+	// ErrorNotInTurn status code
+	ErrorNotInTurn = 418
+	// ErrorInvalidEvidence status code
+	ErrorInvalidEvidence = 419
+	// ErrorInvalidCheckpoint status code
+	ErrorInvalidCheckpoint = 421
+	// ErrorConflictsFinality status code
+	ErrorConflictsFinality = 422
+	// ErrorInvalidVote status code
+	ErrorInvalidVote = 423
+	// ErrorJobNotFound status code
+	ErrorJobNotFound = 424
+	// ErrorMinerBusy status code
+	ErrorMinerBusy = 425
+	// ErrorBlockTampered status code
+	ErrorBlockTampered = 426
+	// ErrorTimestampTooEarly status code
+	ErrorTimestampTooEarly = 427
+	// ErrorTimestampInFuture status code
+	ErrorTimestampInFuture = 428
+	// ErrorUnsupportedSchema status code
+	ErrorUnsupportedSchema = 429
+	// ErrorBlockNotFound status code
+	ErrorBlockNotFound = 430
+	// ErrorUnknown status code, the catch-all for errors without a code of
+	// their own
 	ErrorUnknown = 420
 )
 
@@ -0,0 +1,271 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	evidenceDomain = "iotchain/evidence/v1"
+)
+
+var (
+	evidencePrefix = []byte("evidence/")
+)
+
+// Evidence proves that a device key signed two different blocks on the same
+// parent. It holds both headers and is signed by the miner that reported it,
+// anyone can check it without the chain.
+type Evidence struct {
+	First     *Block
+	Second    *Block
+	Reporter  []byte
+	Timestamp int64
+	Signature []byte
+}
+
+// NewEvidence creates evidence for two conflicting headers signed by
+// reporterKey. Headers are kept in hash order so every reporter describes
+// the same conflict the same way.
+func NewEvidence(first, second *Block, reporterKey *ecdsa.PrivateKey) (*Evidence, error) {
+	if bytes.Compare(first.Hash, second.Hash) > 0 {
+		first, second = second, first
+	}
+	evidence := Evidence{
+		First:     first.Header(),
+		Second:    second.Header(),
+		Reporter:  EncodePublicKey(&reporterKey.PublicKey),
+		Timestamp: time.Now().UnixNano(),
+	}
+	signature, err := SignDigest(reporterKey, evidence.Digest())
+	if err != nil {
+		return nil, err
+	}
+	evidence.Signature = signature
+	return &evidence, nil
+}
+
+// ID identifies the conflict by the signed contents of both headers, so
+// evidence of the same two blocks from different reporters or with other
+// seals shares the ID
+func (evidence *Evidence) ID() []byte {
+	first, second := evidence.First.SigningDigest(), evidence.Second.SigningDigest()
+	if bytes.Compare(first, second) > 0 {
+		first, second = second, first
+	}
+	hash := sha256.Sum256(append(append([]byte{}, first...), second...))
+	return hash[:]
+}
+
+// Digest returns the digest the reporter signs
+func (evidence *Evidence) Digest() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, []byte(evidenceDomain))
+	writeField(&buffer, []byte(Params.NetworkID))
+	writeField(&buffer, evidence.First.Hash)
+	writeField(&buffer, evidence.Second.Hash)
+	writeField(&buffer, evidence.Reporter)
+	buffer.Write(ToHex(evidence.Timestamp))
+
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
+
+// Verify checks that both headers are intact, share parent, token and device
+// key, carry valid device signatures over different contents, and that the
+// reporter signature is valid
+func (evidence *Evidence) Verify() error {
+	first, second := evidence.First, evidence.Second
+	if first == nil || second == nil {
+		return errors.New("Evidence needs two headers")
+	}
+	if bytes.Compare(first.Hash, second.Hash) >= 0 {
+		return errors.New("Evidence headers are not distinct and in hash order")
+	}
+	for _, header := range []*Block{first, second} {
+		if !bytes.Equal(header.ComputeHash(), header.Hash) {
+			return fmt.Errorf("Header hash %X doesn't match header", header.Hash)
+		}
+		if !header.VerifySignature() {
+			return fmt.Errorf("Header %X has invalid signature", header.Hash)
+		}
+	}
+	if first.IsGenesis() || !bytes.Equal(first.PrevHash, second.PrevHash) {
+		return errors.New("Headers don't share a parent")
+	}
+	if !bytes.Equal(first.Token, second.Token) || !bytes.Equal(first.PublicKey, second.PublicKey) {
+		return errors.New("Headers don't share token and device key")
+	}
+	if bytes.Equal(first.SigningDigest(), second.SigningDigest()) {
+		return errors.New("Headers carry the same signed content")
+	}
+	if !VerifyDigest(evidence.Reporter, evidence.Digest(), evidence.Signature) {
+		return errors.New("Reporter signature can't be verified")
+	}
+	return nil
+}
+
+// Proto converts the evidence to its wire and storage message
+func (evidence *Evidence) Proto() *EvidenceMessage {
+	return &EvidenceMessage{
+		First:     evidence.First.Proto(),
+		Second:    evidence.Second.Proto(),
+		Reporter:  evidence.Reporter,
+		Timestamp: evidence.Timestamp,
+		Signature: evidence.Signature,
+	}
+}
+
+// EvidenceFromProto converts a wire or storage message to evidence
+func EvidenceFromProto(msg *EvidenceMessage) *Evidence {
+	evidence := Evidence{
+		Reporter:  msg.GetReporter(),
+		Timestamp: msg.GetTimestamp(),
+		Signature: msg.GetSignature(),
+	}
+	if msg.GetFirst() != nil {
+		evidence.First = BlockFromProto(msg.GetFirst())
+	}
+	if msg.GetSecond() != nil {
+		evidence.Second = BlockFromProto(msg.GetSecond())
+	}
+	return &evidence
+}
+
+// String prints the evidence
+func (evidence *Evidence) String() string {
+	return fmt.Sprintf("\n----Evidence: %X\n Reporter  : %X\n Timestamp : %s\n Token     : %X\n PublicKey : %X\n Parent    : %X\n First     : %X\n Second    : %X",
+		evidence.ID(),
+		evidence.Reporter,
+		time.Unix(0, evidence.Timestamp).UTC().Format(time.RFC3339Nano),
+		evidence.First.Token,
+		evidence.First.PublicKey,
+		evidence.First.PrevHash,
+		evidence.First.Hash,
+		evidence.Second.Hash,
+	)
+}
+
+// evidenceKey holds the evidence with id
+func evidenceKey(id []byte) []byte {
+	return append(append([]byte{}, evidencePrefix...), id...)
+}
+
+// findEquivocationTxn returns a stored sibling of block that the same device
+// key signed with different content, nil when there is none
+func findEquivocationTxn(txn StoreTxn, block *Block) (*Block, error) {
+	if block.IsGenesis() {
+		return nil, nil
+	}
+	siblings, err := childrenTxn(txn, block.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range siblings {
+		sibling, err := getBlockTxn(txn, hash)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(sibling.Token, block.Token) &&
+			bytes.Equal(sibling.PublicKey, block.PublicKey) &&
+			!bytes.Equal(sibling.SigningDigest(), block.SigningDigest()) {
+			return sibling, nil
+		}
+	}
+	return nil, nil
+}
+
+// reportEquivocation records evidence of two conflicting blocks signed with
+// the node key and gossips it to connected nodes. Nodes without a key only
+// log the conflict.
+func (chain *BlockChain) reportEquivocation(first, second *Block) {
+	logrus.Warnf("Device key %X signed conflicting blocks %X and %X", second.PublicKey, first.Hash, second.Hash)
+	if chain.NodeKey == nil {
+		return
+	}
+	evidence, err := NewEvidence(first, second, chain.NodeKey)
+	if err != nil {
+		logrus.Errorf("Can't create evidence: %v\n", err)
+		return
+	}
+	added, err := chain.AddEvidence(evidence)
+	if err != nil {
+		logrus.Errorf("Can't record evidence: %v\n", err)
+		return
+	}
+	if !added {
+		return
+	}
+
+	network := Network{}
+	for addr := range connectedNodes() {
+		go network.PropagateEvidence(evidence.Proto(), addr)
+	}
+}
+
+// AddEvidence verifies and stores evidence, it returns false when evidence of
+// the same conflict is already stored
+func (chain *BlockChain) AddEvidence(evidence *Evidence) (bool, error) {
+	err := evidence.Verify()
+	if err != nil {
+		return false, &ChainError{
+			StatusCode: ErrorInvalidEvidence,
+			Err:        err,
+		}
+	}
+	data, err := marshalDeterministic(evidence.Proto())
+	if err != nil {
+		return false, err
+	}
+
+	added := false
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		key := evidenceKey(evidence.ID())
+		_, err := txn.Get(key)
+		if err == nil {
+			return nil
+		}
+		if err != ErrKeyNotFound {
+			return err
+		}
+		added = true
+		return txn.Set(key, data)
+	})
+	if err != nil {
+		return false, err
+	}
+	if added {
+		logrus.Warnf("Recorded evidence %X against device key %X", evidence.ID(), evidence.First.PublicKey)
+	}
+	return added, nil
+}
+
+// Evidence returns the stored evidence against the chain of token, or all
+// stored evidence when token is empty
+func (chain *BlockChain) Evidence(token []byte) ([]*Evidence, error) {
+	var evidenceList []*Evidence
+	err := chain.Store.Iterate(evidencePrefix, func(key, value []byte) error {
+		var msg EvidenceMessage
+		err := proto.Unmarshal(value, &msg)
+		if err != nil {
+			return err
+		}
+		evidence := EvidenceFromProto(&msg)
+		if len(token) == 0 || bytes.Equal(evidence.First.Token, token) {
+			evidenceList = append(evidenceList, evidence)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return evidenceList, nil
+}
@@ -0,0 +1,471 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checkpointVoteDomain = "iotchain/checkpoint-vote/v1"
+)
+
+var (
+	votePrefix = []byte("vote/")
+	finalKey   = []byte("index/final-checkpoint")
+)
+
+// CheckpointVote is the signature of a miner on a checkpoint. A checkpoint
+// signed by Params.FinalityQuorum miners is final, the device chain tips it
+// commits to are never reorganized.
+type CheckpointVote struct {
+	Height         int64
+	CheckpointHash []byte
+	Signer         []byte
+	Signature      []byte
+}
+
+// NewCheckpointVote signs checkpoint with key
+func NewCheckpointVote(checkpoint *Checkpoint, key *ecdsa.PrivateKey) (*CheckpointVote, error) {
+	vote := CheckpointVote{
+		Height:         checkpoint.Height,
+		CheckpointHash: checkpoint.Hash,
+		Signer:         EncodePublicKey(&key.PublicKey),
+	}
+	signature, err := SignDigest(key, vote.Digest())
+	if err != nil {
+		return nil, err
+	}
+	vote.Signature = signature
+	return &vote, nil
+}
+
+// Digest returns the digest a miner signs
+func (vote *CheckpointVote) Digest() []byte {
+	var buffer bytes.Buffer
+
+	writeField(&buffer, []byte(checkpointVoteDomain))
+	writeField(&buffer, []byte(Params.NetworkID))
+	buffer.Write(ToHex(vote.Height))
+	writeField(&buffer, vote.CheckpointHash)
+
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
+
+// Verify checks that the vote is signed by a known miner
+func (vote *CheckpointVote) Verify() error {
+	if !Params.IsMiner(vote.Signer) {
+		return fmt.Errorf("Signer %X is not a known miner", vote.Signer)
+	}
+	if !VerifyDigest(vote.Signer, vote.Digest(), vote.Signature) {
+		return errors.New("Vote signature can't be verified")
+	}
+	return nil
+}
+
+// Proto converts the vote to its wire and storage message
+func (vote *CheckpointVote) Proto() *CheckpointVoteMessage {
+	return &CheckpointVoteMessage{
+		Height:         vote.Height,
+		CheckpointHash: vote.CheckpointHash,
+		Signer:         vote.Signer,
+		Signature:      vote.Signature,
+	}
+}
+
+// CheckpointVoteFromProto converts a wire or storage message to a vote
+func CheckpointVoteFromProto(msg *CheckpointVoteMessage) *CheckpointVote {
+	return &CheckpointVote{
+		Height:         msg.GetHeight(),
+		CheckpointHash: msg.GetCheckpointHash(),
+		Signer:         msg.GetSigner(),
+		Signature:      msg.GetSignature(),
+	}
+}
+
+// VerifyQuorum checks that votes hold valid signatures of at least
+// Params.FinalityQuorum distinct miners on checkpoint
+func VerifyQuorum(checkpoint *Checkpoint, votes []*CheckpointVote) error {
+	if Params.FinalityQuorum == 0 {
+		return errors.New("Finality is disabled")
+	}
+	signers := make(map[string]bool)
+	for _, vote := range votes {
+		if vote.Height != checkpoint.Height || !bytes.Equal(vote.CheckpointHash, checkpoint.Hash) {
+			continue
+		}
+		if vote.Verify() == nil {
+			signers[string(vote.Signer)] = true
+		}
+	}
+	if len(signers) < Params.FinalityQuorum {
+		return fmt.Errorf("Checkpoint %d has %d valid votes, quorum is %d", checkpoint.Height, len(signers), Params.FinalityQuorum)
+	}
+	return nil
+}
+
+// voteKey holds the vote of signer at height, a signer votes once per height
+func voteKey(height int64, signer []byte) []byte {
+	key := append(append([]byte{}, votePrefix...), ToHex(height)...)
+	return append(key, signer...)
+}
+
+// votesTxn returns the stored votes for the checkpoint with hash at height
+func votesTxn(txn StoreTxn, height int64, hash []byte) ([]*CheckpointVote, error) {
+	prefix := append(append([]byte{}, votePrefix...), ToHex(height)...)
+	var votes []*CheckpointVote
+	err := txn.Iterate(prefix, false, func(key, value []byte) error {
+		var msg CheckpointVoteMessage
+		err := proto.Unmarshal(value, &msg)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(msg.GetCheckpointHash(), hash) {
+			votes = append(votes, CheckpointVoteFromProto(&msg))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+// finalHeightTxn returns the height of the last final checkpoint, -1 when no
+// checkpoint is final
+func finalHeightTxn(txn StoreTxn) (int64, error) {
+	data, err := txn.Get(finalKey)
+	if err == ErrKeyNotFound {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
+}
+
+// finalTipTxn returns the tip of the device chain at address in the last
+// final checkpoint, nil when there is none
+func finalTipTxn(txn StoreTxn, address []byte) ([]byte, error) {
+	height, err := finalHeightTxn(txn)
+	if err != nil || height < 0 {
+		return nil, err
+	}
+	checkpoint, err := getCheckpointTxn(txn, height)
+	if err != nil {
+		return nil, err
+	}
+	index := sort.Search(len(checkpoint.Tips), func(i int) bool {
+		return bytes.Compare(checkpoint.Tips[i].Address, address) >= 0
+	})
+	if index == len(checkpoint.Tips) || !bytes.Equal(checkpoint.Tips[index].Address, address) {
+		return nil, nil
+	}
+	return checkpoint.Tips[index].Hash, nil
+}
+
+// checkFinalityTxn checks that block extends the final tip of its device
+// chain, so it can never reorganize a final block. Final tips this node
+// doesn't hold yet can't be checked.
+func checkFinalityTxn(txn StoreTxn, address []byte, block *Block) error {
+	finalTip, err := finalTipTxn(txn, address)
+	if err != nil || finalTip == nil {
+		return err
+	}
+	final, err := getBlockTxn(txn, finalTip)
+	if err == ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ancestor := block
+	for ancestor.Height > final.Height {
+		ancestor, err = getBlockTxn(txn, ancestor.PrevHash)
+		if err != nil {
+			return err
+		}
+	}
+	if !bytes.Equal(ancestor.Hash, final.Hash) {
+		return &ChainError{
+			StatusCode: ErrorConflictsFinality,
+			Err:        fmt.Errorf("Block %X forks the chain of %s below final block %X", block.Hash, address, final.Hash),
+		}
+	}
+	return nil
+}
+
+// onCanonicalChainTxn reports whether hash is the tip of the device chain at
+// address or one of its ancestors
+func onCanonicalChainTxn(txn StoreTxn, address, hash []byte) (bool, error) {
+	target, err := getBlockTxn(txn, hash)
+	if err == ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	tip, err := txn.Get(tipKey(address))
+	if err == ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	block, err := getBlockTxn(txn, tip)
+	if err != nil {
+		return false, err
+	}
+	for block.Height > target.Height {
+		block, err = getBlockTxn(txn, block.PrevHash)
+		if err != nil {
+			return false, err
+		}
+	}
+	return bytes.Equal(block.Hash, target.Hash), nil
+}
+
+// reorgFinalTxn moves the tip of the device chain at address to the heaviest
+// chain extending its final block when a rival branch is canonical here. The
+// stats and the height index follow the tip. Final blocks this node doesn't
+// hold yet are left to the sync.
+func reorgFinalTxn(txn StoreTxn, address, final []byte) error {
+	canonical, err := onCanonicalChainTxn(txn, address, final)
+	if err != nil || canonical {
+		return err
+	}
+	_, err = getBlockTxn(txn, final)
+	if err == ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	best, err := heaviestChainTxn(txn, final)
+	if err != nil {
+		return err
+	}
+	block, err := getBlockTxn(txn, best)
+	if err != nil {
+		return err
+	}
+	tip, err := txn.Get(tipKey(address))
+	if err != nil {
+		return err
+	}
+	fork, depth, err := forkPointTxn(txn, tip, block)
+	if err != nil {
+		return err
+	}
+	logrus.Warnf("Reorganized chain of %s to final block %X at block %X, %d blocks replaced, new tip %X", address, final, fork, depth, block.Hash)
+	return setTipTxn(txn, address, block)
+}
+
+// endorseCheckpoint votes for a stored checkpoint with the node key when the
+// node is a known miner, hasn't voted at this height yet and every tip of the
+// checkpoint is on the canonical chain of its device here. The vote is
+// gossiped to connected nodes. Miners only vote once rival checkpoints had
+// time to arrive, see RunCheckpoints, since every node keeps the same one of
+// them they vote alike. A height whose votes split anyway is never final, the
+// next checkpoint gets fresh votes and finalizes it along with itself.
+func (chain *BlockChain) endorseCheckpoint(checkpoint *Checkpoint) {
+	if Params.FinalityQuorum == 0 || chain.NodeKey == nil {
+		return
+	}
+	signer := EncodePublicKey(&chain.NodeKey.PublicKey)
+	if !Params.IsMiner(signer) {
+		return
+	}
+
+	endorse := true
+	err := chain.Store.View(func(txn StoreTxn) error {
+		_, err := txn.Get(voteKey(checkpoint.Height, signer))
+		if err == nil {
+			endorse = false
+			return nil
+		}
+		if err != ErrKeyNotFound {
+			return err
+		}
+		stored, err := getCheckpointTxn(txn, checkpoint.Height)
+		if err == ErrKeyNotFound || (err == nil && !bytes.Equal(stored.Hash, checkpoint.Hash)) {
+			endorse = false
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, tip := range checkpoint.Tips {
+			canonical, err := onCanonicalChainTxn(txn, tip.Address, tip.Hash)
+			if err != nil {
+				return err
+			}
+			if !canonical {
+				logrus.Infof("Not voting for checkpoint %d, tip %X of %s is not canonical here", checkpoint.Height, tip.Hash, tip.Address)
+				endorse = false
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Errorf("Can't check checkpoint %d: %v\n", checkpoint.Height, err)
+		return
+	}
+	if !endorse {
+		return
+	}
+
+	vote, err := NewCheckpointVote(checkpoint, chain.NodeKey)
+	if err != nil {
+		logrus.Errorf("Can't vote for checkpoint %d: %v\n", checkpoint.Height, err)
+		return
+	}
+	_, err = chain.AddVote(vote)
+	if err != nil {
+		logrus.Errorf("Can't record vote for checkpoint %d: %v\n", checkpoint.Height, err)
+		return
+	}
+
+	network := Network{}
+	for addr := range connectedNodes() {
+		go network.PropagateVote(vote.Proto(), addr)
+	}
+}
+
+// AddVote verifies and stores a vote, it returns false when the signer
+// already voted at that height. The voted checkpoint becomes final once it
+// is stored and has a quorum of votes.
+func (chain *BlockChain) AddVote(vote *CheckpointVote) (bool, error) {
+	err := vote.Verify()
+	if err != nil {
+		return false, &ChainError{
+			StatusCode: ErrorInvalidVote,
+			Err:        err,
+		}
+	}
+	data, err := marshalDeterministic(vote.Proto())
+	if err != nil {
+		return false, err
+	}
+
+	added := false
+	err = chain.Store.Update(func(txn StoreTxn) error {
+		key := voteKey(vote.Height, vote.Signer)
+		value, err := txn.Get(key)
+		if err == nil {
+			var stored CheckpointVoteMessage
+			err = proto.Unmarshal(value, &stored)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(stored.GetCheckpointHash(), vote.CheckpointHash) {
+				logrus.Warnf("Miner %X voted for two checkpoints at height %d", vote.Signer, vote.Height)
+			}
+			return nil
+		}
+		if err != ErrKeyNotFound {
+			return err
+		}
+		added = true
+		return txn.Set(key, data)
+	})
+	if err != nil || !added {
+		return added, err
+	}
+
+	_, err = chain.finalize(vote.Height, vote.CheckpointHash)
+	if err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// finalize marks the stored checkpoint with hash at height final when it has
+// a quorum of votes and is above the last final checkpoint. Device chains
+// that follow a rival branch here are reorganized to its tips in the same
+// transaction. A newly final checkpoint is gossiped again, so nodes that kept
+// a rival replace it.
+func (chain *BlockChain) finalize(height int64, hash []byte) (bool, error) {
+	if Params.FinalityQuorum == 0 {
+		return false, nil
+	}
+
+	var checkpoint *Checkpoint
+	err := chain.Store.Update(func(txn StoreTxn) error {
+		finalHeight, err := finalHeightTxn(txn)
+		if err != nil || height <= finalHeight {
+			return err
+		}
+		stored, err := getCheckpointTxn(txn, height)
+		if err == ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(stored.Hash, hash) {
+			return nil
+		}
+		votes, err := votesTxn(txn, height, hash)
+		if err != nil {
+			return err
+		}
+		if len(votes) < Params.FinalityQuorum {
+			return nil
+		}
+
+		for _, tip := range stored.Tips {
+			err = reorgFinalTxn(txn, tip.Address, tip.Hash)
+			if err != nil {
+				return err
+			}
+		}
+		checkpoint = stored
+		return txn.Set(finalKey, ToHex(height))
+	})
+	if err != nil || checkpoint == nil {
+		return false, err
+	}
+	logrus.Infof("Checkpoint %d over %d device chains is final", checkpoint.Height, checkpoint.TipCount)
+
+	network := Network{}
+	for addr := range connectedNodes() {
+		go network.PropagateCheckpoint(checkpoint.Proto(), addr)
+	}
+	return true, nil
+}
+
+// FinalCheckpoint returns the last final checkpoint with its votes, nil when
+// no checkpoint is final
+func (chain *BlockChain) FinalCheckpoint() (*Checkpoint, []*CheckpointVote, error) {
+	var checkpoint *Checkpoint
+	var votes []*CheckpointVote
+	err := chain.Store.View(func(txn StoreTxn) error {
+		height, err := finalHeightTxn(txn)
+		if err != nil || height < 0 {
+			return err
+		}
+		checkpoint, err = getCheckpointTxn(txn, height)
+		if err != nil {
+			return err
+		}
+		votes, err = votesTxn(txn, height, checkpoint.Hash)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return checkpoint, votes, nil
+}
@@ -0,0 +1,213 @@
+package blockchain
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	childPrefix = []byte("index/child/")
+	workPrefix  = []byte("index/work/")
+)
+
+// childKey indexes child under parent, the value is empty
+func childKey(parent, child []byte) []byte {
+	return append(append(append([]byte{}, childPrefix...), parent...), child...)
+}
+
+// workKey holds the total work of the chain ending at hash
+func workKey(hash []byte) []byte {
+	return append(append([]byte{}, workPrefix...), hash...)
+}
+
+// BlockWork returns the work a block adds to its chain, 2^Difficulty. Blocks
+// without a proof of work count one each.
+func BlockWork(block *Block) *big.Int {
+	work := big.NewInt(1)
+	if block.Difficulty > 0 {
+		work.Lsh(work, uint(block.Difficulty))
+	}
+	return work
+}
+
+// Children returns the hashes of the blocks built on parent, more than one
+// child means the device chain forked at parent
+func (chain *BlockChain) Children(parent []byte) ([][]byte, error) {
+	var children [][]byte
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		children, err = childrenTxn(txn, parent)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// ChainWork returns the total work of the chain ending at hash
+func (chain *BlockChain) ChainWork(hash []byte) (*big.Int, error) {
+	var work *big.Int
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		work, err = chainWorkTxn(txn, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return work, nil
+}
+
+// childrenTxn returns the hashes of the blocks built on parent within txn
+func childrenTxn(txn StoreTxn, parent []byte) ([][]byte, error) {
+	prefix := childKey(parent, nil)
+	var children [][]byte
+	err := txn.Iterate(prefix, false, func(key, value []byte) error {
+		children = append(children, key[len(prefix):])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// chainWorkTxn returns the total work of the chain ending at hash. The work
+// of blocks stored before work was tracked is summed up from their blocks.
+func chainWorkTxn(txn StoreTxn, hash []byte) (*big.Int, error) {
+	work := big.NewInt(0)
+	for {
+		data, err := txn.Get(workKey(hash))
+		if err == nil {
+			return work.Add(work, new(big.Int).SetBytes(data)), nil
+		}
+		if err != ErrKeyNotFound {
+			return nil, err
+		}
+
+		block, err := getBlockTxn(txn, hash)
+		if err != nil {
+			return nil, err
+		}
+		work.Add(work, BlockWork(block))
+		if block.IsGenesis() {
+			break
+		}
+		hash = block.PrevHash
+	}
+	return work, nil
+}
+
+// connectBlockTxn indexes a stored block under its parent, records the work
+// of its chain and moves the tip of the device chain to it when it wins the
+// fork choice: the chain with the most work, on a tie the lowest tip hash.
+func connectBlockTxn(txn StoreTxn, address []byte, block *Block) error {
+	work := BlockWork(block)
+	if !block.IsGenesis() {
+		siblings, err := childrenTxn(txn, block.PrevHash)
+		if err != nil {
+			return err
+		}
+		if len(siblings) > 0 {
+			logrus.Warnf("Fork detected at block %X of %s, %d competing blocks", block.PrevHash, address, len(siblings)+1)
+		}
+		err = txn.Set(childKey(block.PrevHash, block.Hash), []byte{})
+		if err != nil {
+			return err
+		}
+
+		parentWork, err := chainWorkTxn(txn, block.PrevHash)
+		if err != nil {
+			return err
+		}
+		work.Add(work, parentWork)
+	}
+	err := txn.Set(workKey(block.Hash), work.Bytes())
+	if err != nil {
+		return err
+	}
+
+	tip, err := txn.Get(tipKey(address))
+	if err == ErrKeyNotFound {
+		return setTipTxn(txn, address, block)
+	}
+	if err != nil {
+		return err
+	}
+	tipWork, err := chainWorkTxn(txn, tip)
+	if err != nil {
+		return err
+	}
+
+	cmp := work.Cmp(tipWork)
+	if cmp < 0 || cmp == 0 && bytes.Compare(block.Hash, tip) > 0 {
+		logrus.Infof("Block %X of %s added to a side branch", block.Hash, address)
+		return nil
+	}
+	if !bytes.Equal(block.PrevHash, tip) {
+		fork, depth, err := forkPointTxn(txn, tip, block)
+		if err != nil {
+			return err
+		}
+		logrus.Warnf("Reorganized chain of %s at block %X, %d blocks replaced, new tip %X", address, fork, depth, block.Hash)
+	}
+	return setTipTxn(txn, address, block)
+}
+
+// heaviestChainTxn returns the tip of the chain with the most work that
+// extends the block with hash, on a tie the lowest tip hash
+func heaviestChainTxn(txn StoreTxn, hash []byte) ([]byte, error) {
+	best := hash
+	bestWork, err := chainWorkTxn(txn, hash)
+	if err != nil {
+		return nil, err
+	}
+	queue := [][]byte{hash}
+	for len(queue) > 0 {
+		children, err := childrenTxn(txn, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+		for _, child := range children {
+			work, err := chainWorkTxn(txn, child)
+			if err != nil {
+				return nil, err
+			}
+			cmp := work.Cmp(bestWork)
+			if cmp > 0 || cmp == 0 && bytes.Compare(child, best) < 0 {
+				best, bestWork = child, work
+			}
+			queue = append(queue, child)
+		}
+	}
+	return best, nil
+}
+
+// forkPointTxn returns the last block the chain ending at tip shares with the
+// chain ending at block and the number of blocks of tip's chain after it
+func forkPointTxn(txn StoreTxn, tip []byte, block *Block) ([]byte, int64, error) {
+	old, err := getBlockTxn(txn, tip)
+	if err != nil {
+		return nil, 0, err
+	}
+	tipHeight := old.Height
+	candidate := block
+	for !bytes.Equal(old.Hash, candidate.Hash) {
+		if old.IsGenesis() && candidate.IsGenesis() {
+			return nil, tipHeight + 1, nil
+		}
+		if old.Height >= candidate.Height {
+			old, err = getBlockTxn(txn, old.PrevHash)
+		} else {
+			candidate, err = getBlockTxn(txn, candidate.PrevHash)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return old.Hash, tipHeight - old.Height, nil
+}
@@ -0,0 +1,350 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	txIndexPrefix     = []byte("index/tx/")
+	timeIndexPrefix   = []byte("index/time/")
+	heightIndexPrefix = []byte("index/height/")
+)
+
+// txIndexKey indexes the block with hash under the id of one of its
+// transactions, the value is empty
+func txIndexKey(id, hash []byte) []byte {
+	return append(append(append([]byte{}, txIndexPrefix...), id...), hash...)
+}
+
+// timeIndexKey indexes the block with hash under its device chain and
+// timestamp, the value is empty. Keys of a device chain sort by timestamp.
+func timeIndexKey(address []byte, timestamp int64, hash []byte) []byte {
+	key := append(append(append([]byte{}, timeIndexPrefix...), address...), '/')
+	return append(append(key, ToHex(timestamp)...), hash...)
+}
+
+// heightIndexKey holds the hash of the block at height on the canonical
+// chain of the device at address
+func heightIndexKey(address []byte, height int64) []byte {
+	key := append(append(append([]byte{}, heightIndexPrefix...), address...), '/')
+	return append(key, ToHex(height)...)
+}
+
+// indexBlockTxn indexes a newly stored block by its transactions and its
+// timestamp. Side branches are indexed too, lookups check whether a block is
+// canonical.
+func indexBlockTxn(txn StoreTxn, address []byte, block *Block) error {
+	for _, tx := range block.Transactions {
+		err := txn.Set(txIndexKey(tx.ID, block.Hash), []byte{})
+		if err != nil {
+			return err
+		}
+	}
+	return txn.Set(timeIndexKey(address, block.Timestamp, block.Hash), []byte{})
+}
+
+// indexCanonicalTxn points the height index of the device chain at address
+// at the chain ending at block. Heights above block left by a longer branch,
+// up to height, are dropped.
+func indexCanonicalTxn(txn StoreTxn, address []byte, block *Block, height int64) error {
+	for h := block.Height + 1; h < height; h++ {
+		err := txn.Delete(heightIndexKey(address, h))
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		key := heightIndexKey(address, block.Height)
+		hash, err := txn.Get(key)
+		if err == nil && bytes.Equal(hash, block.Hash) {
+			return nil
+		}
+		if err != nil && err != ErrKeyNotFound {
+			return err
+		}
+		err = txn.Set(key, block.Hash)
+		if err != nil {
+			return err
+		}
+		if block.IsGenesis() {
+			return nil
+		}
+		block, err = getBlockTxn(txn, block.PrevHash)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// canonicalTxn reports whether block is on the canonical chain of the device
+// at address
+func canonicalTxn(txn StoreTxn, address []byte, block *Block) (bool, error) {
+	hash, err := txn.Get(heightIndexKey(address, block.Height))
+	if err == ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(hash, block.Hash), nil
+}
+
+// blockNotFound is returned by lookups that match no block
+func blockNotFound(format string, args ...interface{}) error {
+	return &ChainError{
+		StatusCode: ErrorBlockNotFound,
+		Err:        fmt.Errorf(format, args...),
+	}
+}
+
+// BlockAtHeight returns the block at height on the canonical chain of token
+func (chain *BlockChain) BlockAtHeight(token []byte, height int64) (*Block, error) {
+	address, err := Address(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *Block
+	err = chain.Store.View(func(txn StoreTxn) error {
+		hash, err := txn.Get(heightIndexKey(address, height))
+		if err == ErrKeyNotFound {
+			return blockNotFound("No block at height %d of %s", height, address)
+		}
+		if err != nil {
+			return err
+		}
+		block, err = getBlockTxn(txn, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// TransactionBlock returns the block holding the transaction with id. When
+// the transaction is in several blocks the canonical one is returned.
+func (chain *BlockChain) TransactionBlock(id []byte) (*Block, error) {
+	if len(id) != sha256.Size {
+		return nil, errors.New("Malformed transaction ID")
+	}
+
+	var found *Block
+	err := chain.Store.View(func(txn StoreTxn) error {
+		prefix := txIndexKey(id, nil)
+		return txn.Iterate(prefix, false, func(key, value []byte) error {
+			block, err := getBlockTxn(txn, key[len(prefix):])
+			if err != nil {
+				return err
+			}
+			address, err := Address(block.Token)
+			if err != nil {
+				return err
+			}
+			canonical, err := canonicalTxn(txn, address, block)
+			if err != nil {
+				return err
+			}
+			if found == nil || canonical {
+				found = block
+			}
+			if canonical {
+				return errStopIteration
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, blockNotFound("No block holds transaction %X", id)
+	}
+	return found, nil
+}
+
+// BlocksBetween calls fn with the blocks on the canonical chain of token
+// with a timestamp from from to to, both included, in timestamp order. Like
+// an Iterator it reads the blocks in batches and calls fn outside of the
+// read transaction.
+func (chain *BlockChain) BlocksBetween(token []byte, from, to int64, fn func(block *Block) error) error {
+	address, err := Address(token)
+	if err != nil {
+		return err
+	}
+
+	var after []byte
+	for from <= to {
+		// only keys sharing the leading bytes of both bounds can be in range
+		first, last := timeIndexKey(address, from, nil), timeIndexKey(address, to, nil)
+		n := 0
+		for n < len(first) && first[n] == last[n] {
+			n++
+		}
+		offset := len(first) - 8
+
+		var batch []*Block
+		more := false
+		err := chain.Store.View(func(txn StoreTxn) error {
+			return txn.Iterate(first[:n], false, func(key, value []byte) error {
+				if after != nil && bytes.Compare(key, after) <= 0 {
+					return nil
+				}
+				timestamp := int64(binary.BigEndian.Uint64(key[offset : offset+8]))
+				if timestamp < from {
+					return nil
+				}
+				if timestamp > to {
+					return errStopIteration
+				}
+				if len(batch) == iteratorBatch {
+					more = true
+					return errStopIteration
+				}
+				after = key
+				block, err := getBlockTxn(txn, key[offset+8:])
+				if err != nil {
+					return err
+				}
+				canonical, err := canonicalTxn(txn, address, block)
+				if err != nil || !canonical {
+					return err
+				}
+				batch = append(batch, block)
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+		for _, block := range batch {
+			err = fn(block)
+			if err != nil {
+				return err
+			}
+		}
+		if !more {
+			return nil
+		}
+		from = int64(binary.BigEndian.Uint64(after[offset : offset+8]))
+	}
+	return nil
+}
+
+// Reindex counts the stats and builds the lookup indexes of the store again
+// from its blocks and tips, after blocks were written without going through
+// AddBlock
+func (chain *BlockChain) Reindex() error {
+	_, err := rebuildStats(chain.Store)
+	if err != nil {
+		return err
+	}
+	_, err = rebuildIndexes(chain.Store)
+	return err
+}
+
+// rebuildIndexes replaces the lookup indexes of store with indexes over its
+// blocks and tips and returns the number of index keys written
+func rebuildIndexes(store ChainStore) (int, error) {
+	var stale [][]byte
+	for _, prefix := range [][]byte{txIndexPrefix, timeIndexPrefix, heightIndexPrefix} {
+		err := store.Iterate(prefix, func(key, value []byte) error {
+			stale = append(stale, key)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	type link struct {
+		prevHash []byte
+		height   int64
+	}
+	links := make(map[string]link)
+	entries := make(map[string][]byte)
+	err := store.Iterate(blockPrefix, func(key, value []byte) error {
+		block, err := decodeStoredBlock(key[len(blockPrefix):], value)
+		if err != nil {
+			return nil
+		}
+		address, err := Address(block.Token)
+		if err != nil {
+			return err
+		}
+		links[string(block.Hash)] = link{prevHash: block.PrevHash, height: block.Height}
+		for _, tx := range block.Transactions {
+			entries[string(txIndexKey(tx.ID, block.Hash))] = []byte{}
+		}
+		entries[string(timeIndexKey(address, block.Timestamp, block.Hash))] = []byte{}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	tips, err := store.Tips()
+	if err != nil {
+		return 0, err
+	}
+	for _, tip := range tips {
+		hash := tip.Hash
+		for {
+			l, ok := links[string(hash)]
+			if !ok {
+				break
+			}
+			entries[string(heightIndexKey(tip.Address, l.height))] = hash
+			if len(l.prevHash) == 0 {
+				break
+			}
+			hash = l.prevHash
+		}
+	}
+
+	for _, key := range stale {
+		if _, ok := entries[string(key)]; !ok {
+			entries[string(key)] = nil
+		}
+	}
+	var keys []string
+	written := 0
+	for key, value := range entries {
+		keys = append(keys, key)
+		if value != nil {
+			written++
+		}
+	}
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > migrateBatch {
+			batch = batch[:migrateBatch]
+		}
+		err := store.Update(func(txn StoreTxn) error {
+			for _, key := range batch {
+				var err error
+				if value := entries[key]; value == nil {
+					err = txn.Delete([]byte(key))
+				} else {
+					err = txn.Set([]byte(key), value)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == ErrTxnConflict {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		keys = keys[len(batch):]
+	}
+	return written, nil
+}
@@ -0,0 +1,191 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// iteratorBatch is the number of blocks an Iterator reads per transaction
+var iteratorBatch = 64
+
+// Direction is the way an Iterator walks a device chain
+type Direction int
+
+const (
+	// Backward walks from the tip back to genesis
+	Backward Direction = iota
+	// Forward walks from genesis to the tip
+	Forward
+)
+
+// Iterator walks the canonical chain of a device. Blocks are read in batches,
+// each in one read transaction, so the chain is never held in memory at once.
+// Call Next until it returns false, then Err tells whether the walk ended at
+// the end of the chain or on an error.
+type Iterator struct {
+	store     ChainStore
+	address   []byte
+	direction Direction
+
+	startHash   []byte
+	startHeight int64
+
+	buffer  []*Block
+	last    *Block
+	block   *Block
+	end     bool
+	pending error
+	err     error
+}
+
+// NewIterator returns an iterator over the chain of token, starting at the
+// tip when walking backward or at genesis when walking forward
+func (chain *BlockChain) NewIterator(token []byte, direction Direction) (*Iterator, error) {
+	address, err := Address(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{
+		store:       chain.Store,
+		address:     address,
+		direction:   direction,
+		startHeight: -1,
+	}, nil
+}
+
+// SeekHeight starts the walk at the canonical block at height, it must be
+// called before the first Next
+func (itr *Iterator) SeekHeight(height int64) {
+	itr.startHash = nil
+	itr.startHeight = height
+}
+
+// SeekHash starts the walk at the block with hash, it must be called before
+// the first Next. Walking forward the block must be on the canonical chain,
+// walking backward any block of the device is followed back to genesis.
+func (itr *Iterator) SeekHash(hash []byte) {
+	itr.startHash = hash
+	itr.startHeight = -1
+}
+
+// Next moves to the next block and reports whether there is one
+func (itr *Iterator) Next() bool {
+	itr.block = nil
+	if len(itr.buffer) == 0 && !itr.end {
+		// blocks read before an error are still handed out
+		err := itr.store.View(itr.fill)
+		if err != nil {
+			itr.end = true
+			itr.pending = err
+		}
+	}
+	if len(itr.buffer) == 0 {
+		itr.err = itr.pending
+		return false
+	}
+	itr.block, itr.buffer = itr.buffer[0], itr.buffer[1:]
+	return true
+}
+
+// Block returns the block Next moved to
+func (itr *Iterator) Block() *Block {
+	return itr.block
+}
+
+// Err returns the error that stopped the walk, nil when it reached the end
+// of the chain
+func (itr *Iterator) Err() error {
+	return itr.err
+}
+
+// fill reads the next batch of blocks within txn
+func (itr *Iterator) fill(txn StoreTxn) error {
+	for len(itr.buffer) < iteratorBatch {
+		hash, err := itr.nextHash(txn)
+		if err != nil {
+			return err
+		}
+		if hash == nil {
+			itr.end = true
+			return nil
+		}
+
+		block, err := getBlockTxn(txn, hash)
+		if err == ErrKeyNotFound {
+			return fmt.Errorf("Block %X of %s is missing", hash, itr.address)
+		}
+		if err != nil {
+			return err
+		}
+		if itr.last == nil {
+			err = itr.checkStart(txn, block)
+			if err != nil {
+				return err
+			}
+		} else if itr.direction == Forward && !bytes.Equal(block.PrevHash, itr.last.Hash) {
+			return fmt.Errorf("Chain of %s was reorganized at height %d during the walk", itr.address, block.Height)
+		}
+		itr.buffer = append(itr.buffer, block)
+		itr.last = block
+	}
+	return nil
+}
+
+// nextHash returns the hash of the block after the last one read, nil at the
+// end of the chain
+func (itr *Iterator) nextHash(txn StoreTxn) ([]byte, error) {
+	if itr.last != nil {
+		if itr.direction == Backward {
+			if itr.last.IsGenesis() {
+				return nil, nil
+			}
+			return itr.last.PrevHash, nil
+		}
+		return optionalGet(txn, heightIndexKey(itr.address, itr.last.Height+1))
+	}
+
+	switch {
+	case itr.startHash != nil:
+		return itr.startHash, nil
+	case itr.startHeight >= 0:
+		hash, err := txn.Get(heightIndexKey(itr.address, itr.startHeight))
+		if err == ErrKeyNotFound {
+			return nil, blockNotFound("No block at height %d of %s", itr.startHeight, itr.address)
+		}
+		return hash, err
+	case itr.direction == Backward:
+		return optionalGet(txn, tipKey(itr.address))
+	default:
+		return optionalGet(txn, heightIndexKey(itr.address, 0))
+	}
+}
+
+// checkStart checks that the first block of the walk belongs to the chain
+func (itr *Iterator) checkStart(txn StoreTxn, block *Block) error {
+	address, err := Address(block.Token)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(address, itr.address) {
+		return fmt.Errorf("Block %X is not on the chain of %s", block.Hash, itr.address)
+	}
+	if itr.direction == Forward {
+		canonical, err := canonicalTxn(txn, itr.address, block)
+		if err != nil {
+			return err
+		}
+		if !canonical {
+			return fmt.Errorf("Block %X is on a side branch of %s", block.Hash, itr.address)
+		}
+	}
+	return nil
+}
+
+// optionalGet returns the value of key, nil when it isn't set
+func optionalGet(txn StoreTxn, key []byte) ([]byte, error) {
+	value, err := txn.Get(key)
+	if err == ErrKeyNotFound {
+		return nil, nil
+	}
+	return value, err
+}
@@ -0,0 +1,532 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/TariqueNasrullah/iotchain/analysis"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// Mining job states
+const (
+	JobQueued     = "queued"
+	JobMining     = "mining"
+	JobMined      = "mined"
+	JobPropagated = "propagated"
+	JobFailed     = "failed"
+	JobCancelled  = "cancelled"
+)
+
+var (
+	// JobRetention is how long a miner keeps finished jobs for status polling
+	JobRetention = 30 * time.Minute
+	// JobWorkers is the default number of blocks a miner mines at a time
+	JobWorkers = 1
+	// JobQueueSize is the default number of blocks a miner queues before it answers busy
+	JobQueueSize = 64
+	// MaxJobsPerDevice is the number of queued blocks a single device may have
+	MaxJobsPerDevice = 4
+	// PropagateTimeout bounds the time a mined block is sent to a single peer
+	PropagateTimeout = 10 * time.Second
+
+	errStopWatch = errors.New("Stop watching")
+)
+
+// JobStatus is a snapshot of a mining job. Block is set once the block is
+// mined.
+type JobStatus struct {
+	JobID   []byte
+	State   string
+	Block   *Block
+	Error   string
+	Updated int64
+
+	err error
+}
+
+// Done reports whether the job reached a final state
+func (status *JobStatus) Done() bool {
+	return status.State == JobPropagated || status.State == JobFailed || status.State == JobCancelled
+}
+
+// Proto converts the status to its wire message
+func (status *JobStatus) Proto() *JobStatusMessage {
+	msg := JobStatusMessage{
+		JobId:   status.JobID,
+		State:   status.State,
+		Error:   status.Error,
+		Updated: status.Updated,
+	}
+	if status.Block != nil {
+		msg.Block = status.Block.Proto()
+	}
+	return &msg
+}
+
+// JobStatusFromProto converts a wire message to a status
+func JobStatusFromProto(msg *JobStatusMessage) *JobStatus {
+	status := JobStatus{
+		JobID:   msg.GetJobId(),
+		State:   msg.GetState(),
+		Error:   msg.GetError(),
+		Updated: msg.GetUpdated(),
+	}
+	if msg.GetBlock() != nil {
+		status.Block = BlockFromProto(msg.GetBlock())
+	}
+	return &status
+}
+
+// String prints the status
+func (status *JobStatus) String() string {
+	value := fmt.Sprintf("Job %X: %s at %s", status.JobID, status.State, time.Unix(0, status.Updated).UTC().Format(time.RFC3339Nano))
+	if status.Error != "" {
+		value += ", " + status.Error
+	}
+	return value
+}
+
+type miningJob struct {
+	status  JobStatus
+	device  string
+	chain   *BlockChain
+	block   *Block
+	cancel  context.CancelFunc
+	changed chan struct{}
+	waiters int
+}
+
+// MiningJobs runs blocks submitted for sealing in the background and keeps
+// their status, so a client can fire off a block and check back later.
+// Queued jobs wait in a bounded queue and are started round robin across
+// devices, so one busy device can't starve the others.
+type MiningJobs struct {
+	mutex    sync.Mutex
+	jobs     map[string]*miningJob
+	queues   map[string][]*miningJob
+	devices  []string
+	queued   int
+	running  int
+	workers  int
+	capacity int
+	average  time.Duration
+}
+
+// NewMiningJobs returns an empty job list that mines workers blocks at a time
+// and queues up to capacity more
+func NewMiningJobs(workers, capacity int) *MiningJobs {
+	if workers < 1 {
+		workers = 1
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &MiningJobs{
+		jobs:     make(map[string]*miningJob),
+		queues:   make(map[string][]*miningJob),
+		workers:  workers,
+		capacity: capacity,
+	}
+}
+
+// Submit queues block for sealing on chain and returns its job ID, the
+// digest the device signed. Submitting a block that is queued, mining or
+// already mined returns the existing job, so a retried submission is never
+// mined twice. Failed and cancelled jobs are queued again. A full queue, or
+// MaxJobsPerDevice queued jobs of the same device, is answered with
+// ErrorMinerBusy, Load tells when to retry.
+func (jobs *MiningJobs) Submit(chain *BlockChain, block *Block) ([]byte, error) {
+	err := CheckLimits(block)
+	if err != nil {
+		return nil, err
+	}
+	if !block.VerifySignature() {
+		return nil, &ChainError{
+			StatusCode: ErrorInvalidSignature,
+			Err:        errors.New("Signature can't be verified"),
+		}
+	}
+	id := block.SigningDigest()
+	device := string(block.Token)
+
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	jobs.prune()
+
+	job, ok := jobs.jobs[string(id)]
+	if ok && job.status.State != JobFailed && job.status.State != JobCancelled {
+		return id, nil
+	}
+	if jobs.queued >= jobs.capacity || len(jobs.queues[device]) >= MaxJobsPerDevice {
+		return nil, &ChainError{
+			StatusCode: ErrorMinerBusy,
+			Err:        fmt.Errorf("Miner busy with %d queued and %d running jobs, retry after %v", jobs.queued, jobs.running, jobs.retryAfter()),
+		}
+	}
+
+	job = &miningJob{
+		status: JobStatus{
+			JobID:   id,
+			State:   JobQueued,
+			Updated: time.Now().UnixNano(),
+		},
+		device:  device,
+		chain:   chain,
+		block:   block,
+		changed: make(chan struct{}),
+	}
+	jobs.jobs[string(id)] = job
+	if len(jobs.queues[device]) == 0 {
+		jobs.devices = append(jobs.devices, device)
+	}
+	jobs.queues[device] = append(jobs.queues[device], job)
+	jobs.queued++
+
+	logrus.Infof("Queued mining job %X, %d jobs queued", id, jobs.queued)
+	jobs.dispatch()
+	return id, nil
+}
+
+// dispatch starts queued jobs while workers are free, taking the next job of
+// each device in turn. The caller holds the lock.
+func (jobs *MiningJobs) dispatch() {
+	for jobs.running < jobs.workers && len(jobs.devices) > 0 {
+		device := jobs.devices[0]
+		jobs.devices = jobs.devices[1:]
+		queue := jobs.queues[device]
+		job := queue[0]
+		if len(queue) > 1 {
+			jobs.queues[device] = queue[1:]
+			jobs.devices = append(jobs.devices, device)
+		} else {
+			delete(jobs.queues, device)
+		}
+		jobs.queued--
+		jobs.running++
+
+		// mining stops when the job is cancelled or the deadline passes
+		ctx, cancel := context.WithTimeout(context.Background(), MineTimeout)
+		job.cancel = cancel
+		jobs.setState(job, JobMining, nil, nil)
+		go jobs.run(ctx, job)
+	}
+}
+
+// unqueue removes a queued job, the caller holds the lock
+func (jobs *MiningJobs) unqueue(job *miningJob) {
+	queue := jobs.queues[job.device]
+	for i := range queue {
+		if queue[i] != job {
+			continue
+		}
+		queue = append(queue[:i:i], queue[i+1:]...)
+		jobs.queued--
+		break
+	}
+	if len(queue) > 0 {
+		jobs.queues[job.device] = queue
+		return
+	}
+	delete(jobs.queues, job.device)
+	for i := range jobs.devices {
+		if jobs.devices[i] == job.device {
+			jobs.devices = append(jobs.devices[:i:i], jobs.devices[i+1:]...)
+			break
+		}
+	}
+}
+
+// run seals and stores the block of job and starts the next queued job. The
+// block is propagated after the worker is free, so slow peers don't hold it.
+func (jobs *MiningJobs) run(ctx context.Context, job *miningJob) {
+	block := jobs.mine(ctx, job)
+
+	job.cancel()
+	jobs.mutex.Lock()
+	jobs.running--
+	jobs.dispatch()
+	jobs.mutex.Unlock()
+
+	if block != nil {
+		jobs.propagate(job, block)
+	}
+}
+
+// mine seals and stores the block of job, it returns nil when the job
+// failed or was cancelled
+func (jobs *MiningJobs) mine(ctx context.Context, job *miningJob) *Block {
+	startTime := time.Now() // analysis
+	chain, block := job.chain, job.block
+	err := chain.Engine.Seal(ctx, chain, block)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			jobs.update(job, JobCancelled, nil, err)
+		} else {
+			jobs.update(job, JobFailed, nil, err)
+		}
+		return nil
+	}
+	err = chain.AddBlock(block)
+	if err != nil {
+		jobs.update(job, JobFailed, nil, err)
+		return nil
+	}
+	jobs.update(job, JobMined, block, nil)
+
+	endTime := time.Now()                                        // analysis
+	go analysis.SaveBlockGenTime(startTime, endTime, block.Hash) // analysis
+
+	jobs.mutex.Lock()
+	if jobs.average == 0 {
+		jobs.average = endTime.Sub(startTime)
+	} else {
+		jobs.average = (3*jobs.average + endTime.Sub(startTime)) / 4
+	}
+	jobs.mutex.Unlock()
+	return block
+}
+
+// propagate sends the mined block of job to the connected nodes
+func (jobs *MiningJobs) propagate(job *miningJob, block *Block) {
+	var wg sync.WaitGroup
+	network := Network{}
+	minedBlock := block.Proto()
+	for addr := range connectedNodes() {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			network.PropagateBlock(minedBlock, addr)
+		}(addr)
+	}
+	wg.Wait()
+	jobs.update(job, JobPropagated, block, nil)
+}
+
+// update moves job to state and wakes up its watchers
+func (jobs *MiningJobs) update(job *miningJob, state string, block *Block, err error) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	jobs.setState(job, state, block, err)
+}
+
+// setState moves job to state and wakes up its watchers, the caller holds
+// the lock
+func (jobs *MiningJobs) setState(job *miningJob, state string, block *Block, err error) {
+	job.status.State = state
+	job.status.Updated = time.Now().UnixNano()
+	if block != nil {
+		job.status.Block = block
+	}
+	if err != nil {
+		job.status.err = err
+		job.status.Error = err.Error()
+	}
+	close(job.changed)
+	job.changed = make(chan struct{})
+
+	if err != nil {
+		logrus.Warnf("Mining job %X %s: %v", job.status.JobID, state, err)
+	} else {
+		logrus.Infof("Mining job %X %s", job.status.JobID, state)
+	}
+}
+
+// startShare takes a worker for a share of pooled mining, shares count
+// against the workers like jobs. Without a free worker it answers
+// ErrorMinerBusy.
+func (jobs *MiningJobs) startShare() error {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	if jobs.running >= jobs.workers {
+		return &ChainError{
+			StatusCode: ErrorMinerBusy,
+			Err:        fmt.Errorf("Miner busy with %d queued and %d running jobs, retry after %v", jobs.queued, jobs.running, jobs.retryAfter()),
+		}
+	}
+	jobs.running++
+	return nil
+}
+
+// endShare frees the worker of a share and starts the next queued job
+func (jobs *MiningJobs) endShare() {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	jobs.running--
+	jobs.dispatch()
+}
+
+// MinerLoad is a snapshot of the mining queue of a miner
+type MinerLoad struct {
+	Queued     int
+	Running    int
+	Workers    int
+	Capacity   int
+	RetryAfter time.Duration
+}
+
+// Busy reports whether the queue is full
+func (load MinerLoad) Busy() bool {
+	return load.Queued >= load.Capacity
+}
+
+// Depth returns the number of jobs ahead of a new job per worker
+func (load MinerLoad) Depth() float64 {
+	return float64(load.Queued+load.Running) / float64(load.Workers)
+}
+
+// Load returns the state of the mining queue
+func (jobs *MiningJobs) Load() MinerLoad {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	return MinerLoad{
+		Queued:     jobs.queued,
+		Running:    jobs.running,
+		Workers:    jobs.workers,
+		Capacity:   jobs.capacity,
+		RetryAfter: jobs.retryAfter(),
+	}
+}
+
+// retryAfter estimates when the miner has room, the caller holds the lock
+func (jobs *MiningJobs) retryAfter() time.Duration {
+	average := jobs.average
+	if average < time.Second {
+		average = time.Second
+	}
+	return average * time.Duration(jobs.queued/jobs.workers+1)
+}
+
+// prune drops finished jobs older than JobRetention, the caller holds the
+// lock
+func (jobs *MiningJobs) prune() {
+	deadline := time.Now().Add(-JobRetention).UnixNano()
+	for id, job := range jobs.jobs {
+		if job.status.Done() && job.status.Updated < deadline {
+			delete(jobs.jobs, id)
+		}
+	}
+}
+
+// get returns the job with id, a copy of its status and the channel closed
+// on its next change
+func (jobs *MiningJobs) get(id []byte) (*miningJob, JobStatus, chan struct{}, error) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	job, ok := jobs.jobs[string(id)]
+	if !ok {
+		return nil, JobStatus{}, nil, &ChainError{
+			StatusCode: ErrorJobNotFound,
+			Err:        fmt.Errorf("Mining job %s not found", hex.EncodeToString(id)),
+		}
+	}
+	return job, job.status, job.changed, nil
+}
+
+// Status returns the status of the job with id
+func (jobs *MiningJobs) Status(id []byte) (*JobStatus, error) {
+	_, status, _, err := jobs.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Watch calls fn with the status of the job with id now and on every change
+// until the job is done, fn returns an error or ctx is done
+func (jobs *MiningJobs) Watch(ctx context.Context, id []byte, fn func(*JobStatus) error) error {
+	job, _, _, err := jobs.get(id)
+	if err != nil {
+		return err
+	}
+	jobs.mutex.Lock()
+	job.waiters++
+	jobs.mutex.Unlock()
+	defer func() {
+		jobs.mutex.Lock()
+		job.waiters--
+		jobs.mutex.Unlock()
+	}()
+
+	for {
+		jobs.mutex.Lock()
+		status, changed := job.status, job.changed
+		jobs.mutex.Unlock()
+
+		err = fn(&status)
+		if err != nil || status.Done() {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Wait blocks until the job with id is mined or done. It returns the status
+// and the error the job failed with.
+func (jobs *MiningJobs) Wait(ctx context.Context, id []byte) (*JobStatus, error) {
+	var last *JobStatus
+	err := jobs.Watch(ctx, id, func(status *JobStatus) error {
+		last = status
+		if status.State == JobMined {
+			return errStopWatch
+		}
+		return nil
+	})
+	if err != nil && err != errStopWatch {
+		return nil, err
+	}
+
+	return last, last.err
+}
+
+// Cancel stops the job with id, it returns false when the block is already
+// mined or the job is done
+func (jobs *MiningJobs) Cancel(id []byte) (bool, error) {
+	job, _, _, err := jobs.get(id)
+	if err != nil {
+		return false, err
+	}
+
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	return jobs.cancel(job), nil
+}
+
+// Abandon stops the job with id like Cancel when nobody watches it anymore,
+// so a block isn't mined for a client that is gone
+func (jobs *MiningJobs) Abandon(id []byte) (bool, error) {
+	job, _, _, err := jobs.get(id)
+	if err != nil {
+		return false, err
+	}
+
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+	if job.waiters > 0 {
+		return false, nil
+	}
+	return jobs.cancel(job), nil
+}
+
+// cancel stops job unless it is mined or done, the caller holds the lock
+func (jobs *MiningJobs) cancel(job *miningJob) bool {
+	switch job.status.State {
+	case JobQueued:
+		jobs.unqueue(job)
+		jobs.setState(job, JobCancelled, nil, errors.New("Cancelled before mining"))
+		return true
+	case JobMining:
+		job.cancel()
+		return true
+	}
+	return false
+}
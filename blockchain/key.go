@@ -9,8 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
 // Key structure
@@ -21,9 +24,36 @@ type Key struct {
 	Token      []byte
 }
 
+// keyFile is the on-disk form of Key, the private key is kept as its scalar
+type keyFile struct {
+	PrivateKey []byte
+	SecretKey  []byte
+	Token      []byte
+}
+
+// legacyKeyFile reads key files written before keyFile, a gob of Key itself.
+// Only the scalar of the private key is read, the curve is always P256.
+type legacyKeyFile struct {
+	PrivateKey *struct{ D *big.Int }
+	SecretKey  []byte
+	Token      []byte
+}
+
+const (
+	// PublicKeyLength is the length of an encoded public key, X || Y
+	PublicKeyLength = 64
+	// SignatureLength is the length of an encoded signature, r || s
+	SignatureLength = 64
+
+	coordinateLength = 32
+)
+
 var (
 	// KEYPATH is the path of key file
 	KEYPATH = "tmp/key/key.data"
+	// JOBPATH holds the blocks submitted as mining jobs by job ID, next to
+	// the key file
+	JOBPATH = "tmp/key/jobs/"
 )
 
 // GenerateKey generates SecretKey, privatekey, publickey
@@ -41,41 +71,75 @@ func GenerateKey(keyPath string) (*Key, error) {
 		return &key, err
 	}
 	key.PrivateKey = privKey
-	key.PublicKey = append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+	key.PublicKey = EncodePublicKey(&privKey.PublicKey)
 
 	return &key, nil
 }
 
-// LoadKey loads key from file
+// LoadKey loads key from file. Key files in the legacy layout are rewritten
+// in the current one.
 func LoadKey(keyPath string) (*Key, error) {
 	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
 		return &Key{}, errors.New("Key File Does not exist")
 	}
 
-	var key Key
-
 	fileContent, err := ioutil.ReadFile(keyPath)
 	if err != nil {
-		return &key, err
+		return &Key{}, err
 	}
 
-	gob.Register(elliptic.P256())
-	decoder := gob.NewDecoder(bytes.NewReader(fileContent))
-	err = decoder.Decode(&key)
+	var file keyFile
+	legacy := false
+	err = gob.NewDecoder(bytes.NewReader(fileContent)).Decode(&file)
 	if err != nil {
-		return &key, err
+		var old legacyKeyFile
+		if gob.NewDecoder(bytes.NewReader(fileContent)).Decode(&old) != nil || old.PrivateKey == nil || old.PrivateKey.D == nil {
+			return &Key{}, err
+		}
+		file = keyFile{
+			PrivateKey: fixedBytes(old.PrivateKey.D, coordinateLength),
+			SecretKey:  old.SecretKey,
+			Token:      old.Token,
+		}
+		legacy = true
 	}
 
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(file.PrivateKey)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return &Key{}, errors.New("Key file holds an invalid private key")
+	}
+	privKey := &ecdsa.PrivateKey{D: d}
+	privKey.PublicKey.Curve = curve
+	privKey.PublicKey.X, privKey.PublicKey.Y = curve.ScalarBaseMult(file.PrivateKey)
+
+	key := Key{
+		PrivateKey: privKey,
+		PublicKey:  EncodePublicKey(&privKey.PublicKey),
+		SecretKey:  file.SecretKey,
+		Token:      file.Token,
+	}
+	if legacy {
+		err = key.SaveFile(keyPath)
+		if err != nil {
+			return &Key{}, fmt.Errorf("Can't rewrite legacy key file: %v", err)
+		}
+		logrus.Infof("Rewrote key file %v in the current layout", keyPath)
+	}
 	return &key, nil
 }
 
 // SaveFile saves key into file
 func (key *Key) SaveFile(keyPath string) error {
 	var buffer bytes.Buffer
-	gob.Register(elliptic.P256())
 
+	file := keyFile{
+		PrivateKey: fixedBytes(key.PrivateKey.D, coordinateLength),
+		SecretKey:  key.SecretKey,
+		Token:      key.Token,
+	}
 	enc := gob.NewEncoder(&buffer)
-	err := enc.Encode(key)
+	err := enc.Encode(file)
 	if err != nil {
 		return err
 	}
@@ -87,6 +151,67 @@ func (key *Key) SaveFile(keyPath string) error {
 	return nil
 }
 
+// EncodePublicKey encodes publicKey as fixed width X || Y
+func EncodePublicKey(publicKey *ecdsa.PublicKey) []byte {
+	return append(fixedBytes(publicKey.X, coordinateLength), fixedBytes(publicKey.Y, coordinateLength)...)
+}
+
+// DecodePublicKey decodes a fixed width X || Y public key
+func DecodePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	if len(data) != PublicKeyLength {
+		return nil, fmt.Errorf("Public key must be %d bytes, got %d", PublicKeyLength, len(data))
+	}
+	curve := elliptic.P256()
+	x := new(big.Int).SetBytes(data[:coordinateLength])
+	y := new(big.Int).SetBytes(data[coordinateLength:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("Public key is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// SignDigest signs digest and returns a fixed width r || s signature.
+// s is normalised to the lower half of the curve order so every message has
+// exactly one valid encoding.
+func SignDigest(privateKey *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	n := privateKey.Curve.Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+	return append(fixedBytes(r, coordinateLength), fixedBytes(s, coordinateLength)...), nil
+}
+
+// VerifyDigest verifies a fixed width r || s signature of digest made by the
+// holder of publicKey. Malformed keys and signatures never verify.
+func VerifyDigest(publicKey, digest, signature []byte) bool {
+	if len(signature) != SignatureLength {
+		return false
+	}
+	rawPublicKey, err := DecodePublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:coordinateLength])
+	s := new(big.Int).SetBytes(signature[coordinateLength:])
+	if s.Cmp(new(big.Int).Rsh(rawPublicKey.Curve.Params().N, 1)) > 0 {
+		return false
+	}
+	return ecdsa.Verify(rawPublicKey, digest, r, s)
+}
+
+// fixedBytes returns num as a big endian byte slice left padded to size
+func fixedBytes(num *big.Int, size int) []byte {
+	data := num.Bytes()
+	if len(data) >= size {
+		return data
+	}
+	return append(make([]byte, size-len(data)), data...)
+}
+
 func (key *Key) String() string {
 	var lines []string
 
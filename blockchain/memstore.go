@@ -0,0 +1,149 @@
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var errReadOnlyTxn = errors.New("Transaction is read only")
+
+// MemoryStore keeps the chain in memory, for tests and nodes that don't need
+// to survive a restart. Updates run one at a time, so they never conflict.
+type MemoryStore struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+// View runs fn in a read only transaction
+func (store *MemoryStore) View(fn func(txn StoreTxn) error) error {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return fn(&memoryTxn{store: store})
+}
+
+// Update runs fn in a read write transaction, its writes are applied only
+// when fn returns nil
+func (store *MemoryStore) Update(fn func(txn StoreTxn) error) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	txn := &memoryTxn{store: store, writes: make(map[string][]byte)}
+	err := fn(txn)
+	if err != nil {
+		return err
+	}
+	for key, value := range txn.writes {
+		if value == nil {
+			delete(store.data, key)
+		} else {
+			store.data[key] = value
+		}
+	}
+	return nil
+}
+
+// GetBlock returns the block stored under hash
+func (store *MemoryStore) GetBlock(hash []byte) (*Block, error) {
+	return getBlock(store, hash)
+}
+
+// PutBlock stores block under its hash
+func (store *MemoryStore) PutBlock(block *Block) error {
+	return putBlock(store, block)
+}
+
+// Tips returns the tips of all device chains in address order
+func (store *MemoryStore) Tips() ([]CheckpointTip, error) {
+	return storeTips(store)
+}
+
+// Iterate calls fn with every key starting with prefix in key order
+func (store *MemoryStore) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	return iterate(store, prefix, fn)
+}
+
+// Close drops the stored data
+func (store *MemoryStore) Close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.data = make(map[string][]byte)
+	return nil
+}
+
+// memoryTxn reads through the writes of an update to the store, a nil write
+// is a deleted key. writes is nil in read only transactions.
+type memoryTxn struct {
+	store  *MemoryStore
+	writes map[string][]byte
+}
+
+func (txn *memoryTxn) get(key string) ([]byte, bool) {
+	if value, ok := txn.writes[key]; ok {
+		return value, value != nil
+	}
+	value, ok := txn.store.data[key]
+	return value, ok
+}
+
+func (txn *memoryTxn) Get(key []byte) ([]byte, error) {
+	value, ok := txn.get(string(key))
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return append([]byte{}, value...), nil
+}
+
+func (txn *memoryTxn) Set(key, value []byte) error {
+	if txn.writes == nil {
+		return errReadOnlyTxn
+	}
+	txn.writes[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (txn *memoryTxn) Delete(key []byte) error {
+	if txn.writes == nil {
+		return errReadOnlyTxn
+	}
+	txn.writes[string(key)] = nil
+	return nil
+}
+
+func (txn *memoryTxn) Iterate(prefix []byte, reverse bool, fn func(key, value []byte) error) error {
+	var keys []string
+	for key := range txn.store.data {
+		if _, ok := txn.writes[key]; !ok && bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	for key, value := range txn.writes {
+		if value != nil && bytes.HasPrefix([]byte(key), prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if reverse {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	for _, key := range keys {
+		value, _ := txn.get(key)
+		err := fn([]byte(key), append([]byte{}, value...))
+		if err == errStopIteration {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
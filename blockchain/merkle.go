@@ -1,10 +1,15 @@
 package blockchain
 
-import "crypto/sha256"
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
 
 // MerkleTree strcut
 type MerkleTree struct {
 	RootNode *MerkleNode
+	size     int
 }
 
 // MerkleNode struct
@@ -14,15 +19,23 @@ type MerkleNode struct {
 	Data  []byte
 }
 
+// Leaves and inner nodes are hashed under different prefixes, so an inner
+// node can't be passed off as a leaf
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
 // NewMerkleNode creates new merkle node
 func NewMerkleNode(left, right *MerkleNode, data []byte) *MerkleNode {
 	node := MerkleNode{}
 
 	if left == nil && right == nil {
-		hash := sha256.Sum256(data)
+		hash := sha256.Sum256(append([]byte{merkleLeafPrefix}, data...))
 		node.Data = hash[:]
 	} else {
-		prevHashes := append(left.Data, right.Data...)
+		prevHashes := append([]byte{merkleNodePrefix}, left.Data...)
+		prevHashes = append(prevHashes, right.Data...)
 		hash := sha256.Sum256(prevHashes)
 		node.Data = hash[:]
 	}
@@ -36,6 +49,10 @@ func NewMerkleNode(left, right *MerkleNode, data []byte) *MerkleNode {
 func NewMerkleTree(data [][]byte) *MerkleTree {
 	var nodes []MerkleNode
 
+	if len(data) == 0 {
+		return &MerkleTree{RootNode: NewMerkleNode(nil, nil, []byte{}), size: 0}
+	}
+
 	for _, dat := range data {
 		node := NewMerkleNode(nil, nil, dat)
 		nodes = append(nodes, *node)
@@ -53,6 +70,72 @@ func NewMerkleTree(data [][]byte) *MerkleTree {
 		}
 		nodes = level
 	}
-	tree := MerkleTree{RootNode: &nodes[0]}
+	tree := MerkleTree{RootNode: &nodes[0], size: len(data)}
 	return &tree
 }
+
+// MerkleProofStep is one step of a merkle audit path, the sibling hash and
+// whether the sibling is the left child
+type MerkleProofStep struct {
+	Hash []byte
+	Left bool
+}
+
+// MerkleProof is the audit path from a leaf up to the root
+type MerkleProof []MerkleProofStep
+
+// GenerateProof returns the audit path of the leaf at index
+func (tree *MerkleTree) GenerateProof(index int) (MerkleProof, error) {
+	if index < 0 || index >= tree.size {
+		return nil, fmt.Errorf("Leaf index %d out of range [0, %d)", index, tree.size)
+	}
+
+	depth := merkleDepth(tree.size)
+
+	// every level is padded to an even width, so the tree is perfect and
+	// the bits of index spell the path from the root down to the leaf
+	proof := make(MerkleProof, depth)
+	node := tree.RootNode
+	for level := depth - 1; level >= 0; level-- {
+		if (index>>uint(level))&1 == 0 {
+			proof[level] = MerkleProofStep{Hash: node.Right.Data, Left: false}
+			node = node.Left
+		} else {
+			proof[level] = MerkleProofStep{Hash: node.Left.Data, Left: true}
+			node = node.Right
+		}
+	}
+	return proof, nil
+}
+
+// merkleDepth returns the number of levels above the leaves of a tree with
+// size leaves
+func merkleDepth(size int) int {
+	depth := 0
+	for width := size; width > 1; width = (width + 1) / 2 {
+		depth++
+	}
+	return depth
+}
+
+// VerifyProof verifies that leaf is the leaf at index of the tree of size
+// leaves with root. The proof must be as long as the tree is deep and its
+// steps must follow the bits of index.
+func VerifyProof(root, leaf []byte, index, size int, proof MerkleProof) bool {
+	if index < 0 || index >= size || len(proof) != merkleDepth(size) {
+		return false
+	}
+	node := NewMerkleNode(nil, nil, leaf)
+	for level, step := range proof {
+		if step.Left != ((index>>uint(level))&1 == 1) {
+			return false
+		}
+		sibling := &MerkleNode{Data: step.Hash}
+		if step.Left {
+			node = NewMerkleNode(sibling, node, nil)
+		} else {
+			node = NewMerkleNode(node, sibling, nil)
+		}
+	}
+	return bytes.Equal(node.Data, root)
+}
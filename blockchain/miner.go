@@ -1,14 +1,12 @@
 package blockchain
 
 import (
-	"crypto/ecdsa"
-	"crypto/rand"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
-	"github.com/TariqueNasrullah/iotchain/analysis"
-	"github.com/dgraph-io/badger"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
@@ -18,12 +16,20 @@ import (
 // Server structure
 type Server struct {
 	UnimplementedMinerServer
+	Chain *BlockChain
+	Jobs  *MiningJobs
+}
+
+// NewServer returns a server for chain that mines blocks with jobs
+func NewServer(chain *BlockChain, jobs *MiningJobs) *Server {
+	return &Server{Chain: chain, Jobs: jobs}
 }
 
 // Test tests
 func (srv *Server) Test(ctx context.Context, in *TestRequest) (*TestResponse, error) {
 	trans := Transaction{Data: []byte("data")}
 	block := Block{
+		Difficulty:   Params.Difficulty,
 		PrevHash:     []byte("hash"),
 		Transactions: []*Transaction{&trans},
 		Token:        []byte("token"),
@@ -41,16 +47,16 @@ func (srv *Server) Test(ctx context.Context, in *TestRequest) (*TestResponse, er
 	} else {
 		logrus.Info("sending block valid")
 	}
-	serializedBlock, err := block.Serialize()
-	if err != nil {
-		logrus.Fatal(err)
-	}
 	fmt.Printf("%s\n", block)
-	return &TestResponse{Block: serializedBlock}, nil
+	return &TestResponse{Block: block.Proto()}, nil
 }
 
 // SendAddress implementation
 func (srv *Server) SendAddress(ctx context.Context, in *SendAddressRequest) (*SendAddressResponse, error) {
+	if !bytes.Equal(in.ParamsHash, Params.Hash()) {
+		logrus.Warnf("Rejected %v: chain parameters mismatch\n", in.Addr)
+		return &SendAddressResponse{ResponseText: "Chain parameters mismatch", StatusCode: ErrorParamsMismatch}, nil
+	}
 
 	network := Network{}
 	conn, err := network.Connect(in.Addr)
@@ -58,14 +64,23 @@ func (srv *Server) SendAddress(ctx context.Context, in *SendAddressRequest) (*Se
 		return &SendAddressResponse{ResponseText: "Cant't Connect with " + in.Addr, StatusCode: 401}, nil
 	}
 
-	ConnectedNodes[conn.Target()] = conn
+	addConnectedNode(conn)
 	logrus.Infof("Connected to %v\n", conn.Target())
 	return &SendAddressResponse{ResponseText: "OK", StatusCode: 200}, nil
 }
 
+// GetChainParams returns the chain parameters of this node
+func (srv *Server) GetChainParams(ctx context.Context, in *GetChainParamsRequest) (*GetChainParamsResponse, error) {
+	data, err := json.Marshal(Params)
+	if err != nil {
+		return nil, err
+	}
+	return &GetChainParamsResponse{Params: data}, nil
+}
+
 // GetAddress returns a stream of address that this node is connected to
 func (srv *Server) GetAddress(in *GetAddressRequest, stream Miner_GetAddressServer) error {
-	for addr := range ConnectedNodes {
+	for addr := range connectedNodes() {
 		if err := stream.Send(&GetAddressResponse{Address: addr}); err != nil {
 			return err
 		}
@@ -75,35 +90,15 @@ func (srv *Server) GetAddress(in *GetAddressRequest, stream Miner_GetAddressServ
 
 // FullHeight returns blockchain fullheight
 func (srv *Server) FullHeight(context.Context, *FullHeightRequest) (*FullHeightResponse, error) {
-	height := Chain.FullHeight()
+	height := srv.Chain.FullHeight()
 	return &FullHeightResponse{Height: height}, nil
 }
 
-// GetFullChain streams back the full blockchain
+// GetFullChain streams back the blocks, evidence, checkpoints and votes of
+// the full blockchain
 func (srv *Server) GetFullChain(in *GetFullChainRequest, stream Miner_GetFullChainServer) error {
-	err := Chain.Database.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		it := txn.NewIterator(opts)
-		defer it.Close()
-
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := item.Key()
-			var value []byte
-
-			err := item.Value(func(val []byte) error {
-				value = val
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-
-			if err := stream.Send(&GetFullChainResponse{Key: key, Value: value}); err != nil {
-				return err
-			}
-		}
-		return nil
+	err := exportChain(srv.Chain.Store, func(key, value []byte) error {
+		return stream.Send(&GetFullChainResponse{Key: key, Value: value})
 	})
 	if err != nil {
 		return err
@@ -111,43 +106,48 @@ func (srv *Server) GetFullChain(in *GetFullChainRequest, stream Miner_GetFullCha
 	return nil
 }
 
-// GetChain returns stream of block
+// GetChain streams the chain of a token from genesis to the tip
 func (srv *Server) GetChain(in *GetChainRequest, stream Miner_GetChainServer) error {
-	blockList, err := Chain.Chain(in.Token)
+	itr, err := srv.Chain.NewIterator(in.Token, Forward)
 	if err != nil {
 		return err
 	}
-	for i := len(blockList) - 1; i >= 0; i-- {
-		serializedBlock, err := blockList[i].Serialize()
+	for itr.Next() {
+		err := stream.Send(&GetChainResponse{Block: itr.Block().Proto()})
 		if err != nil {
 			return err
 		}
-		stream.Send(&GetChainResponse{Block: serializedBlock})
 	}
-	return nil
+	return itr.Err()
 }
 
 // PropagateBlock propagates a block accross the network
 func (srv *Server) PropagateBlock(ctx context.Context, in *PropagateBlockRequest) (*PropagateBlockResponse, error) {
-	block, err := Deserialize(in.Block)
+	if in.Block == nil {
+		return nil, errors.New("Block is missing")
+	}
+	block := BlockFromProto(in.Block)
+
+	// blocks from the future are turned away before they are checked any
+	// further, AddBlock enforces the median time rule
+	err := checkFutureTimestamp(block)
 	if err != nil {
 		return nil, err
 	}
-
 	if block.IsGenesis() {
-		err := Chain.AddGenesis(block)
+		err := srv.Chain.AddGenesis(block)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		err = Chain.AddBlock(block)
+		err := srv.Chain.AddBlock(block)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	network := Network{}
-	for addr := range ConnectedNodes {
+	for addr := range connectedNodes() {
 		go network.PropagateBlock(in.Block, addr)
 	}
 
@@ -164,29 +164,24 @@ func (srv *Server) Token(ctx context.Context, in *TokenRequest) (*TokenResponse,
 	if err != nil {
 		return nil, err
 	}
-	r, s, err := ecdsa.Sign(rand.Reader, key.PrivateKey, hash[:])
+	signature, err := SignDigest(key.PrivateKey, hash[:])
 	if err != nil {
 		return nil, err
 	}
-	signature := append(r.Bytes(), s.Bytes()...)
 
-	block, err := NewGenesisBlock(signature, key.PrivateKey)
-	if err != nil {
-		return nil, err
-	}
-	serializedBlock, err := block.Serialize()
+	block, err := NewGenesisBlock(ctx, srv.Chain, signature, key.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	err = Chain.AddGenesis(block)
+	err = srv.Chain.AddGenesis(block)
 	if err != nil {
 		return nil, err
 	}
 
 	network := Network{}
-	for addr := range ConnectedNodes {
-		go network.PropagateBlock(serializedBlock, addr)
+	for addr := range connectedNodes() {
+		go network.PropagateBlock(block.Proto(), addr)
 	}
 
 	return &TokenResponse{Token: signature}, nil
@@ -199,52 +194,331 @@ func (srv *Server) Ping(ctx context.Context, in *PingRequest) (*PingResponse, er
 
 // Height returns height of chain of token
 func (srv *Server) Height(ctx context.Context, in *HeightRequest) (*HeightResponse, error) {
-	height, err := Chain.Height(in.Token)
+	height, err := srv.Chain.Height(in.Token)
 	if err != nil {
 		return nil, err
 	}
 	return &HeightResponse{Height: height}, nil
 }
 
-// Mine mines
+// Mine seals a block and returns it once it is added to the chain. The
+// block runs as a mining job, a retried request waits for the same job
+// instead of mining the block again. The job is cancelled when the client
+// goes away and no other request waits for it.
 func (srv *Server) Mine(ctx context.Context, in *MineRequest) (*MineResponse, error) {
-	startTime := time.Now() // analysis
+	if in.Block == nil {
+		return nil, errors.New("Block is missing")
+	}
+	id, err := srv.Jobs.Submit(srv.Chain, BlockFromProto(in.Block))
+	if err != nil {
+		return nil, err
+	}
+	status, err := srv.Jobs.Wait(ctx, id)
+	if err != nil {
+		if ctx.Err() != nil {
+			srv.Jobs.Abandon(id)
+		}
+		return nil, err
+	}
+	return &MineResponse{Block: status.Block.Proto()}, nil
+}
 
-	block, err := Deserialize(in.Block)
+// SubmitMiningJob queues a block for sealing in the background and returns
+// the job ID to poll. A busy miner answers with a retry after hint in
+// milliseconds instead.
+func (srv *Server) SubmitMiningJob(ctx context.Context, in *SubmitMiningJobRequest) (*SubmitMiningJobResponse, error) {
+	if in.Block == nil {
+		return nil, errors.New("Block is missing")
+	}
+	id, err := srv.Jobs.Submit(srv.Chain, BlockFromProto(in.Block))
+	if cErr, ok := err.(*ChainError); ok && cErr.StatusCode == ErrorMinerBusy {
+		load := srv.Jobs.Load()
+		return &SubmitMiningJobResponse{
+			Busy:       true,
+			RetryAfter: int64(load.RetryAfter / time.Millisecond),
+			Queued:     int64(load.Queued),
+		}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	pow := NewProof(block)
+	return &SubmitMiningJobResponse{JobId: id, Queued: int64(srv.Jobs.Load().Queued)}, nil
+}
 
-	nonce, hash := pow.Run()
-	block.Nonce = nonce
-	block.Hash = hash
+// GetMinerLoad returns the depth of the mining queue, clients pick the least
+// loaded miner with it
+func (srv *Server) GetMinerLoad(ctx context.Context, in *GetMinerLoadRequest) (*GetMinerLoadResponse, error) {
+	load := srv.Jobs.Load()
+	return &GetMinerLoadResponse{
+		Queued:     int64(load.Queued),
+		Running:    int64(load.Running),
+		Workers:    int64(load.Workers),
+		Capacity:   int64(load.Capacity),
+		RetryAfter: int64(load.RetryAfter / time.Millisecond),
+	}, nil
+}
 
-	serializedBlock, err := block.Serialize()
+// GetJobStatus returns the status of a mining job
+func (srv *Server) GetJobStatus(ctx context.Context, in *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	status, err := srv.Jobs.Status(in.JobId)
 	if err != nil {
 		return nil, err
 	}
+	return &GetJobStatusResponse{Status: status.Proto()}, nil
+}
 
-	err = Chain.AddBlock(block)
+// WatchJob streams the status of a mining job on every change until it is
+// done
+func (srv *Server) WatchJob(in *WatchJobRequest, stream Miner_WatchJobServer) error {
+	return srv.Jobs.Watch(stream.Context(), in.JobId, func(status *JobStatus) error {
+		return stream.Send(&WatchJobResponse{Status: status.Proto()})
+	})
+}
+
+// CancelJob stops a mining job that isn't mined yet
+func (srv *Server) CancelJob(ctx context.Context, in *CancelJobRequest) (*CancelJobResponse, error) {
+	cancelled, err := srv.Jobs.Cancel(in.JobId)
 	if err != nil {
 		return nil, err
 	}
+	return &CancelJobResponse{Ok: cancelled}, nil
+}
 
-	network := Network{}
-	for addr := range ConnectedNodes {
-		go network.PropagateBlock(serializedBlock, addr)
+// MineShare searches a share of the nonce space of a block for a miner
+// mining it pooled. The search stops when the pooled miner cancels the call.
+// Only connected and known nodes are served, a share can't be larger than
+// ShareSize and takes a mining worker, see MiningJobs.startShare.
+func (srv *Server) MineShare(ctx context.Context, in *MineShareRequest) (*MineShareResponse, error) {
+	if in.Block == nil {
+		return nil, errors.New("Block is missing")
+	}
+	if Params.Consensus != ConsensusProofOfWork {
+		return nil, errors.New("Pooled mining needs proof of work")
+	}
+	if in.Start < 0 || in.Count <= 0 || in.Count > ShareSize {
+		return nil, fmt.Errorf("Share of %d nonces from %d is out of range, a share holds up to %d", in.Count, in.Start, ShareSize)
+	}
+	if !knownPeer(ctx) {
+		return nil, errors.New("Shares are only mined for connected nodes")
+	}
+	block := BlockFromProto(in.Block)
+	err := CheckLimits(block)
+	if err != nil {
+		return nil, err
+	}
+	if !block.VerifySignature() {
+		return nil, &ChainError{
+			StatusCode: ErrorInvalidSignature,
+			Err:        errors.New("Signature can't be verified"),
+		}
+	}
+	err = ProofOfWorkEngine{}.checkDifficulty(nil, block)
+	if err != nil {
+		return nil, err
+	}
+
+	err = srv.Jobs.startShare()
+	if err != nil {
+		return nil, err
+	}
+	defer srv.Jobs.endShare()
+
+	ctx, cancel := context.WithTimeout(ctx, MineTimeout)
+	defer cancel()
+
+	nonce, hash, err := NewProof(block).MineRange(ctx, MiningWorkers, in.Start, in.Count)
+	if err == errNonceRangeExhausted {
+		return &MineShareResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MineShareResponse{Found: true, Nonce: int64(nonce), Hash: hash}, nil
+}
+
+// GetTransactionProof returns the header of a block and the merkle audit
+// path of one of its transactions
+func (srv *Server) GetTransactionProof(ctx context.Context, in *GetTransactionProofRequest) (*GetTransactionProofResponse, error) {
+	block, err := srv.Chain.GetBlock(in.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	index, proof, err := block.TransactionProof(in.TransactionId)
+	if err != nil {
+		return nil, err
 	}
 
-	endTime := time.Now()                                        // analysis
-	go analysis.SaveBlockGenTime(startTime, endTime, block.Hash) // analysis
+	var path []*ProofStep
+	for _, step := range proof {
+		path = append(path, &ProofStep{Hash: step.Hash, Left: step.Left})
+	}
+	return &GetTransactionProofResponse{
+		Header:      block.Header().Proto(),
+		Transaction: block.Transactions[index].Proto(),
+		Index:       int64(index),
+		Path:        path,
+		Count:       int64(len(block.Transactions)),
+	}, nil
+}
+
+// GetBlockByTransaction returns the block holding a transaction
+func (srv *Server) GetBlockByTransaction(ctx context.Context, in *GetBlockByTransactionRequest) (*GetBlockByTransactionResponse, error) {
+	block, err := srv.Chain.TransactionBlock(in.TransactionId)
+	if err != nil {
+		return nil, err
+	}
+	return &GetBlockByTransactionResponse{Block: block.Proto()}, nil
+}
+
+// GetBlockByHeight returns the block at a height of a device chain
+func (srv *Server) GetBlockByHeight(ctx context.Context, in *GetBlockByHeightRequest) (*GetBlockByHeightResponse, error) {
+	block, err := srv.Chain.BlockAtHeight(in.Token, in.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &GetBlockByHeightResponse{Block: block.Proto()}, nil
+}
 
-	return &MineResponse{Block: serializedBlock}, nil
+// GetBlocksByTime streams back the blocks of a device chain with a timestamp
+// in a time range
+func (srv *Server) GetBlocksByTime(in *GetBlocksByTimeRequest, stream Miner_GetBlocksByTimeServer) error {
+	return srv.Chain.BlocksBetween(in.Token, in.From, in.To, func(block *Block) error {
+		return stream.Send(&GetBlocksByTimeResponse{Block: block.Proto()})
+	})
+}
+
+// PropagateEvidence records equivocation evidence and gossips it on when it
+// wasn't known yet
+func (srv *Server) PropagateEvidence(ctx context.Context, in *PropagateEvidenceRequest) (*PropagateEvidenceResponse, error) {
+	if in.Evidence == nil {
+		return nil, errors.New("Evidence is missing")
+	}
+	added, err := srv.Chain.AddEvidence(EvidenceFromProto(in.Evidence))
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		network := Network{}
+		for addr := range connectedNodes() {
+			go network.PropagateEvidence(in.Evidence, addr)
+		}
+	}
+	return &PropagateEvidenceResponse{Ok: true}, nil
+}
+
+// GetEvidence returns the equivocation evidence recorded against the chain
+// of a token, or all of it when no token is given
+func (srv *Server) GetEvidence(ctx context.Context, in *GetEvidenceRequest) (*GetEvidenceResponse, error) {
+	evidenceList, err := srv.Chain.Evidence(in.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*EvidenceMessage
+	for _, evidence := range evidenceList {
+		messages = append(messages, evidence.Proto())
+	}
+	return &GetEvidenceResponse{Evidence: messages}, nil
+}
+
+// PropagateCheckpoint records a checkpoint and gossips it on when it wasn't
+// known yet
+func (srv *Server) PropagateCheckpoint(ctx context.Context, in *PropagateCheckpointRequest) (*PropagateCheckpointResponse, error) {
+	if in.Checkpoint == nil {
+		return nil, errors.New("Checkpoint is missing")
+	}
+	added, err := srv.Chain.AddCheckpoint(CheckpointFromProto(in.Checkpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		network := Network{}
+		for addr := range connectedNodes() {
+			go network.PropagateCheckpoint(in.Checkpoint, addr)
+		}
+	}
+	return &PropagateCheckpointResponse{Ok: true}, nil
+}
+
+// GetCheckpointProof returns the header of a checkpoint, the last one when
+// no height is given, with the tip of the device chain of a token and its
+// merkle audit path
+func (srv *Server) GetCheckpointProof(ctx context.Context, in *GetCheckpointProofRequest) (*GetCheckpointProofResponse, error) {
+	checkpoint, err := srv.Chain.Checkpoint(in.Height)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("Checkpoint %d not found", in.Height)
+	}
+	address, err := Address(in.Token)
+	if err != nil {
+		return nil, err
+	}
+	index, tip, proof, err := checkpoint.TipProof(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var path []*ProofStep
+	for _, step := range proof {
+		path = append(path, &ProofStep{Hash: step.Hash, Left: step.Left})
+	}
+	return &GetCheckpointProofResponse{
+		Checkpoint: checkpoint.Header().Proto(),
+		Tip:        tip.Proto(),
+		Index:      int64(index),
+		Path:       path,
+	}, nil
+}
+
+// PropagateVote records a checkpoint vote and gossips it on when it wasn't
+// known yet
+func (srv *Server) PropagateVote(ctx context.Context, in *PropagateVoteRequest) (*PropagateVoteResponse, error) {
+	if in.Vote == nil {
+		return nil, errors.New("Vote is missing")
+	}
+	added, err := srv.Chain.AddVote(CheckpointVoteFromProto(in.Vote))
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		network := Network{}
+		for addr := range connectedNodes() {
+			go network.PropagateVote(in.Vote, addr)
+		}
+	}
+	return &PropagateVoteResponse{Ok: true}, nil
+}
+
+// GetFinality returns the header of the last final checkpoint with the votes
+// that made it final, an empty response when no checkpoint is final
+func (srv *Server) GetFinality(ctx context.Context, in *GetFinalityRequest) (*GetFinalityResponse, error) {
+	checkpoint, votes, err := srv.Chain.FinalCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return &GetFinalityResponse{}, nil
+	}
+
+	var messages []*CheckpointVoteMessage
+	for _, vote := range votes {
+		messages = append(messages, vote.Proto())
+	}
+	return &GetFinalityResponse{
+		Checkpoint: checkpoint.Header().Proto(),
+		Votes:      messages,
+	}, nil
 }
 
 // PrintConnectedNodes prints connected nodes
 func PrintConnectedNodes() {
 	fmt.Println("  --Connected Nodes")
-	for key := range ConnectedNodes {
+	for key := range connectedNodes() {
 		fmt.Println("   ", key)
 	}
 }
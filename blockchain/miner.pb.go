@@ -24,8 +24,231 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type TransactionMessage struct {
+	Id                   []byte            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type                 string            `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Timestamp            int64             `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ContentType          string            `protobuf:"bytes,4,opt,name=contentType,proto3" json:"contentType,omitempty"`
+	Metadata             map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Data                 []byte            `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *TransactionMessage) Reset()         { *m = TransactionMessage{} }
+func (m *TransactionMessage) String() string { return proto.CompactTextString(m) }
+func (*TransactionMessage) ProtoMessage()    {}
+func (*TransactionMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{0}
+}
+
+func (m *TransactionMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransactionMessage.Unmarshal(m, b)
+}
+func (m *TransactionMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransactionMessage.Marshal(b, m, deterministic)
+}
+func (m *TransactionMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransactionMessage.Merge(m, src)
+}
+func (m *TransactionMessage) XXX_Size() int {
+	return xxx_messageInfo_TransactionMessage.Size(m)
+}
+func (m *TransactionMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransactionMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransactionMessage proto.InternalMessageInfo
+
+func (m *TransactionMessage) GetId() []byte {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+func (m *TransactionMessage) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *TransactionMessage) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *TransactionMessage) GetContentType() string {
+	if m != nil {
+		return m.ContentType
+	}
+	return ""
+}
+
+func (m *TransactionMessage) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *TransactionMessage) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type BlockMessage struct {
+	Version              int64                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	PrevHash             []byte                `protobuf:"bytes,2,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
+	Hash                 []byte                `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	MerkleRoot           []byte                `protobuf:"bytes,4,opt,name=merkleRoot,proto3" json:"merkleRoot,omitempty"`
+	Timestamp            int64                 `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Height               int64                 `protobuf:"varint,6,opt,name=height,proto3" json:"height,omitempty"`
+	Nonce                int64                 `protobuf:"varint,7,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Signature            []byte                `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
+	Token                []byte                `protobuf:"bytes,9,opt,name=token,proto3" json:"token,omitempty"`
+	PublicKey            []byte                `protobuf:"bytes,10,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	Transactions         []*TransactionMessage `protobuf:"bytes,11,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Difficulty           int64                 `protobuf:"varint,12,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Sealer               []byte                `protobuf:"bytes,13,opt,name=sealer,proto3" json:"sealer,omitempty"`
+	Seal                 []byte                `protobuf:"bytes,14,opt,name=seal,proto3" json:"seal,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *BlockMessage) Reset()         { *m = BlockMessage{} }
+func (m *BlockMessage) String() string { return proto.CompactTextString(m) }
+func (*BlockMessage) ProtoMessage()    {}
+func (*BlockMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{1}
+}
+
+func (m *BlockMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockMessage.Unmarshal(m, b)
+}
+func (m *BlockMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockMessage.Marshal(b, m, deterministic)
+}
+func (m *BlockMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockMessage.Merge(m, src)
+}
+func (m *BlockMessage) XXX_Size() int {
+	return xxx_messageInfo_BlockMessage.Size(m)
+}
+func (m *BlockMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockMessage proto.InternalMessageInfo
+
+func (m *BlockMessage) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *BlockMessage) GetPrevHash() []byte {
+	if m != nil {
+		return m.PrevHash
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetMerkleRoot() []byte {
+	if m != nil {
+		return m.MerkleRoot
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *BlockMessage) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *BlockMessage) GetNonce() int64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *BlockMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetTransactions() []*TransactionMessage {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetDifficulty() int64 {
+	if m != nil {
+		return m.Difficulty
+	}
+	return 0
+}
+
+func (m *BlockMessage) GetSealer() []byte {
+	if m != nil {
+		return m.Sealer
+	}
+	return nil
+}
+
+func (m *BlockMessage) GetSeal() []byte {
+	if m != nil {
+		return m.Seal
+	}
+	return nil
+}
+
 type SendAddressRequest struct {
 	Addr                 string   `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	ParamsHash           []byte   `protobuf:"bytes,2,opt,name=paramsHash,proto3" json:"paramsHash,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -35,7 +258,7 @@ func (m *SendAddressRequest) Reset()         { *m = SendAddressRequest{} }
 func (m *SendAddressRequest) String() string { return proto.CompactTextString(m) }
 func (*SendAddressRequest) ProtoMessage()    {}
 func (*SendAddressRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{0}
+	return fileDescriptor_6e7fcaacee94c057, []int{2}
 }
 
 func (m *SendAddressRequest) XXX_Unmarshal(b []byte) error {
@@ -63,6 +286,13 @@ func (m *SendAddressRequest) GetAddr() string {
 	return ""
 }
 
+func (m *SendAddressRequest) GetParamsHash() []byte {
+	if m != nil {
+		return m.ParamsHash
+	}
+	return nil
+}
+
 type SendAddressResponse struct {
 	ResponseText         string   `protobuf:"bytes,1,opt,name=responseText,proto3" json:"responseText,omitempty"`
 	StatusCode           uint64   `protobuf:"varint,2,opt,name=statusCode,proto3" json:"statusCode,omitempty"`
@@ -75,7 +305,7 @@ func (m *SendAddressResponse) Reset()         { *m = SendAddressResponse{} }
 func (m *SendAddressResponse) String() string { return proto.CompactTextString(m) }
 func (*SendAddressResponse) ProtoMessage()    {}
 func (*SendAddressResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{1}
+	return fileDescriptor_6e7fcaacee94c057, []int{3}
 }
 
 func (m *SendAddressResponse) XXX_Unmarshal(b []byte) error {
@@ -120,7 +350,7 @@ func (m *GetAddressRequest) Reset()         { *m = GetAddressRequest{} }
 func (m *GetAddressRequest) String() string { return proto.CompactTextString(m) }
 func (*GetAddressRequest) ProtoMessage()    {}
 func (*GetAddressRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{2}
+	return fileDescriptor_6e7fcaacee94c057, []int{4}
 }
 
 func (m *GetAddressRequest) XXX_Unmarshal(b []byte) error {
@@ -152,7 +382,7 @@ func (m *GetAddressResponse) Reset()         { *m = GetAddressResponse{} }
 func (m *GetAddressResponse) String() string { return proto.CompactTextString(m) }
 func (*GetAddressResponse) ProtoMessage()    {}
 func (*GetAddressResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{3}
+	return fileDescriptor_6e7fcaacee94c057, []int{5}
 }
 
 func (m *GetAddressResponse) XXX_Unmarshal(b []byte) error {
@@ -191,7 +421,7 @@ func (m *FullHeightRequest) Reset()         { *m = FullHeightRequest{} }
 func (m *FullHeightRequest) String() string { return proto.CompactTextString(m) }
 func (*FullHeightRequest) ProtoMessage()    {}
 func (*FullHeightRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{4}
+	return fileDescriptor_6e7fcaacee94c057, []int{6}
 }
 
 func (m *FullHeightRequest) XXX_Unmarshal(b []byte) error {
@@ -230,7 +460,7 @@ func (m *FullHeightResponse) Reset()         { *m = FullHeightResponse{} }
 func (m *FullHeightResponse) String() string { return proto.CompactTextString(m) }
 func (*FullHeightResponse) ProtoMessage()    {}
 func (*FullHeightResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{5}
+	return fileDescriptor_6e7fcaacee94c057, []int{7}
 }
 
 func (m *FullHeightResponse) XXX_Unmarshal(b []byte) error {
@@ -268,7 +498,7 @@ func (m *GetFullChainRequest) Reset()         { *m = GetFullChainRequest{} }
 func (m *GetFullChainRequest) String() string { return proto.CompactTextString(m) }
 func (*GetFullChainRequest) ProtoMessage()    {}
 func (*GetFullChainRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{6}
+	return fileDescriptor_6e7fcaacee94c057, []int{8}
 }
 
 func (m *GetFullChainRequest) XXX_Unmarshal(b []byte) error {
@@ -301,7 +531,7 @@ func (m *GetFullChainResponse) Reset()         { *m = GetFullChainResponse{} }
 func (m *GetFullChainResponse) String() string { return proto.CompactTextString(m) }
 func (*GetFullChainResponse) ProtoMessage()    {}
 func (*GetFullChainResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{7}
+	return fileDescriptor_6e7fcaacee94c057, []int{9}
 }
 
 func (m *GetFullChainResponse) XXX_Unmarshal(b []byte) error {
@@ -337,17 +567,17 @@ func (m *GetFullChainResponse) GetValue() []byte {
 }
 
 type PropagateBlockRequest struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *PropagateBlockRequest) Reset()         { *m = PropagateBlockRequest{} }
 func (m *PropagateBlockRequest) String() string { return proto.CompactTextString(m) }
 func (*PropagateBlockRequest) ProtoMessage()    {}
 func (*PropagateBlockRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{8}
+	return fileDescriptor_6e7fcaacee94c057, []int{10}
 }
 
 func (m *PropagateBlockRequest) XXX_Unmarshal(b []byte) error {
@@ -368,7 +598,7 @@ func (m *PropagateBlockRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_PropagateBlockRequest proto.InternalMessageInfo
 
-func (m *PropagateBlockRequest) GetBlock() []byte {
+func (m *PropagateBlockRequest) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
@@ -386,7 +616,7 @@ func (m *PropagateBlockResponse) Reset()         { *m = PropagateBlockResponse{}
 func (m *PropagateBlockResponse) String() string { return proto.CompactTextString(m) }
 func (*PropagateBlockResponse) ProtoMessage()    {}
 func (*PropagateBlockResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{9}
+	return fileDescriptor_6e7fcaacee94c057, []int{11}
 }
 
 func (m *PropagateBlockResponse) XXX_Unmarshal(b []byte) error {
@@ -426,7 +656,7 @@ func (m *TokenRequest) Reset()         { *m = TokenRequest{} }
 func (m *TokenRequest) String() string { return proto.CompactTextString(m) }
 func (*TokenRequest) ProtoMessage()    {}
 func (*TokenRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{10}
+	return fileDescriptor_6e7fcaacee94c057, []int{12}
 }
 
 func (m *TokenRequest) XXX_Unmarshal(b []byte) error {
@@ -472,7 +702,7 @@ func (m *TokenResponse) Reset()         { *m = TokenResponse{} }
 func (m *TokenResponse) String() string { return proto.CompactTextString(m) }
 func (*TokenResponse) ProtoMessage()    {}
 func (*TokenResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{11}
+	return fileDescriptor_6e7fcaacee94c057, []int{13}
 }
 
 func (m *TokenResponse) XXX_Unmarshal(b []byte) error {
@@ -510,7 +740,7 @@ func (m *PingRequest) Reset()         { *m = PingRequest{} }
 func (m *PingRequest) String() string { return proto.CompactTextString(m) }
 func (*PingRequest) ProtoMessage()    {}
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{12}
+	return fileDescriptor_6e7fcaacee94c057, []int{14}
 }
 
 func (m *PingRequest) XXX_Unmarshal(b []byte) error {
@@ -541,7 +771,7 @@ func (m *PingResponse) Reset()         { *m = PingResponse{} }
 func (m *PingResponse) String() string { return proto.CompactTextString(m) }
 func (*PingResponse) ProtoMessage()    {}
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{13}
+	return fileDescriptor_6e7fcaacee94c057, []int{15}
 }
 
 func (m *PingResponse) XXX_Unmarshal(b []byte) error {
@@ -573,7 +803,7 @@ func (m *HeightRequest) Reset()         { *m = HeightRequest{} }
 func (m *HeightRequest) String() string { return proto.CompactTextString(m) }
 func (*HeightRequest) ProtoMessage()    {}
 func (*HeightRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{14}
+	return fileDescriptor_6e7fcaacee94c057, []int{16}
 }
 
 func (m *HeightRequest) XXX_Unmarshal(b []byte) error {
@@ -612,7 +842,7 @@ func (m *HeightResponse) Reset()         { *m = HeightResponse{} }
 func (m *HeightResponse) String() string { return proto.CompactTextString(m) }
 func (*HeightResponse) ProtoMessage()    {}
 func (*HeightResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{15}
+	return fileDescriptor_6e7fcaacee94c057, []int{17}
 }
 
 func (m *HeightResponse) XXX_Unmarshal(b []byte) error {
@@ -651,7 +881,7 @@ func (m *GetChainRequest) Reset()         { *m = GetChainRequest{} }
 func (m *GetChainRequest) String() string { return proto.CompactTextString(m) }
 func (*GetChainRequest) ProtoMessage()    {}
 func (*GetChainRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{16}
+	return fileDescriptor_6e7fcaacee94c057, []int{18}
 }
 
 func (m *GetChainRequest) XXX_Unmarshal(b []byte) error {
@@ -680,17 +910,17 @@ func (m *GetChainRequest) GetToken() []byte {
 }
 
 type GetChainResponse struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *GetChainResponse) Reset()         { *m = GetChainResponse{} }
 func (m *GetChainResponse) String() string { return proto.CompactTextString(m) }
 func (*GetChainResponse) ProtoMessage()    {}
 func (*GetChainResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{17}
+	return fileDescriptor_6e7fcaacee94c057, []int{19}
 }
 
 func (m *GetChainResponse) XXX_Unmarshal(b []byte) error {
@@ -711,7 +941,7 @@ func (m *GetChainResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_GetChainResponse proto.InternalMessageInfo
 
-func (m *GetChainResponse) GetBlock() []byte {
+func (m *GetChainResponse) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
@@ -719,17 +949,17 @@ func (m *GetChainResponse) GetBlock() []byte {
 }
 
 type MineRequest struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *MineRequest) Reset()         { *m = MineRequest{} }
 func (m *MineRequest) String() string { return proto.CompactTextString(m) }
 func (*MineRequest) ProtoMessage()    {}
 func (*MineRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{18}
+	return fileDescriptor_6e7fcaacee94c057, []int{20}
 }
 
 func (m *MineRequest) XXX_Unmarshal(b []byte) error {
@@ -750,7 +980,7 @@ func (m *MineRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_MineRequest proto.InternalMessageInfo
 
-func (m *MineRequest) GetBlock() []byte {
+func (m *MineRequest) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
@@ -758,17 +988,17 @@ func (m *MineRequest) GetBlock() []byte {
 }
 
 type MineResponse struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *MineResponse) Reset()         { *m = MineResponse{} }
 func (m *MineResponse) String() string { return proto.CompactTextString(m) }
 func (*MineResponse) ProtoMessage()    {}
 func (*MineResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{19}
+	return fileDescriptor_6e7fcaacee94c057, []int{21}
 }
 
 func (m *MineResponse) XXX_Unmarshal(b []byte) error {
@@ -789,7 +1019,7 @@ func (m *MineResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_MineResponse proto.InternalMessageInfo
 
-func (m *MineResponse) GetBlock() []byte {
+func (m *MineResponse) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
@@ -797,17 +1027,17 @@ func (m *MineResponse) GetBlock() []byte {
 }
 
 type TestRequest struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *TestRequest) Reset()         { *m = TestRequest{} }
 func (m *TestRequest) String() string { return proto.CompactTextString(m) }
 func (*TestRequest) ProtoMessage()    {}
 func (*TestRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{20}
+	return fileDescriptor_6e7fcaacee94c057, []int{22}
 }
 
 func (m *TestRequest) XXX_Unmarshal(b []byte) error {
@@ -828,7 +1058,7 @@ func (m *TestRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_TestRequest proto.InternalMessageInfo
 
-func (m *TestRequest) GetBlock() []byte {
+func (m *TestRequest) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
@@ -836,17 +1066,17 @@ func (m *TestRequest) GetBlock() []byte {
 }
 
 type TestResponse struct {
-	Block                []byte   `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *TestResponse) Reset()         { *m = TestResponse{} }
 func (m *TestResponse) String() string { return proto.CompactTextString(m) }
 func (*TestResponse) ProtoMessage()    {}
 func (*TestResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6e7fcaacee94c057, []int{21}
+	return fileDescriptor_6e7fcaacee94c057, []int{23}
 }
 
 func (m *TestResponse) XXX_Unmarshal(b []byte) error {
@@ -867,115 +1097,2168 @@ func (m *TestResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_TestResponse proto.InternalMessageInfo
 
-func (m *TestResponse) GetBlock() []byte {
+func (m *TestResponse) GetBlock() *BlockMessage {
 	if m != nil {
 		return m.Block
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*SendAddressRequest)(nil), "blockchain.SendAddressRequest")
-	proto.RegisterType((*SendAddressResponse)(nil), "blockchain.SendAddressResponse")
-	proto.RegisterType((*GetAddressRequest)(nil), "blockchain.GetAddressRequest")
-	proto.RegisterType((*GetAddressResponse)(nil), "blockchain.GetAddressResponse")
-	proto.RegisterType((*FullHeightRequest)(nil), "blockchain.FullHeightRequest")
-	proto.RegisterType((*FullHeightResponse)(nil), "blockchain.FullHeightResponse")
-	proto.RegisterType((*GetFullChainRequest)(nil), "blockchain.GetFullChainRequest")
-	proto.RegisterType((*GetFullChainResponse)(nil), "blockchain.GetFullChainResponse")
-	proto.RegisterType((*PropagateBlockRequest)(nil), "blockchain.PropagateBlockRequest")
-	proto.RegisterType((*PropagateBlockResponse)(nil), "blockchain.PropagateBlockResponse")
-	proto.RegisterType((*TokenRequest)(nil), "blockchain.TokenRequest")
-	proto.RegisterType((*TokenResponse)(nil), "blockchain.TokenResponse")
-	proto.RegisterType((*PingRequest)(nil), "blockchain.PingRequest")
-	proto.RegisterType((*PingResponse)(nil), "blockchain.PingResponse")
-	proto.RegisterType((*HeightRequest)(nil), "blockchain.HeightRequest")
-	proto.RegisterType((*HeightResponse)(nil), "blockchain.HeightResponse")
-	proto.RegisterType((*GetChainRequest)(nil), "blockchain.GetChainRequest")
-	proto.RegisterType((*GetChainResponse)(nil), "blockchain.GetChainResponse")
-	proto.RegisterType((*MineRequest)(nil), "blockchain.MineRequest")
-	proto.RegisterType((*MineResponse)(nil), "blockchain.MineResponse")
-	proto.RegisterType((*TestRequest)(nil), "blockchain.TestRequest")
-	proto.RegisterType((*TestResponse)(nil), "blockchain.TestResponse")
+type GetTransactionProofRequest struct {
+	BlockHash            []byte   `protobuf:"bytes,1,opt,name=blockHash,proto3" json:"blockHash,omitempty"`
+	TransactionId        []byte   `protobuf:"bytes,2,opt,name=transactionId,proto3" json:"transactionId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("miner.proto", fileDescriptor_6e7fcaacee94c057) }
+func (m *GetTransactionProofRequest) Reset()         { *m = GetTransactionProofRequest{} }
+func (m *GetTransactionProofRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTransactionProofRequest) ProtoMessage()    {}
+func (*GetTransactionProofRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{24}
+}
 
-var fileDescriptor_6e7fcaacee94c057 = []byte{
-	// 589 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xef, 0x6f, 0x12, 0x41,
-	0x10, 0x0d, 0x14, 0x90, 0x3e, 0xae, 0xd8, 0x2e, 0xb4, 0xd2, 0x53, 0x29, 0xae, 0x1a, 0x49, 0x54,
-	0x62, 0xf4, 0x13, 0x89, 0xd1, 0x68, 0x93, 0x62, 0x62, 0x6a, 0x9a, 0x2b, 0x89, 0xf1, 0xe3, 0xb5,
-	0xb7, 0x01, 0x02, 0xbd, 0xc3, 0xdb, 0xc5, 0x1f, 0xff, 0x8a, 0x7f, 0xad, 0xd9, 0xdb, 0x3d, 0x6e,
-	0x17, 0x0e, 0xda, 0x6f, 0x37, 0x33, 0x6f, 0xde, 0xbc, 0x9d, 0xe1, 0x05, 0xd4, 0x6e, 0x26, 0x21,
-	0x8b, 0x7b, 0xf3, 0x38, 0x12, 0x11, 0xc1, 0xd5, 0x2c, 0xba, 0x9e, 0x5e, 0x8f, 0xfd, 0x49, 0x48,
-	0xbb, 0x20, 0x97, 0x2c, 0x0c, 0x3e, 0x05, 0x41, 0xcc, 0x38, 0xf7, 0xd8, 0xcf, 0x05, 0xe3, 0x82,
-	0x10, 0x94, 0xfc, 0x20, 0x88, 0x5b, 0x85, 0x4e, 0xa1, 0xbb, 0xeb, 0x25, 0xdf, 0xf4, 0x07, 0x1a,
-	0x16, 0x92, 0xcf, 0xa3, 0x90, 0x33, 0x42, 0xe1, 0xc4, 0xfa, 0x7b, 0xc8, 0xfe, 0x08, 0xdd, 0x62,
-	0xe5, 0x48, 0x1b, 0xe0, 0xc2, 0x17, 0x0b, 0x7e, 0x1a, 0x05, 0xac, 0x55, 0xec, 0x14, 0xba, 0x25,
-	0xcf, 0xc8, 0xd0, 0x06, 0x0e, 0x06, 0x4c, 0xd8, 0x1a, 0x68, 0x0f, 0xc4, 0x4c, 0xea, 0x71, 0x2d,
-	0xdc, 0xf3, 0x55, 0x4a, 0x4f, 0x4a, 0x43, 0xfa, 0x12, 0x07, 0x67, 0x8b, 0xd9, 0xec, 0x0b, 0x9b,
-	0x8c, 0xc6, 0x22, 0x7d, 0xc8, 0x11, 0x2a, 0xe3, 0x24, 0x91, 0xa0, 0x77, 0x3c, 0x1d, 0xd1, 0x57,
-	0x20, 0x26, 0x58, 0x93, 0x6f, 0x42, 0x1f, 0xa2, 0x31, 0x60, 0x42, 0x36, 0x9c, 0xca, 0xa5, 0xa5,
-	0x0a, 0x3f, 0xa0, 0x69, 0xa7, 0x35, 0xcd, 0x3e, 0x76, 0xa6, 0xec, 0x6f, 0xc2, 0xe1, 0x78, 0xf2,
-	0x93, 0x34, 0x51, 0xfe, 0xe5, 0xcf, 0x16, 0xea, 0xed, 0x8e, 0xa7, 0x02, 0xfa, 0x1a, 0x87, 0x17,
-	0x71, 0x34, 0xf7, 0x47, 0xbe, 0x60, 0x9f, 0xe5, 0x49, 0x52, 0xd5, 0x4d, 0x94, 0x93, 0x13, 0x69,
-	0x0a, 0x15, 0xd0, 0x2e, 0x8e, 0x56, 0xe1, 0x7a, 0x60, 0x1d, 0xc5, 0x48, 0x81, 0xab, 0x5e, 0x31,
-	0x9a, 0xd2, 0x33, 0x38, 0xc3, 0x68, 0xca, 0x52, 0xa1, 0xc4, 0x45, 0x75, 0xc1, 0x59, 0x1c, 0xfa,
-	0x37, 0x4c, 0x6f, 0x6d, 0x19, 0xcb, 0xda, 0xdc, 0xe7, 0xfc, 0x77, 0x14, 0x07, 0x89, 0xba, 0x5d,
-	0x6f, 0x19, 0xd3, 0xe7, 0xd8, 0xd3, 0x3c, 0x7a, 0x50, 0x13, 0x65, 0x21, 0x13, 0xa9, 0xb0, 0x24,
-	0xa0, 0x7b, 0xa8, 0x5d, 0x4c, 0xc2, 0x51, 0xba, 0x96, 0x3a, 0x1c, 0x15, 0xaa, 0x26, 0xc9, 0x62,
-	0x1f, 0x25, 0x9f, 0xa5, 0x8b, 0xfa, 0x1d, 0xcf, 0xf1, 0x02, 0xf7, 0x07, 0x4c, 0x98, 0xa7, 0xd8,
-	0x48, 0xb9, 0x9f, 0x01, 0xb3, 0x27, 0xe4, 0xec, 0xf6, 0x29, 0x6a, 0xe7, 0x93, 0x90, 0x6d, 0x3f,
-	0xc0, 0x33, 0x38, 0x0a, 0x74, 0x1b, 0xd5, 0x90, 0x71, 0x71, 0x2b, 0x95, 0x02, 0x6d, 0xa3, 0x7a,
-	0xfb, 0xaf, 0x82, 0xb2, 0x9c, 0x18, 0x93, 0x6f, 0xa8, 0x19, 0xe6, 0x23, 0xed, 0x5e, 0x66, 0xe1,
-	0xde, 0xba, 0x7f, 0xdd, 0x93, 0x8d, 0x75, 0x3d, 0xef, 0x1c, 0xc8, 0xcc, 0x45, 0x1e, 0x9b, 0xf0,
-	0x35, 0x27, 0xba, 0xed, 0x4d, 0x65, 0x45, 0xf6, 0xa6, 0x40, 0xbe, 0x02, 0x99, 0x9d, 0x6c, 0xba,
-	0x35, 0x4f, 0xda, 0x74, 0x39, 0x2e, 0xfc, 0x88, 0x8a, 0x26, 0x3a, 0x36, 0x91, 0x36, 0x89, 0x9b,
-	0x57, 0xd2, 0x04, 0x97, 0x70, 0x4c, 0x5f, 0x92, 0x93, 0x15, 0xfd, 0xab, 0x46, 0x76, 0x3b, 0x9b,
-	0x01, 0xcb, 0x27, 0x7e, 0x47, 0xdd, 0x76, 0x1f, 0x79, 0x62, 0x76, 0xe5, 0x1a, 0xd9, 0xa5, 0xdb,
-	0x20, 0x5a, 0xed, 0x7b, 0x94, 0x13, 0x93, 0x91, 0x96, 0x09, 0x36, 0xfd, 0xeb, 0x1e, 0xe7, 0x54,
-	0x74, 0x77, 0x1f, 0x25, 0x69, 0x36, 0xf2, 0xc0, 0x9a, 0x94, 0xb9, 0xd1, 0x6d, 0xad, 0x17, 0x74,
-	0xeb, 0x00, 0xd5, 0xd4, 0x1d, 0xe4, 0xe1, 0xca, 0x06, 0xac, 0xf5, 0x3c, 0xca, 0x2f, 0x2e, 0x57,
-	0xd3, 0x47, 0x49, 0xfe, 0x4a, 0x6d, 0x0d, 0x86, 0x9d, 0x6c, 0x0d, 0x96, 0x85, 0xfa, 0x28, 0x49,
-	0x1f, 0xd8, 0xad, 0x86, 0x7d, 0xec, 0x56, 0xd3, 0x32, 0x57, 0x95, 0xe4, 0xcf, 0xec, 0xdd, 0xff,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0xac, 0x47, 0x8c, 0xdb, 0xdb, 0x06, 0x00, 0x00,
+func (m *GetTransactionProofRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTransactionProofRequest.Unmarshal(m, b)
+}
+func (m *GetTransactionProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTransactionProofRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTransactionProofRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTransactionProofRequest.Merge(m, src)
+}
+func (m *GetTransactionProofRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTransactionProofRequest.Size(m)
+}
+func (m *GetTransactionProofRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTransactionProofRequest.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
+var xxx_messageInfo_GetTransactionProofRequest proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
+func (m *GetTransactionProofRequest) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
 
-// MinerClient is the client API for Miner service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type MinerClient interface {
-	SendAddress(ctx context.Context, in *SendAddressRequest, opts ...grpc.CallOption) (*SendAddressResponse, error)
-	GetAddress(ctx context.Context, in *GetAddressRequest, opts ...grpc.CallOption) (Miner_GetAddressClient, error)
-	FullHeight(ctx context.Context, in *FullHeightRequest, opts ...grpc.CallOption) (*FullHeightResponse, error)
-	Height(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*HeightResponse, error)
-	GetFullChain(ctx context.Context, in *GetFullChainRequest, opts ...grpc.CallOption) (Miner_GetFullChainClient, error)
-	PropagateBlock(ctx context.Context, in *PropagateBlockRequest, opts ...grpc.CallOption) (*PropagateBlockResponse, error)
-	Token(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*TokenResponse, error)
-	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
-	GetChain(ctx context.Context, in *GetChainRequest, opts ...grpc.CallOption) (Miner_GetChainClient, error)
-	Mine(ctx context.Context, in *MineRequest, opts ...grpc.CallOption) (*MineResponse, error)
-	Test(ctx context.Context, in *TestRequest, opts ...grpc.CallOption) (*TestResponse, error)
+func (m *GetTransactionProofRequest) GetTransactionId() []byte {
+	if m != nil {
+		return m.TransactionId
+	}
+	return nil
 }
 
-type minerClient struct {
-	cc grpc.ClientConnInterface
+type ProofStep struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Left                 bool     `protobuf:"varint,2,opt,name=left,proto3" json:"left,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func NewMinerClient(cc grpc.ClientConnInterface) MinerClient {
-	return &minerClient{cc}
+func (m *ProofStep) Reset()         { *m = ProofStep{} }
+func (m *ProofStep) String() string { return proto.CompactTextString(m) }
+func (*ProofStep) ProtoMessage()    {}
+func (*ProofStep) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{25}
 }
 
-func (c *minerClient) SendAddress(ctx context.Context, in *SendAddressRequest, opts ...grpc.CallOption) (*SendAddressResponse, error) {
+func (m *ProofStep) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProofStep.Unmarshal(m, b)
+}
+func (m *ProofStep) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProofStep.Marshal(b, m, deterministic)
+}
+func (m *ProofStep) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProofStep.Merge(m, src)
+}
+func (m *ProofStep) XXX_Size() int {
+	return xxx_messageInfo_ProofStep.Size(m)
+}
+func (m *ProofStep) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProofStep.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProofStep proto.InternalMessageInfo
+
+func (m *ProofStep) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *ProofStep) GetLeft() bool {
+	if m != nil {
+		return m.Left
+	}
+	return false
+}
+
+type GetTransactionProofResponse struct {
+	Header               *BlockMessage       `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Transaction          *TransactionMessage `protobuf:"bytes,2,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	Index                int64               `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Path                 []*ProofStep        `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+	Count                int64               `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *GetTransactionProofResponse) Reset()         { *m = GetTransactionProofResponse{} }
+func (m *GetTransactionProofResponse) String() string { return proto.CompactTextString(m) }
+func (*GetTransactionProofResponse) ProtoMessage()    {}
+func (*GetTransactionProofResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{26}
+}
+
+func (m *GetTransactionProofResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTransactionProofResponse.Unmarshal(m, b)
+}
+func (m *GetTransactionProofResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTransactionProofResponse.Marshal(b, m, deterministic)
+}
+func (m *GetTransactionProofResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTransactionProofResponse.Merge(m, src)
+}
+func (m *GetTransactionProofResponse) XXX_Size() int {
+	return xxx_messageInfo_GetTransactionProofResponse.Size(m)
+}
+func (m *GetTransactionProofResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTransactionProofResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTransactionProofResponse proto.InternalMessageInfo
+
+func (m *GetTransactionProofResponse) GetHeader() *BlockMessage {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *GetTransactionProofResponse) GetTransaction() *TransactionMessage {
+	if m != nil {
+		return m.Transaction
+	}
+	return nil
+}
+
+func (m *GetTransactionProofResponse) GetIndex() int64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *GetTransactionProofResponse) GetPath() []*ProofStep {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+func (m *GetTransactionProofResponse) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type GetChainParamsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetChainParamsRequest) Reset()         { *m = GetChainParamsRequest{} }
+func (m *GetChainParamsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetChainParamsRequest) ProtoMessage()    {}
+func (*GetChainParamsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{27}
+}
+
+func (m *GetChainParamsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetChainParamsRequest.Unmarshal(m, b)
+}
+func (m *GetChainParamsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetChainParamsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetChainParamsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetChainParamsRequest.Merge(m, src)
+}
+func (m *GetChainParamsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetChainParamsRequest.Size(m)
+}
+func (m *GetChainParamsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetChainParamsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetChainParamsRequest proto.InternalMessageInfo
+
+type GetChainParamsResponse struct {
+	Params               []byte   `protobuf:"bytes,1,opt,name=params,proto3" json:"params,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetChainParamsResponse) Reset()         { *m = GetChainParamsResponse{} }
+func (m *GetChainParamsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetChainParamsResponse) ProtoMessage()    {}
+func (*GetChainParamsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{28}
+}
+
+func (m *GetChainParamsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetChainParamsResponse.Unmarshal(m, b)
+}
+func (m *GetChainParamsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetChainParamsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetChainParamsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetChainParamsResponse.Merge(m, src)
+}
+func (m *GetChainParamsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetChainParamsResponse.Size(m)
+}
+func (m *GetChainParamsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetChainParamsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetChainParamsResponse proto.InternalMessageInfo
+
+func (m *GetChainParamsResponse) GetParams() []byte {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type EvidenceMessage struct {
+	First                *BlockMessage `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	Second               *BlockMessage `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+	Reporter             []byte        `protobuf:"bytes,3,opt,name=reporter,proto3" json:"reporter,omitempty"`
+	Timestamp            int64         `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Signature            []byte        `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *EvidenceMessage) Reset()         { *m = EvidenceMessage{} }
+func (m *EvidenceMessage) String() string { return proto.CompactTextString(m) }
+func (*EvidenceMessage) ProtoMessage()    {}
+func (*EvidenceMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{29}
+}
+
+func (m *EvidenceMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EvidenceMessage.Unmarshal(m, b)
+}
+func (m *EvidenceMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EvidenceMessage.Marshal(b, m, deterministic)
+}
+func (m *EvidenceMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EvidenceMessage.Merge(m, src)
+}
+func (m *EvidenceMessage) XXX_Size() int {
+	return xxx_messageInfo_EvidenceMessage.Size(m)
+}
+func (m *EvidenceMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_EvidenceMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EvidenceMessage proto.InternalMessageInfo
+
+func (m *EvidenceMessage) GetFirst() *BlockMessage {
+	if m != nil {
+		return m.First
+	}
+	return nil
+}
+
+func (m *EvidenceMessage) GetSecond() *BlockMessage {
+	if m != nil {
+		return m.Second
+	}
+	return nil
+}
+
+func (m *EvidenceMessage) GetReporter() []byte {
+	if m != nil {
+		return m.Reporter
+	}
+	return nil
+}
+
+func (m *EvidenceMessage) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *EvidenceMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type PropagateEvidenceRequest struct {
+	Evidence             *EvidenceMessage `protobuf:"bytes,1,opt,name=evidence,proto3" json:"evidence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *PropagateEvidenceRequest) Reset()         { *m = PropagateEvidenceRequest{} }
+func (m *PropagateEvidenceRequest) String() string { return proto.CompactTextString(m) }
+func (*PropagateEvidenceRequest) ProtoMessage()    {}
+func (*PropagateEvidenceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{30}
+}
+
+func (m *PropagateEvidenceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateEvidenceRequest.Unmarshal(m, b)
+}
+func (m *PropagateEvidenceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateEvidenceRequest.Marshal(b, m, deterministic)
+}
+func (m *PropagateEvidenceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateEvidenceRequest.Merge(m, src)
+}
+func (m *PropagateEvidenceRequest) XXX_Size() int {
+	return xxx_messageInfo_PropagateEvidenceRequest.Size(m)
+}
+func (m *PropagateEvidenceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateEvidenceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateEvidenceRequest proto.InternalMessageInfo
+
+func (m *PropagateEvidenceRequest) GetEvidence() *EvidenceMessage {
+	if m != nil {
+		return m.Evidence
+	}
+	return nil
+}
+
+type PropagateEvidenceResponse struct {
+	Ok                   bool     `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PropagateEvidenceResponse) Reset()         { *m = PropagateEvidenceResponse{} }
+func (m *PropagateEvidenceResponse) String() string { return proto.CompactTextString(m) }
+func (*PropagateEvidenceResponse) ProtoMessage()    {}
+func (*PropagateEvidenceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{31}
+}
+
+func (m *PropagateEvidenceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateEvidenceResponse.Unmarshal(m, b)
+}
+func (m *PropagateEvidenceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateEvidenceResponse.Marshal(b, m, deterministic)
+}
+func (m *PropagateEvidenceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateEvidenceResponse.Merge(m, src)
+}
+func (m *PropagateEvidenceResponse) XXX_Size() int {
+	return xxx_messageInfo_PropagateEvidenceResponse.Size(m)
+}
+func (m *PropagateEvidenceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateEvidenceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateEvidenceResponse proto.InternalMessageInfo
+
+func (m *PropagateEvidenceResponse) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+type GetEvidenceRequest struct {
+	Token                []byte   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetEvidenceRequest) Reset()         { *m = GetEvidenceRequest{} }
+func (m *GetEvidenceRequest) String() string { return proto.CompactTextString(m) }
+func (*GetEvidenceRequest) ProtoMessage()    {}
+func (*GetEvidenceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{32}
+}
+
+func (m *GetEvidenceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEvidenceRequest.Unmarshal(m, b)
+}
+func (m *GetEvidenceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEvidenceRequest.Marshal(b, m, deterministic)
+}
+func (m *GetEvidenceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEvidenceRequest.Merge(m, src)
+}
+func (m *GetEvidenceRequest) XXX_Size() int {
+	return xxx_messageInfo_GetEvidenceRequest.Size(m)
+}
+func (m *GetEvidenceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEvidenceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetEvidenceRequest proto.InternalMessageInfo
+
+func (m *GetEvidenceRequest) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+type GetEvidenceResponse struct {
+	Evidence             []*EvidenceMessage `protobuf:"bytes,1,rep,name=evidence,proto3" json:"evidence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetEvidenceResponse) Reset()         { *m = GetEvidenceResponse{} }
+func (m *GetEvidenceResponse) String() string { return proto.CompactTextString(m) }
+func (*GetEvidenceResponse) ProtoMessage()    {}
+func (*GetEvidenceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{33}
+}
+
+func (m *GetEvidenceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEvidenceResponse.Unmarshal(m, b)
+}
+func (m *GetEvidenceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEvidenceResponse.Marshal(b, m, deterministic)
+}
+func (m *GetEvidenceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEvidenceResponse.Merge(m, src)
+}
+func (m *GetEvidenceResponse) XXX_Size() int {
+	return xxx_messageInfo_GetEvidenceResponse.Size(m)
+}
+func (m *GetEvidenceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEvidenceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetEvidenceResponse proto.InternalMessageInfo
+
+func (m *GetEvidenceResponse) GetEvidence() []*EvidenceMessage {
+	if m != nil {
+		return m.Evidence
+	}
+	return nil
+}
+
+type CheckpointTipMessage struct {
+	Address              []byte   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Hash                 []byte   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckpointTipMessage) Reset()         { *m = CheckpointTipMessage{} }
+func (m *CheckpointTipMessage) String() string { return proto.CompactTextString(m) }
+func (*CheckpointTipMessage) ProtoMessage()    {}
+func (*CheckpointTipMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{34}
+}
+
+func (m *CheckpointTipMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckpointTipMessage.Unmarshal(m, b)
+}
+func (m *CheckpointTipMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckpointTipMessage.Marshal(b, m, deterministic)
+}
+func (m *CheckpointTipMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckpointTipMessage.Merge(m, src)
+}
+func (m *CheckpointTipMessage) XXX_Size() int {
+	return xxx_messageInfo_CheckpointTipMessage.Size(m)
+}
+func (m *CheckpointTipMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckpointTipMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckpointTipMessage proto.InternalMessageInfo
+
+func (m *CheckpointTipMessage) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *CheckpointTipMessage) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type CheckpointMessage struct {
+	Height               int64                   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	PrevHash             []byte                  `protobuf:"bytes,2,opt,name=prevHash,proto3" json:"prevHash,omitempty"`
+	Timestamp            int64                   `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	TipsRoot             []byte                  `protobuf:"bytes,4,opt,name=tipsRoot,proto3" json:"tipsRoot,omitempty"`
+	TipCount             int64                   `protobuf:"varint,5,opt,name=tipCount,proto3" json:"tipCount,omitempty"`
+	Tips                 []*CheckpointTipMessage `protobuf:"bytes,6,rep,name=tips,proto3" json:"tips,omitempty"`
+	Creator              []byte                  `protobuf:"bytes,7,opt,name=creator,proto3" json:"creator,omitempty"`
+	Signature            []byte                  `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
+	Hash                 []byte                  `protobuf:"bytes,9,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *CheckpointMessage) Reset()         { *m = CheckpointMessage{} }
+func (m *CheckpointMessage) String() string { return proto.CompactTextString(m) }
+func (*CheckpointMessage) ProtoMessage()    {}
+func (*CheckpointMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{35}
+}
+
+func (m *CheckpointMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckpointMessage.Unmarshal(m, b)
+}
+func (m *CheckpointMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckpointMessage.Marshal(b, m, deterministic)
+}
+func (m *CheckpointMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckpointMessage.Merge(m, src)
+}
+func (m *CheckpointMessage) XXX_Size() int {
+	return xxx_messageInfo_CheckpointMessage.Size(m)
+}
+func (m *CheckpointMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckpointMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckpointMessage proto.InternalMessageInfo
+
+func (m *CheckpointMessage) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *CheckpointMessage) GetPrevHash() []byte {
+	if m != nil {
+		return m.PrevHash
+	}
+	return nil
+}
+
+func (m *CheckpointMessage) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *CheckpointMessage) GetTipsRoot() []byte {
+	if m != nil {
+		return m.TipsRoot
+	}
+	return nil
+}
+
+func (m *CheckpointMessage) GetTipCount() int64 {
+	if m != nil {
+		return m.TipCount
+	}
+	return 0
+}
+
+func (m *CheckpointMessage) GetTips() []*CheckpointTipMessage {
+	if m != nil {
+		return m.Tips
+	}
+	return nil
+}
+
+func (m *CheckpointMessage) GetCreator() []byte {
+	if m != nil {
+		return m.Creator
+	}
+	return nil
+}
+
+func (m *CheckpointMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *CheckpointMessage) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type PropagateCheckpointRequest struct {
+	Checkpoint           *CheckpointMessage `protobuf:"bytes,1,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *PropagateCheckpointRequest) Reset()         { *m = PropagateCheckpointRequest{} }
+func (m *PropagateCheckpointRequest) String() string { return proto.CompactTextString(m) }
+func (*PropagateCheckpointRequest) ProtoMessage()    {}
+func (*PropagateCheckpointRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{36}
+}
+
+func (m *PropagateCheckpointRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateCheckpointRequest.Unmarshal(m, b)
+}
+func (m *PropagateCheckpointRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateCheckpointRequest.Marshal(b, m, deterministic)
+}
+func (m *PropagateCheckpointRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateCheckpointRequest.Merge(m, src)
+}
+func (m *PropagateCheckpointRequest) XXX_Size() int {
+	return xxx_messageInfo_PropagateCheckpointRequest.Size(m)
+}
+func (m *PropagateCheckpointRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateCheckpointRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateCheckpointRequest proto.InternalMessageInfo
+
+func (m *PropagateCheckpointRequest) GetCheckpoint() *CheckpointMessage {
+	if m != nil {
+		return m.Checkpoint
+	}
+	return nil
+}
+
+type PropagateCheckpointResponse struct {
+	Ok                   bool     `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PropagateCheckpointResponse) Reset()         { *m = PropagateCheckpointResponse{} }
+func (m *PropagateCheckpointResponse) String() string { return proto.CompactTextString(m) }
+func (*PropagateCheckpointResponse) ProtoMessage()    {}
+func (*PropagateCheckpointResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{37}
+}
+
+func (m *PropagateCheckpointResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateCheckpointResponse.Unmarshal(m, b)
+}
+func (m *PropagateCheckpointResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateCheckpointResponse.Marshal(b, m, deterministic)
+}
+func (m *PropagateCheckpointResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateCheckpointResponse.Merge(m, src)
+}
+func (m *PropagateCheckpointResponse) XXX_Size() int {
+	return xxx_messageInfo_PropagateCheckpointResponse.Size(m)
+}
+func (m *PropagateCheckpointResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateCheckpointResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateCheckpointResponse proto.InternalMessageInfo
+
+func (m *PropagateCheckpointResponse) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+type GetCheckpointProofRequest struct {
+	Height               int64    `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Token                []byte   `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCheckpointProofRequest) Reset()         { *m = GetCheckpointProofRequest{} }
+func (m *GetCheckpointProofRequest) String() string { return proto.CompactTextString(m) }
+func (*GetCheckpointProofRequest) ProtoMessage()    {}
+func (*GetCheckpointProofRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{38}
+}
+
+func (m *GetCheckpointProofRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCheckpointProofRequest.Unmarshal(m, b)
+}
+func (m *GetCheckpointProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCheckpointProofRequest.Marshal(b, m, deterministic)
+}
+func (m *GetCheckpointProofRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCheckpointProofRequest.Merge(m, src)
+}
+func (m *GetCheckpointProofRequest) XXX_Size() int {
+	return xxx_messageInfo_GetCheckpointProofRequest.Size(m)
+}
+func (m *GetCheckpointProofRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCheckpointProofRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCheckpointProofRequest proto.InternalMessageInfo
+
+func (m *GetCheckpointProofRequest) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *GetCheckpointProofRequest) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+type GetCheckpointProofResponse struct {
+	Checkpoint           *CheckpointMessage    `protobuf:"bytes,1,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	Tip                  *CheckpointTipMessage `protobuf:"bytes,2,opt,name=tip,proto3" json:"tip,omitempty"`
+	Index                int64                 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Path                 []*ProofStep          `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *GetCheckpointProofResponse) Reset()         { *m = GetCheckpointProofResponse{} }
+func (m *GetCheckpointProofResponse) String() string { return proto.CompactTextString(m) }
+func (*GetCheckpointProofResponse) ProtoMessage()    {}
+func (*GetCheckpointProofResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{39}
+}
+
+func (m *GetCheckpointProofResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCheckpointProofResponse.Unmarshal(m, b)
+}
+func (m *GetCheckpointProofResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCheckpointProofResponse.Marshal(b, m, deterministic)
+}
+func (m *GetCheckpointProofResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCheckpointProofResponse.Merge(m, src)
+}
+func (m *GetCheckpointProofResponse) XXX_Size() int {
+	return xxx_messageInfo_GetCheckpointProofResponse.Size(m)
+}
+func (m *GetCheckpointProofResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCheckpointProofResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCheckpointProofResponse proto.InternalMessageInfo
+
+func (m *GetCheckpointProofResponse) GetCheckpoint() *CheckpointMessage {
+	if m != nil {
+		return m.Checkpoint
+	}
+	return nil
+}
+
+func (m *GetCheckpointProofResponse) GetTip() *CheckpointTipMessage {
+	if m != nil {
+		return m.Tip
+	}
+	return nil
+}
+
+func (m *GetCheckpointProofResponse) GetIndex() int64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *GetCheckpointProofResponse) GetPath() []*ProofStep {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+type CheckpointVoteMessage struct {
+	Height               int64    `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	CheckpointHash       []byte   `protobuf:"bytes,2,opt,name=checkpointHash,proto3" json:"checkpointHash,omitempty"`
+	Signer               []byte   `protobuf:"bytes,3,opt,name=signer,proto3" json:"signer,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckpointVoteMessage) Reset()         { *m = CheckpointVoteMessage{} }
+func (m *CheckpointVoteMessage) String() string { return proto.CompactTextString(m) }
+func (*CheckpointVoteMessage) ProtoMessage()    {}
+func (*CheckpointVoteMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{40}
+}
+
+func (m *CheckpointVoteMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckpointVoteMessage.Unmarshal(m, b)
+}
+func (m *CheckpointVoteMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckpointVoteMessage.Marshal(b, m, deterministic)
+}
+func (m *CheckpointVoteMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckpointVoteMessage.Merge(m, src)
+}
+func (m *CheckpointVoteMessage) XXX_Size() int {
+	return xxx_messageInfo_CheckpointVoteMessage.Size(m)
+}
+func (m *CheckpointVoteMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckpointVoteMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckpointVoteMessage proto.InternalMessageInfo
+
+func (m *CheckpointVoteMessage) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *CheckpointVoteMessage) GetCheckpointHash() []byte {
+	if m != nil {
+		return m.CheckpointHash
+	}
+	return nil
+}
+
+func (m *CheckpointVoteMessage) GetSigner() []byte {
+	if m != nil {
+		return m.Signer
+	}
+	return nil
+}
+
+func (m *CheckpointVoteMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type PropagateVoteRequest struct {
+	Vote                 *CheckpointVoteMessage `protobuf:"bytes,1,opt,name=vote,proto3" json:"vote,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *PropagateVoteRequest) Reset()         { *m = PropagateVoteRequest{} }
+func (m *PropagateVoteRequest) String() string { return proto.CompactTextString(m) }
+func (*PropagateVoteRequest) ProtoMessage()    {}
+func (*PropagateVoteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{41}
+}
+
+func (m *PropagateVoteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateVoteRequest.Unmarshal(m, b)
+}
+func (m *PropagateVoteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateVoteRequest.Marshal(b, m, deterministic)
+}
+func (m *PropagateVoteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateVoteRequest.Merge(m, src)
+}
+func (m *PropagateVoteRequest) XXX_Size() int {
+	return xxx_messageInfo_PropagateVoteRequest.Size(m)
+}
+func (m *PropagateVoteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateVoteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateVoteRequest proto.InternalMessageInfo
+
+func (m *PropagateVoteRequest) GetVote() *CheckpointVoteMessage {
+	if m != nil {
+		return m.Vote
+	}
+	return nil
+}
+
+type PropagateVoteResponse struct {
+	Ok                   bool     `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PropagateVoteResponse) Reset()         { *m = PropagateVoteResponse{} }
+func (m *PropagateVoteResponse) String() string { return proto.CompactTextString(m) }
+func (*PropagateVoteResponse) ProtoMessage()    {}
+func (*PropagateVoteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{42}
+}
+
+func (m *PropagateVoteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropagateVoteResponse.Unmarshal(m, b)
+}
+func (m *PropagateVoteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropagateVoteResponse.Marshal(b, m, deterministic)
+}
+func (m *PropagateVoteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropagateVoteResponse.Merge(m, src)
+}
+func (m *PropagateVoteResponse) XXX_Size() int {
+	return xxx_messageInfo_PropagateVoteResponse.Size(m)
+}
+func (m *PropagateVoteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropagateVoteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropagateVoteResponse proto.InternalMessageInfo
+
+func (m *PropagateVoteResponse) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+type GetFinalityRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetFinalityRequest) Reset()         { *m = GetFinalityRequest{} }
+func (m *GetFinalityRequest) String() string { return proto.CompactTextString(m) }
+func (*GetFinalityRequest) ProtoMessage()    {}
+func (*GetFinalityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{43}
+}
+
+func (m *GetFinalityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetFinalityRequest.Unmarshal(m, b)
+}
+func (m *GetFinalityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetFinalityRequest.Marshal(b, m, deterministic)
+}
+func (m *GetFinalityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetFinalityRequest.Merge(m, src)
+}
+func (m *GetFinalityRequest) XXX_Size() int {
+	return xxx_messageInfo_GetFinalityRequest.Size(m)
+}
+func (m *GetFinalityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetFinalityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetFinalityRequest proto.InternalMessageInfo
+
+type GetFinalityResponse struct {
+	Checkpoint           *CheckpointMessage       `protobuf:"bytes,1,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	Votes                []*CheckpointVoteMessage `protobuf:"bytes,2,rep,name=votes,proto3" json:"votes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *GetFinalityResponse) Reset()         { *m = GetFinalityResponse{} }
+func (m *GetFinalityResponse) String() string { return proto.CompactTextString(m) }
+func (*GetFinalityResponse) ProtoMessage()    {}
+func (*GetFinalityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{44}
+}
+
+func (m *GetFinalityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetFinalityResponse.Unmarshal(m, b)
+}
+func (m *GetFinalityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetFinalityResponse.Marshal(b, m, deterministic)
+}
+func (m *GetFinalityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetFinalityResponse.Merge(m, src)
+}
+func (m *GetFinalityResponse) XXX_Size() int {
+	return xxx_messageInfo_GetFinalityResponse.Size(m)
+}
+func (m *GetFinalityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetFinalityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetFinalityResponse proto.InternalMessageInfo
+
+func (m *GetFinalityResponse) GetCheckpoint() *CheckpointMessage {
+	if m != nil {
+		return m.Checkpoint
+	}
+	return nil
+}
+
+func (m *GetFinalityResponse) GetVotes() []*CheckpointVoteMessage {
+	if m != nil {
+		return m.Votes
+	}
+	return nil
+}
+
+type JobStatusMessage struct {
+	JobId                []byte        `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	State                string        `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Block                *BlockMessage `protobuf:"bytes,3,opt,name=block,proto3" json:"block,omitempty"`
+	Error                string        `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Updated              int64         `protobuf:"varint,5,opt,name=updated,proto3" json:"updated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *JobStatusMessage) Reset()         { *m = JobStatusMessage{} }
+func (m *JobStatusMessage) String() string { return proto.CompactTextString(m) }
+func (*JobStatusMessage) ProtoMessage()    {}
+func (*JobStatusMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{45}
+}
+
+func (m *JobStatusMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JobStatusMessage.Unmarshal(m, b)
+}
+func (m *JobStatusMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JobStatusMessage.Marshal(b, m, deterministic)
+}
+func (m *JobStatusMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobStatusMessage.Merge(m, src)
+}
+func (m *JobStatusMessage) XXX_Size() int {
+	return xxx_messageInfo_JobStatusMessage.Size(m)
+}
+func (m *JobStatusMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobStatusMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobStatusMessage proto.InternalMessageInfo
+
+func (m *JobStatusMessage) GetJobId() []byte {
+	if m != nil {
+		return m.JobId
+	}
+	return nil
+}
+
+func (m *JobStatusMessage) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *JobStatusMessage) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *JobStatusMessage) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *JobStatusMessage) GetUpdated() int64 {
+	if m != nil {
+		return m.Updated
+	}
+	return 0
+}
+
+type SubmitMiningJobRequest struct {
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *SubmitMiningJobRequest) Reset()         { *m = SubmitMiningJobRequest{} }
+func (m *SubmitMiningJobRequest) String() string { return proto.CompactTextString(m) }
+func (*SubmitMiningJobRequest) ProtoMessage()    {}
+func (*SubmitMiningJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{46}
+}
+
+func (m *SubmitMiningJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubmitMiningJobRequest.Unmarshal(m, b)
+}
+func (m *SubmitMiningJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubmitMiningJobRequest.Marshal(b, m, deterministic)
+}
+func (m *SubmitMiningJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubmitMiningJobRequest.Merge(m, src)
+}
+func (m *SubmitMiningJobRequest) XXX_Size() int {
+	return xxx_messageInfo_SubmitMiningJobRequest.Size(m)
+}
+func (m *SubmitMiningJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubmitMiningJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubmitMiningJobRequest proto.InternalMessageInfo
+
+func (m *SubmitMiningJobRequest) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type SubmitMiningJobResponse struct {
+	JobId                []byte   `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	Busy                 bool     `protobuf:"varint,2,opt,name=busy,proto3" json:"busy,omitempty"`
+	RetryAfter           int64    `protobuf:"varint,3,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
+	Queued               int64    `protobuf:"varint,4,opt,name=queued,proto3" json:"queued,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubmitMiningJobResponse) Reset()         { *m = SubmitMiningJobResponse{} }
+func (m *SubmitMiningJobResponse) String() string { return proto.CompactTextString(m) }
+func (*SubmitMiningJobResponse) ProtoMessage()    {}
+func (*SubmitMiningJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{47}
+}
+
+func (m *SubmitMiningJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubmitMiningJobResponse.Unmarshal(m, b)
+}
+func (m *SubmitMiningJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubmitMiningJobResponse.Marshal(b, m, deterministic)
+}
+func (m *SubmitMiningJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubmitMiningJobResponse.Merge(m, src)
+}
+func (m *SubmitMiningJobResponse) XXX_Size() int {
+	return xxx_messageInfo_SubmitMiningJobResponse.Size(m)
+}
+func (m *SubmitMiningJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubmitMiningJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubmitMiningJobResponse proto.InternalMessageInfo
+
+func (m *SubmitMiningJobResponse) GetJobId() []byte {
+	if m != nil {
+		return m.JobId
+	}
+	return nil
+}
+
+func (m *SubmitMiningJobResponse) GetBusy() bool {
+	if m != nil {
+		return m.Busy
+	}
+	return false
+}
+
+func (m *SubmitMiningJobResponse) GetRetryAfter() int64 {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
+func (m *SubmitMiningJobResponse) GetQueued() int64 {
+	if m != nil {
+		return m.Queued
+	}
+	return 0
+}
+
+type GetJobStatusRequest struct {
+	JobId                []byte   `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetJobStatusRequest) Reset()         { *m = GetJobStatusRequest{} }
+func (m *GetJobStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetJobStatusRequest) ProtoMessage()    {}
+func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{48}
+}
+
+func (m *GetJobStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJobStatusRequest.Unmarshal(m, b)
+}
+func (m *GetJobStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJobStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetJobStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJobStatusRequest.Merge(m, src)
+}
+func (m *GetJobStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetJobStatusRequest.Size(m)
+}
+func (m *GetJobStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJobStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetJobStatusRequest proto.InternalMessageInfo
+
+func (m *GetJobStatusRequest) GetJobId() []byte {
+	if m != nil {
+		return m.JobId
+	}
+	return nil
+}
+
+type GetJobStatusResponse struct {
+	Status               *JobStatusMessage `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *GetJobStatusResponse) Reset()         { *m = GetJobStatusResponse{} }
+func (m *GetJobStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetJobStatusResponse) ProtoMessage()    {}
+func (*GetJobStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{49}
+}
+
+func (m *GetJobStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetJobStatusResponse.Unmarshal(m, b)
+}
+func (m *GetJobStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetJobStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *GetJobStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetJobStatusResponse.Merge(m, src)
+}
+func (m *GetJobStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetJobStatusResponse.Size(m)
+}
+func (m *GetJobStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetJobStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetJobStatusResponse proto.InternalMessageInfo
+
+func (m *GetJobStatusResponse) GetStatus() *JobStatusMessage {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type WatchJobRequest struct {
+	JobId                []byte   `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchJobRequest) Reset()         { *m = WatchJobRequest{} }
+func (m *WatchJobRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchJobRequest) ProtoMessage()    {}
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{50}
+}
+
+func (m *WatchJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchJobRequest.Unmarshal(m, b)
+}
+func (m *WatchJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchJobRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchJobRequest.Merge(m, src)
+}
+func (m *WatchJobRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchJobRequest.Size(m)
+}
+func (m *WatchJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchJobRequest proto.InternalMessageInfo
+
+func (m *WatchJobRequest) GetJobId() []byte {
+	if m != nil {
+		return m.JobId
+	}
+	return nil
+}
+
+type WatchJobResponse struct {
+	Status               *JobStatusMessage `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *WatchJobResponse) Reset()         { *m = WatchJobResponse{} }
+func (m *WatchJobResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchJobResponse) ProtoMessage()    {}
+func (*WatchJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{51}
+}
+
+func (m *WatchJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchJobResponse.Unmarshal(m, b)
+}
+func (m *WatchJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchJobResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchJobResponse.Merge(m, src)
+}
+func (m *WatchJobResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchJobResponse.Size(m)
+}
+func (m *WatchJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchJobResponse proto.InternalMessageInfo
+
+func (m *WatchJobResponse) GetStatus() *JobStatusMessage {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type CancelJobRequest struct {
+	JobId                []byte   `protobuf:"bytes,1,opt,name=jobId,proto3" json:"jobId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobRequest) Reset()         { *m = CancelJobRequest{} }
+func (m *CancelJobRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelJobRequest) ProtoMessage()    {}
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{52}
+}
+
+func (m *CancelJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobRequest.Unmarshal(m, b)
+}
+func (m *CancelJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobRequest.Marshal(b, m, deterministic)
+}
+func (m *CancelJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobRequest.Merge(m, src)
+}
+func (m *CancelJobRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelJobRequest.Size(m)
+}
+func (m *CancelJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobRequest proto.InternalMessageInfo
+
+func (m *CancelJobRequest) GetJobId() []byte {
+	if m != nil {
+		return m.JobId
+	}
+	return nil
+}
+
+type CancelJobResponse struct {
+	Ok                   bool     `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobResponse) Reset()         { *m = CancelJobResponse{} }
+func (m *CancelJobResponse) String() string { return proto.CompactTextString(m) }
+func (*CancelJobResponse) ProtoMessage()    {}
+func (*CancelJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{53}
+}
+
+func (m *CancelJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobResponse.Unmarshal(m, b)
+}
+func (m *CancelJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobResponse.Marshal(b, m, deterministic)
+}
+func (m *CancelJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobResponse.Merge(m, src)
+}
+func (m *CancelJobResponse) XXX_Size() int {
+	return xxx_messageInfo_CancelJobResponse.Size(m)
+}
+func (m *CancelJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobResponse proto.InternalMessageInfo
+
+func (m *CancelJobResponse) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+type GetMinerLoadRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMinerLoadRequest) Reset()         { *m = GetMinerLoadRequest{} }
+func (m *GetMinerLoadRequest) String() string { return proto.CompactTextString(m) }
+func (*GetMinerLoadRequest) ProtoMessage()    {}
+func (*GetMinerLoadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{54}
+}
+
+func (m *GetMinerLoadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMinerLoadRequest.Unmarshal(m, b)
+}
+func (m *GetMinerLoadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMinerLoadRequest.Marshal(b, m, deterministic)
+}
+func (m *GetMinerLoadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMinerLoadRequest.Merge(m, src)
+}
+func (m *GetMinerLoadRequest) XXX_Size() int {
+	return xxx_messageInfo_GetMinerLoadRequest.Size(m)
+}
+func (m *GetMinerLoadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMinerLoadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMinerLoadRequest proto.InternalMessageInfo
+
+type GetMinerLoadResponse struct {
+	Queued               int64    `protobuf:"varint,1,opt,name=queued,proto3" json:"queued,omitempty"`
+	Running              int64    `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	Workers              int64    `protobuf:"varint,3,opt,name=workers,proto3" json:"workers,omitempty"`
+	Capacity             int64    `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	RetryAfter           int64    `protobuf:"varint,5,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetMinerLoadResponse) Reset()         { *m = GetMinerLoadResponse{} }
+func (m *GetMinerLoadResponse) String() string { return proto.CompactTextString(m) }
+func (*GetMinerLoadResponse) ProtoMessage()    {}
+func (*GetMinerLoadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{55}
+}
+
+func (m *GetMinerLoadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetMinerLoadResponse.Unmarshal(m, b)
+}
+func (m *GetMinerLoadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetMinerLoadResponse.Marshal(b, m, deterministic)
+}
+func (m *GetMinerLoadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetMinerLoadResponse.Merge(m, src)
+}
+func (m *GetMinerLoadResponse) XXX_Size() int {
+	return xxx_messageInfo_GetMinerLoadResponse.Size(m)
+}
+func (m *GetMinerLoadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetMinerLoadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetMinerLoadResponse proto.InternalMessageInfo
+
+func (m *GetMinerLoadResponse) GetQueued() int64 {
+	if m != nil {
+		return m.Queued
+	}
+	return 0
+}
+
+func (m *GetMinerLoadResponse) GetRunning() int64 {
+	if m != nil {
+		return m.Running
+	}
+	return 0
+}
+
+func (m *GetMinerLoadResponse) GetWorkers() int64 {
+	if m != nil {
+		return m.Workers
+	}
+	return 0
+}
+
+func (m *GetMinerLoadResponse) GetCapacity() int64 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+func (m *GetMinerLoadResponse) GetRetryAfter() int64 {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
+type MineShareRequest struct {
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Start                int64         `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Count                int64         `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *MineShareRequest) Reset()         { *m = MineShareRequest{} }
+func (m *MineShareRequest) String() string { return proto.CompactTextString(m) }
+func (*MineShareRequest) ProtoMessage()    {}
+func (*MineShareRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{56}
+}
+
+func (m *MineShareRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MineShareRequest.Unmarshal(m, b)
+}
+func (m *MineShareRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MineShareRequest.Marshal(b, m, deterministic)
+}
+func (m *MineShareRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MineShareRequest.Merge(m, src)
+}
+func (m *MineShareRequest) XXX_Size() int {
+	return xxx_messageInfo_MineShareRequest.Size(m)
+}
+func (m *MineShareRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MineShareRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MineShareRequest proto.InternalMessageInfo
+
+func (m *MineShareRequest) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *MineShareRequest) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *MineShareRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type MineShareResponse struct {
+	Found                bool     `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Nonce                int64    `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Hash                 []byte   `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MineShareResponse) Reset()         { *m = MineShareResponse{} }
+func (m *MineShareResponse) String() string { return proto.CompactTextString(m) }
+func (*MineShareResponse) ProtoMessage()    {}
+func (*MineShareResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{57}
+}
+
+func (m *MineShareResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MineShareResponse.Unmarshal(m, b)
+}
+func (m *MineShareResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MineShareResponse.Marshal(b, m, deterministic)
+}
+func (m *MineShareResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MineShareResponse.Merge(m, src)
+}
+func (m *MineShareResponse) XXX_Size() int {
+	return xxx_messageInfo_MineShareResponse.Size(m)
+}
+func (m *MineShareResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MineShareResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MineShareResponse proto.InternalMessageInfo
+
+func (m *MineShareResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *MineShareResponse) GetNonce() int64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *MineShareResponse) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type GetBlockByTransactionRequest struct {
+	TransactionId        []byte   `protobuf:"bytes,1,opt,name=transactionId,proto3" json:"transactionId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBlockByTransactionRequest) Reset()         { *m = GetBlockByTransactionRequest{} }
+func (m *GetBlockByTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBlockByTransactionRequest) ProtoMessage()    {}
+func (*GetBlockByTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{58}
+}
+
+func (m *GetBlockByTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockByTransactionRequest.Unmarshal(m, b)
+}
+func (m *GetBlockByTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockByTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBlockByTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockByTransactionRequest.Merge(m, src)
+}
+func (m *GetBlockByTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBlockByTransactionRequest.Size(m)
+}
+func (m *GetBlockByTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockByTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockByTransactionRequest proto.InternalMessageInfo
+
+func (m *GetBlockByTransactionRequest) GetTransactionId() []byte {
+	if m != nil {
+		return m.TransactionId
+	}
+	return nil
+}
+
+type GetBlockByTransactionResponse struct {
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetBlockByTransactionResponse) Reset()         { *m = GetBlockByTransactionResponse{} }
+func (m *GetBlockByTransactionResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBlockByTransactionResponse) ProtoMessage()    {}
+func (*GetBlockByTransactionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{59}
+}
+
+func (m *GetBlockByTransactionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockByTransactionResponse.Unmarshal(m, b)
+}
+func (m *GetBlockByTransactionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockByTransactionResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBlockByTransactionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockByTransactionResponse.Merge(m, src)
+}
+func (m *GetBlockByTransactionResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBlockByTransactionResponse.Size(m)
+}
+func (m *GetBlockByTransactionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockByTransactionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockByTransactionResponse proto.InternalMessageInfo
+
+func (m *GetBlockByTransactionResponse) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type GetBlockByHeightRequest struct {
+	Token                []byte   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Height               int64    `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBlockByHeightRequest) Reset()         { *m = GetBlockByHeightRequest{} }
+func (m *GetBlockByHeightRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBlockByHeightRequest) ProtoMessage()    {}
+func (*GetBlockByHeightRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{60}
+}
+
+func (m *GetBlockByHeightRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockByHeightRequest.Unmarshal(m, b)
+}
+func (m *GetBlockByHeightRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockByHeightRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBlockByHeightRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockByHeightRequest.Merge(m, src)
+}
+func (m *GetBlockByHeightRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBlockByHeightRequest.Size(m)
+}
+func (m *GetBlockByHeightRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockByHeightRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockByHeightRequest proto.InternalMessageInfo
+
+func (m *GetBlockByHeightRequest) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+func (m *GetBlockByHeightRequest) GetHeight() int64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type GetBlockByHeightResponse struct {
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetBlockByHeightResponse) Reset()         { *m = GetBlockByHeightResponse{} }
+func (m *GetBlockByHeightResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBlockByHeightResponse) ProtoMessage()    {}
+func (*GetBlockByHeightResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{61}
+}
+
+func (m *GetBlockByHeightResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlockByHeightResponse.Unmarshal(m, b)
+}
+func (m *GetBlockByHeightResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlockByHeightResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBlockByHeightResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlockByHeightResponse.Merge(m, src)
+}
+func (m *GetBlockByHeightResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBlockByHeightResponse.Size(m)
+}
+func (m *GetBlockByHeightResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlockByHeightResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlockByHeightResponse proto.InternalMessageInfo
+
+func (m *GetBlockByHeightResponse) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+type GetBlocksByTimeRequest struct {
+	Token                []byte   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   int64    `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBlocksByTimeRequest) Reset()         { *m = GetBlocksByTimeRequest{} }
+func (m *GetBlocksByTimeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBlocksByTimeRequest) ProtoMessage()    {}
+func (*GetBlocksByTimeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{62}
+}
+
+func (m *GetBlocksByTimeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlocksByTimeRequest.Unmarshal(m, b)
+}
+func (m *GetBlocksByTimeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlocksByTimeRequest.Marshal(b, m, deterministic)
+}
+func (m *GetBlocksByTimeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlocksByTimeRequest.Merge(m, src)
+}
+func (m *GetBlocksByTimeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetBlocksByTimeRequest.Size(m)
+}
+func (m *GetBlocksByTimeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlocksByTimeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlocksByTimeRequest proto.InternalMessageInfo
+
+func (m *GetBlocksByTimeRequest) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+func (m *GetBlocksByTimeRequest) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *GetBlocksByTimeRequest) GetTo() int64 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+type GetBlocksByTimeResponse struct {
+	Block                *BlockMessage `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetBlocksByTimeResponse) Reset()         { *m = GetBlocksByTimeResponse{} }
+func (m *GetBlocksByTimeResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBlocksByTimeResponse) ProtoMessage()    {}
+func (*GetBlocksByTimeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6e7fcaacee94c057, []int{63}
+}
+
+func (m *GetBlocksByTimeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetBlocksByTimeResponse.Unmarshal(m, b)
+}
+func (m *GetBlocksByTimeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetBlocksByTimeResponse.Marshal(b, m, deterministic)
+}
+func (m *GetBlocksByTimeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBlocksByTimeResponse.Merge(m, src)
+}
+func (m *GetBlocksByTimeResponse) XXX_Size() int {
+	return xxx_messageInfo_GetBlocksByTimeResponse.Size(m)
+}
+func (m *GetBlocksByTimeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBlocksByTimeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBlocksByTimeResponse proto.InternalMessageInfo
+
+func (m *GetBlocksByTimeResponse) GetBlock() *BlockMessage {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*TransactionMessage)(nil), "blockchain.TransactionMessage")
+	proto.RegisterMapType((map[string]string)(nil), "blockchain.TransactionMessage.MetadataEntry")
+	proto.RegisterType((*BlockMessage)(nil), "blockchain.BlockMessage")
+	proto.RegisterType((*SendAddressRequest)(nil), "blockchain.SendAddressRequest")
+	proto.RegisterType((*SendAddressResponse)(nil), "blockchain.SendAddressResponse")
+	proto.RegisterType((*GetAddressRequest)(nil), "blockchain.GetAddressRequest")
+	proto.RegisterType((*GetAddressResponse)(nil), "blockchain.GetAddressResponse")
+	proto.RegisterType((*FullHeightRequest)(nil), "blockchain.FullHeightRequest")
+	proto.RegisterType((*FullHeightResponse)(nil), "blockchain.FullHeightResponse")
+	proto.RegisterType((*GetFullChainRequest)(nil), "blockchain.GetFullChainRequest")
+	proto.RegisterType((*GetFullChainResponse)(nil), "blockchain.GetFullChainResponse")
+	proto.RegisterType((*PropagateBlockRequest)(nil), "blockchain.PropagateBlockRequest")
+	proto.RegisterType((*PropagateBlockResponse)(nil), "blockchain.PropagateBlockResponse")
+	proto.RegisterType((*TokenRequest)(nil), "blockchain.TokenRequest")
+	proto.RegisterType((*TokenResponse)(nil), "blockchain.TokenResponse")
+	proto.RegisterType((*PingRequest)(nil), "blockchain.PingRequest")
+	proto.RegisterType((*PingResponse)(nil), "blockchain.PingResponse")
+	proto.RegisterType((*HeightRequest)(nil), "blockchain.HeightRequest")
+	proto.RegisterType((*HeightResponse)(nil), "blockchain.HeightResponse")
+	proto.RegisterType((*GetChainRequest)(nil), "blockchain.GetChainRequest")
+	proto.RegisterType((*GetChainResponse)(nil), "blockchain.GetChainResponse")
+	proto.RegisterType((*MineRequest)(nil), "blockchain.MineRequest")
+	proto.RegisterType((*MineResponse)(nil), "blockchain.MineResponse")
+	proto.RegisterType((*TestRequest)(nil), "blockchain.TestRequest")
+	proto.RegisterType((*TestResponse)(nil), "blockchain.TestResponse")
+	proto.RegisterType((*GetTransactionProofRequest)(nil), "blockchain.GetTransactionProofRequest")
+	proto.RegisterType((*ProofStep)(nil), "blockchain.ProofStep")
+	proto.RegisterType((*GetTransactionProofResponse)(nil), "blockchain.GetTransactionProofResponse")
+	proto.RegisterType((*GetChainParamsRequest)(nil), "blockchain.GetChainParamsRequest")
+	proto.RegisterType((*GetChainParamsResponse)(nil), "blockchain.GetChainParamsResponse")
+	proto.RegisterType((*EvidenceMessage)(nil), "blockchain.EvidenceMessage")
+	proto.RegisterType((*PropagateEvidenceRequest)(nil), "blockchain.PropagateEvidenceRequest")
+	proto.RegisterType((*PropagateEvidenceResponse)(nil), "blockchain.PropagateEvidenceResponse")
+	proto.RegisterType((*GetEvidenceRequest)(nil), "blockchain.GetEvidenceRequest")
+	proto.RegisterType((*GetEvidenceResponse)(nil), "blockchain.GetEvidenceResponse")
+	proto.RegisterType((*CheckpointTipMessage)(nil), "blockchain.CheckpointTipMessage")
+	proto.RegisterType((*CheckpointMessage)(nil), "blockchain.CheckpointMessage")
+	proto.RegisterType((*PropagateCheckpointRequest)(nil), "blockchain.PropagateCheckpointRequest")
+	proto.RegisterType((*PropagateCheckpointResponse)(nil), "blockchain.PropagateCheckpointResponse")
+	proto.RegisterType((*GetCheckpointProofRequest)(nil), "blockchain.GetCheckpointProofRequest")
+	proto.RegisterType((*GetCheckpointProofResponse)(nil), "blockchain.GetCheckpointProofResponse")
+	proto.RegisterType((*CheckpointVoteMessage)(nil), "blockchain.CheckpointVoteMessage")
+	proto.RegisterType((*PropagateVoteRequest)(nil), "blockchain.PropagateVoteRequest")
+	proto.RegisterType((*PropagateVoteResponse)(nil), "blockchain.PropagateVoteResponse")
+	proto.RegisterType((*GetFinalityRequest)(nil), "blockchain.GetFinalityRequest")
+	proto.RegisterType((*GetFinalityResponse)(nil), "blockchain.GetFinalityResponse")
+	proto.RegisterType((*JobStatusMessage)(nil), "blockchain.JobStatusMessage")
+	proto.RegisterType((*SubmitMiningJobRequest)(nil), "blockchain.SubmitMiningJobRequest")
+	proto.RegisterType((*SubmitMiningJobResponse)(nil), "blockchain.SubmitMiningJobResponse")
+	proto.RegisterType((*GetJobStatusRequest)(nil), "blockchain.GetJobStatusRequest")
+	proto.RegisterType((*GetJobStatusResponse)(nil), "blockchain.GetJobStatusResponse")
+	proto.RegisterType((*WatchJobRequest)(nil), "blockchain.WatchJobRequest")
+	proto.RegisterType((*WatchJobResponse)(nil), "blockchain.WatchJobResponse")
+	proto.RegisterType((*CancelJobRequest)(nil), "blockchain.CancelJobRequest")
+	proto.RegisterType((*CancelJobResponse)(nil), "blockchain.CancelJobResponse")
+	proto.RegisterType((*GetMinerLoadRequest)(nil), "blockchain.GetMinerLoadRequest")
+	proto.RegisterType((*GetMinerLoadResponse)(nil), "blockchain.GetMinerLoadResponse")
+	proto.RegisterType((*MineShareRequest)(nil), "blockchain.MineShareRequest")
+	proto.RegisterType((*MineShareResponse)(nil), "blockchain.MineShareResponse")
+	proto.RegisterType((*GetBlockByTransactionRequest)(nil), "blockchain.GetBlockByTransactionRequest")
+	proto.RegisterType((*GetBlockByTransactionResponse)(nil), "blockchain.GetBlockByTransactionResponse")
+	proto.RegisterType((*GetBlockByHeightRequest)(nil), "blockchain.GetBlockByHeightRequest")
+	proto.RegisterType((*GetBlockByHeightResponse)(nil), "blockchain.GetBlockByHeightResponse")
+	proto.RegisterType((*GetBlocksByTimeRequest)(nil), "blockchain.GetBlocksByTimeRequest")
+	proto.RegisterType((*GetBlocksByTimeResponse)(nil), "blockchain.GetBlocksByTimeResponse")
+}
+
+func init() { proto.RegisterFile("miner.proto", fileDescriptor_6e7fcaacee94c057) }
+
+var fileDescriptor_6e7fcaacee94c057 = []byte{
+	// 2136 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x19, 0x5d, 0x73, 0xdc, 0xb6,
+	0x71, 0x78, 0x1f, 0xb2, 0x6e, 0xef, 0x24, 0x4b, 0xb0, 0x64, 0xd1, 0xb4, 0x6c, 0x9f, 0xe9, 0x2f,
+	0xa5, 0x4e, 0x35, 0x1e, 0x27, 0x9d, 0x34, 0x6d, 0x93, 0x36, 0x52, 0x62, 0xc9, 0x4e, 0xe4, 0xba,
+	0x94, 0xa6, 0x9e, 0x7e, 0xcd, 0x84, 0x22, 0x71, 0x3a, 0x56, 0x77, 0xc4, 0x05, 0xc4, 0x29, 0xb9,
+	0xe9, 0x3f, 0xe8, 0x4c, 0x9e, 0xfa, 0x9e, 0xbe, 0xf5, 0xcf, 0xf4, 0xa5, 0x3f, 0xa3, 0x3f, 0xa3,
+	0x03, 0x10, 0x20, 0x01, 0x7e, 0xdc, 0xa9, 0x72, 0xde, 0xb8, 0x8b, 0xc5, 0xee, 0x62, 0x77, 0xb1,
+	0xd8, 0x5d, 0x42, 0x77, 0x1c, 0xc5, 0x98, 0xee, 0x4e, 0x28, 0x61, 0x04, 0xc1, 0xe9, 0x88, 0x04,
+	0xe7, 0xc1, 0xd0, 0x8f, 0x62, 0xf7, 0x1f, 0x0d, 0x40, 0x27, 0xd4, 0x8f, 0x13, 0x3f, 0x60, 0x11,
+	0x89, 0x8f, 0x70, 0x92, 0xf8, 0x67, 0x18, 0xad, 0x42, 0x23, 0x0a, 0x6d, 0xab, 0x6f, 0xed, 0xf4,
+	0xbc, 0x46, 0x14, 0x22, 0x04, 0x2d, 0x36, 0x9b, 0x60, 0xbb, 0xd1, 0xb7, 0x76, 0x3a, 0x9e, 0xf8,
+	0x46, 0xdb, 0xd0, 0x61, 0xd1, 0x18, 0x27, 0xcc, 0x1f, 0x4f, 0xec, 0x66, 0xdf, 0xda, 0x69, 0x7a,
+	0x39, 0x02, 0xf5, 0xa1, 0x1b, 0x90, 0x98, 0xe1, 0x98, 0x9d, 0xf0, 0x8d, 0x2d, 0xb1, 0x51, 0x47,
+	0xa1, 0x43, 0x58, 0x1e, 0x63, 0xe6, 0x87, 0x3e, 0xf3, 0xed, 0x76, 0xbf, 0xb9, 0xd3, 0x7d, 0xfe,
+	0xfe, 0x6e, 0xae, 0xd9, 0x6e, 0x59, 0xab, 0xdd, 0x23, 0x49, 0xfe, 0x45, 0xcc, 0xe8, 0xcc, 0xcb,
+	0x76, 0x73, 0xed, 0x04, 0x97, 0x25, 0xa1, 0xaf, 0xf8, 0x76, 0x7e, 0x09, 0x2b, 0x06, 0x39, 0x5a,
+	0x83, 0xe6, 0x39, 0x9e, 0x89, 0x33, 0x75, 0x3c, 0xfe, 0x89, 0x36, 0xa0, 0x7d, 0xe1, 0x8f, 0xa6,
+	0xea, 0x54, 0x29, 0xf0, 0x8b, 0xc6, 0xcf, 0x2d, 0xf7, 0x5f, 0x4d, 0xe8, 0xed, 0x71, 0x55, 0x94,
+	0x3d, 0x6c, 0xb8, 0x76, 0x81, 0x69, 0x12, 0x91, 0x58, 0x30, 0x68, 0x7a, 0x0a, 0x44, 0x0e, 0x2c,
+	0x4f, 0x28, 0xbe, 0x38, 0xf4, 0x93, 0xa1, 0xe0, 0xd3, 0xf3, 0x32, 0x98, 0xeb, 0x35, 0xe4, 0xf8,
+	0x66, 0xaa, 0x17, 0xff, 0x46, 0x77, 0x01, 0xc6, 0x98, 0x9e, 0x8f, 0xb0, 0x47, 0x08, 0x13, 0x66,
+	0xe9, 0x79, 0x1a, 0xc6, 0xb4, 0x6a, 0xbb, 0x68, 0xd5, 0x9b, 0xb0, 0x34, 0xc4, 0xd1, 0xd9, 0x90,
+	0x89, 0xb3, 0x36, 0x3d, 0x09, 0xf1, 0xa3, 0xc4, 0x24, 0x0e, 0xb0, 0x7d, 0x4d, 0xa0, 0x53, 0x80,
+	0xf3, 0x4a, 0xa2, 0xb3, 0xd8, 0x67, 0x53, 0x8a, 0xed, 0x65, 0x21, 0x2a, 0x47, 0xf0, 0x3d, 0x8c,
+	0x9c, 0xe3, 0xd8, 0xee, 0x88, 0x95, 0x14, 0xe0, 0x7b, 0x26, 0xd3, 0xd3, 0x51, 0x14, 0x7c, 0x89,
+	0x67, 0x36, 0xa4, 0x7b, 0x32, 0x04, 0xda, 0x83, 0x1e, 0xcb, 0xfd, 0x92, 0xd8, 0x5d, 0xe1, 0xb7,
+	0xbb, 0xf3, 0xfd, 0xe6, 0x19, 0x7b, 0xb8, 0x05, 0xc2, 0x68, 0x30, 0x88, 0x82, 0xe9, 0x88, 0xcd,
+	0xec, 0x9e, 0x50, 0x58, 0xc3, 0xf0, 0x33, 0x26, 0xd8, 0x1f, 0x61, 0x6a, 0xaf, 0x08, 0xf1, 0x12,
+	0xe2, 0xd6, 0xe4, 0x5f, 0xf6, 0x6a, 0x6a, 0x4d, 0xfe, 0xed, 0x1e, 0x02, 0x3a, 0xc6, 0x71, 0xf8,
+	0x59, 0x18, 0x52, 0x9c, 0x24, 0x1e, 0xfe, 0x66, 0x8a, 0x13, 0xc6, 0x29, 0xfd, 0x30, 0xa4, 0xd2,
+	0xd7, 0xe2, 0x9b, 0x4b, 0x9d, 0xf8, 0xd4, 0x1f, 0x27, 0x9a, 0xa7, 0x34, 0x8c, 0xfb, 0x07, 0xb8,
+	0x61, 0x70, 0x4a, 0x26, 0x24, 0x4e, 0x30, 0x72, 0xa1, 0x47, 0xe5, 0xf7, 0x09, 0xfe, 0x8e, 0x49,
+	0x96, 0x06, 0x8e, 0xb3, 0x4e, 0x98, 0xcf, 0xa6, 0xc9, 0x3e, 0x09, 0xd3, 0x60, 0x6a, 0x79, 0x1a,
+	0xc6, 0xbd, 0x01, 0xeb, 0x07, 0x98, 0x99, 0x3a, 0xba, 0xbb, 0x80, 0x74, 0xa4, 0x14, 0x67, 0xc3,
+	0x35, 0x3f, 0x45, 0x49, 0x49, 0x0a, 0x74, 0x9f, 0xc2, 0xfa, 0x8b, 0xe9, 0x68, 0x74, 0x28, 0xfc,
+	0xad, 0x0e, 0x9a, 0x87, 0x83, 0xa5, 0x87, 0x83, 0xfb, 0x3e, 0x20, 0x9d, 0x58, 0x32, 0xaf, 0xa3,
+	0xde, 0x84, 0x1b, 0x07, 0x98, 0xf1, 0x0d, 0xfb, 0xdc, 0x83, 0x4a, 0xc3, 0x4f, 0x61, 0xc3, 0x44,
+	0x4b, 0x36, 0xda, 0x45, 0xea, 0x55, 0x5c, 0xa4, 0x9e, 0xbc, 0x48, 0xee, 0x01, 0x6c, 0xbe, 0xa1,
+	0x64, 0xe2, 0x9f, 0xf9, 0x0c, 0x8b, 0xcb, 0xa4, 0xb4, 0xde, 0x85, 0xb6, 0x88, 0x17, 0xc1, 0xa2,
+	0xfb, 0xdc, 0xd6, 0xa3, 0x47, 0xbf, 0x75, 0x5e, 0x4a, 0xe6, 0xee, 0xc0, 0xcd, 0x22, 0x23, 0xa9,
+	0xca, 0x2a, 0x34, 0x48, 0xca, 0x66, 0xd9, 0x6b, 0x90, 0x73, 0xf7, 0x05, 0xf4, 0x4e, 0x78, 0x14,
+	0x2b, 0x49, 0x0e, 0x2c, 0x4f, 0x13, 0x4c, 0x63, 0x7f, 0x8c, 0xa5, 0x3d, 0x33, 0x58, 0x5c, 0x5c,
+	0x3f, 0x49, 0xbe, 0x25, 0x34, 0x94, 0x09, 0x20, 0x83, 0xdd, 0x47, 0xb0, 0x22, 0xf9, 0x48, 0x41,
+	0xd9, 0x5d, 0xb1, 0xb4, 0xbb, 0xe2, 0xae, 0x40, 0xf7, 0x4d, 0x14, 0x9f, 0x29, 0x83, 0xad, 0x42,
+	0x2f, 0x05, 0xd3, 0x4d, 0x9c, 0x8b, 0xe9, 0xae, 0x6a, 0x2e, 0x3b, 0xb0, 0x7a, 0x49, 0x47, 0x3d,
+	0x81, 0xeb, 0x07, 0x98, 0xe9, 0x4e, 0xaa, 0x61, 0xb9, 0x07, 0x6b, 0x39, 0xa1, 0x64, 0xfa, 0xff,
+	0x5a, 0xfd, 0x13, 0xe8, 0x1e, 0x45, 0x31, 0xbe, 0xaa, 0xd3, 0x3e, 0x85, 0x5e, 0xba, 0xfd, 0xea,
+	0xe2, 0x4f, 0x70, 0xc2, 0xde, 0x41, 0x7c, 0xba, 0xfd, 0x8a, 0xe2, 0xbf, 0x06, 0xe7, 0x00, 0x33,
+	0x2d, 0x97, 0xbd, 0xa1, 0x84, 0x0c, 0x94, 0x36, 0xdb, 0xd0, 0x11, 0x64, 0x22, 0x97, 0xa4, 0x96,
+	0xcf, 0x11, 0xe8, 0x21, 0xac, 0x68, 0x09, 0xef, 0x65, 0x28, 0xaf, 0x85, 0x89, 0x74, 0x3f, 0x80,
+	0x8e, 0xe0, 0x79, 0xcc, 0xf0, 0x24, 0x7b, 0x29, 0x2c, 0xed, 0xa5, 0x40, 0xd0, 0x1a, 0xe1, 0x01,
+	0x13, 0xbb, 0x97, 0x3d, 0xf1, 0xed, 0xfe, 0xd7, 0x82, 0xdb, 0x95, 0x7a, 0xc9, 0x63, 0x3e, 0xe3,
+	0x91, 0xe3, 0x87, 0x98, 0x2e, 0x3c, 0xa7, 0xa4, 0x43, 0xbf, 0x81, 0xae, 0xa6, 0x97, 0x10, 0xb6,
+	0x38, 0xa1, 0xeb, 0x5b, 0x78, 0x08, 0x46, 0x71, 0x88, 0xbf, 0x93, 0x35, 0x40, 0x0a, 0xa0, 0xf7,
+	0xa0, 0x35, 0xf1, 0xd9, 0xd0, 0x6e, 0x89, 0x17, 0x62, 0x53, 0x67, 0x98, 0x1d, 0xdb, 0x13, 0x24,
+	0x9c, 0x41, 0x40, 0xa6, 0x31, 0x93, 0xcf, 0x5d, 0x0a, 0xb8, 0x5b, 0xb0, 0xa9, 0x62, 0xf8, 0x8d,
+	0x48, 0xd3, 0xea, 0x9a, 0x3d, 0x83, 0x9b, 0xc5, 0x85, 0xfc, 0xde, 0xa4, 0x19, 0x5d, 0xda, 0x51,
+	0x42, 0xee, 0xbf, 0x2d, 0xb8, 0xfe, 0xc5, 0x45, 0x14, 0xe2, 0x38, 0xc0, 0xea, 0x45, 0xdf, 0x85,
+	0xf6, 0x20, 0xa2, 0x09, 0x5b, 0x1c, 0x10, 0x82, 0x8c, 0x5b, 0x36, 0xc1, 0x01, 0x89, 0x43, 0xbb,
+	0xb1, 0x60, 0x83, 0xa4, 0xe3, 0x09, 0x86, 0xe2, 0x09, 0xa1, 0x0c, 0x53, 0x59, 0x01, 0x64, 0xb0,
+	0xf9, 0xca, 0xb7, 0x8a, 0xaf, 0xbc, 0xf1, 0x6e, 0xb7, 0x0b, 0xef, 0xb6, 0x7b, 0x0c, 0x76, 0x96,
+	0x0e, 0xd5, 0xa9, 0x54, 0x60, 0x7e, 0x04, 0xcb, 0x58, 0xa2, 0xe4, 0xc1, 0x6e, 0xeb, 0x7a, 0x16,
+	0x8c, 0xe0, 0x65, 0xc4, 0xee, 0x53, 0xb8, 0x55, 0xc1, 0xb4, 0x26, 0xcd, 0xfe, 0x44, 0xbc, 0x5d,
+	0x45, 0xd9, 0xd5, 0xa9, 0xe8, 0x35, 0xdc, 0x30, 0x68, 0x25, 0x4b, 0x53, 0xd1, 0xe6, 0xe5, 0x15,
+	0xfd, 0x1c, 0x36, 0xf6, 0x87, 0x38, 0x38, 0x9f, 0x90, 0x28, 0x66, 0x27, 0xd1, 0x44, 0xab, 0xd0,
+	0xf4, 0x97, 0xb3, 0x97, 0xbd, 0x9c, 0xd9, 0xdd, 0x6a, 0xe4, 0x77, 0xcb, 0xfd, 0x67, 0x03, 0xd6,
+	0x73, 0x36, 0x8a, 0x47, 0x4d, 0xde, 0x9d, 0x5b, 0xe3, 0xcd, 0xaf, 0x82, 0x1d, 0x58, 0x66, 0xd1,
+	0x24, 0xd1, 0x6a, 0xbd, 0x0c, 0x96, 0x6b, 0xfb, 0x5a, 0xe4, 0x67, 0x30, 0xfa, 0x10, 0x5a, 0x9c,
+	0xce, 0x5e, 0x12, 0xa6, 0xe9, 0xeb, 0xa6, 0xa9, 0x3a, 0xbd, 0x27, 0xa8, 0xb9, 0x0d, 0x02, 0x8a,
+	0x7d, 0x46, 0xa8, 0xa8, 0x03, 0x7b, 0x9e, 0x02, 0x17, 0x54, 0x82, 0xca, 0x42, 0x1d, 0xcd, 0x42,
+	0x7f, 0x02, 0x27, 0x0b, 0x88, 0x5c, 0xa4, 0xf2, 0xf5, 0x27, 0x00, 0x41, 0x86, 0x94, 0x91, 0x76,
+	0xa7, 0x5a, 0x4b, 0xa5, 0xa2, 0xb6, 0xc1, 0xfd, 0x29, 0xdc, 0xae, 0x64, 0x5e, 0x13, 0x6f, 0x2f,
+	0xe1, 0x96, 0xb8, 0xf1, 0x8a, 0xd0, 0xc8, 0xc5, 0x75, 0x4e, 0xcb, 0xc2, 0xb1, 0xa1, 0x87, 0xe3,
+	0x7f, 0x2c, 0x70, 0xaa, 0x78, 0x49, 0xc9, 0xef, 0x76, 0x2e, 0xf4, 0x1c, 0x9a, 0x2c, 0x9a, 0xc8,
+	0x0c, 0xb1, 0xd8, 0x6b, 0x9c, 0xf8, 0x9d, 0xd3, 0xa7, 0xfb, 0xbd, 0x05, 0x9b, 0x39, 0xfb, 0xdf,
+	0x13, 0x86, 0x17, 0xc5, 0xf3, 0x63, 0x58, 0xcd, 0x95, 0xd6, 0xa2, 0xba, 0x80, 0xe5, 0xfb, 0x79,
+	0x90, 0x64, 0xf9, 0x4b, 0x42, 0x66, 0x34, 0xb5, 0x8a, 0xf9, 0xe9, 0x08, 0x36, 0x32, 0xe7, 0x72,
+	0x6d, 0x94, 0xa3, 0x7e, 0x06, 0xad, 0x0b, 0xc2, 0x54, 0x5e, 0xba, 0x5f, 0x6d, 0x1d, 0x4d, 0x7d,
+	0x4f, 0x90, 0xbb, 0x4f, 0xb4, 0x32, 0x32, 0x65, 0x57, 0x13, 0x25, 0x1b, 0x22, 0x2b, 0xbd, 0x88,
+	0x62, 0x7f, 0x14, 0xb1, 0x99, 0x7a, 0x2d, 0xbe, 0xb7, 0xe0, 0x86, 0x81, 0xfe, 0x71, 0x3c, 0xfd,
+	0x11, 0xb4, 0xb9, 0x76, 0x89, 0xdd, 0xe8, 0x37, 0x2f, 0x77, 0x9a, 0x94, 0xde, 0xfd, 0xc1, 0x82,
+	0xb5, 0x57, 0xe4, 0xf4, 0x58, 0xb4, 0x07, 0xca, 0x51, 0x1b, 0xd0, 0xfe, 0x2b, 0x39, 0x7d, 0xa9,
+	0x3a, 0xee, 0x14, 0xe0, 0xd8, 0x84, 0xf9, 0x2c, 0xeb, 0x4f, 0x05, 0x90, 0x57, 0x32, 0xcd, 0x4b,
+	0x55, 0x32, 0x9c, 0x0b, 0xa6, 0x94, 0x50, 0xd9, 0x82, 0xa7, 0x00, 0x4f, 0x15, 0xd3, 0x49, 0xe8,
+	0x33, 0x1c, 0xca, 0xdc, 0xa3, 0x40, 0xf7, 0x10, 0x6e, 0x1e, 0x4f, 0x4f, 0xc7, 0x11, 0x3b, 0x8a,
+	0xe2, 0x28, 0x3e, 0x7b, 0x45, 0x4e, 0xaf, 0x5a, 0x83, 0xfd, 0x0d, 0xb6, 0x4a, 0x9c, 0xf2, 0x7a,
+	0xba, 0xe2, 0xc0, 0x08, 0x5a, 0xa7, 0xd3, 0x64, 0xa6, 0x2a, 0x1e, 0xfe, 0xcd, 0x9b, 0x2b, 0x8a,
+	0x19, 0x9d, 0x7d, 0x36, 0x50, 0xef, 0x68, 0xd3, 0xd3, 0x30, 0x3c, 0x46, 0xbf, 0x99, 0xe2, 0x29,
+	0x0e, 0xe5, 0x33, 0x2a, 0x21, 0xf7, 0xa9, 0x70, 0x7b, 0x66, 0x69, 0xed, 0x91, 0x2a, 0x0b, 0x76,
+	0xbf, 0x82, 0x0d, 0x93, 0x58, 0xaa, 0xf9, 0x21, 0x2c, 0xa5, 0x7d, 0x9c, 0x3c, 0xf2, 0xb6, 0x7e,
+	0xe4, 0xa2, 0x17, 0x3d, 0x49, 0xcb, 0xcb, 0xf4, 0xb7, 0x3e, 0x0b, 0x86, 0x9a, 0xe9, 0xaa, 0xc5,
+	0x1e, 0xc2, 0x5a, 0x4e, 0xf8, 0x4e, 0x22, 0x77, 0x60, 0x6d, 0xdf, 0x8f, 0x03, 0x3c, 0x5a, 0x28,
+	0xf3, 0x01, 0xac, 0x6b, 0x94, 0x35, 0x57, 0x29, 0xed, 0x08, 0x79, 0xfd, 0x4e, 0xbf, 0x22, 0x7e,
+	0xa8, 0xee, 0xd2, 0x0f, 0x16, 0x6c, 0x98, 0xf8, 0xbc, 0xf0, 0x92, 0x4e, 0xb0, 0x74, 0x27, 0xf0,
+	0x28, 0xa3, 0xd3, 0x98, 0x3b, 0x5f, 0xf8, 0xb4, 0xe9, 0x29, 0x90, 0xaf, 0x7c, 0x4b, 0xe8, 0x39,
+	0xa6, 0x89, 0xf4, 0xa9, 0x02, 0xf9, 0xb3, 0x18, 0xf8, 0x13, 0x3f, 0x88, 0xd8, 0x4c, 0xba, 0x34,
+	0x83, 0x0b, 0xc1, 0xd0, 0x2e, 0x06, 0x83, 0x1b, 0xc3, 0x1a, 0x57, 0xee, 0x78, 0xe8, 0xd3, 0xab,
+	0x36, 0x2e, 0xf2, 0xd6, 0x51, 0x26, 0x35, 0x4e, 0x81, 0xbc, 0x46, 0x6d, 0xea, 0x35, 0xea, 0x31,
+	0xac, 0x6b, 0xf2, 0xf2, 0xd8, 0x1e, 0x90, 0x69, 0x1c, 0x4a, 0x7b, 0xa6, 0x40, 0x3e, 0xa1, 0x69,
+	0xe8, 0x13, 0x9a, 0x8a, 0x09, 0x91, 0xfb, 0x39, 0x6c, 0x1f, 0x60, 0x26, 0x54, 0xdb, 0x9b, 0x69,
+	0xc5, 0xb7, 0x3a, 0x50, 0xa9, 0xbd, 0xb0, 0xaa, 0xda, 0x8b, 0xdf, 0xc2, 0x9d, 0x1a, 0x2e, 0x57,
+	0xec, 0x88, 0x0e, 0x60, 0x2b, 0x67, 0x78, 0x89, 0xbe, 0x56, 0x7b, 0x7d, 0x1a, 0x46, 0x17, 0xfb,
+	0x0a, 0xec, 0x32, 0xa3, 0x2b, 0x2a, 0xe5, 0x89, 0x5e, 0x40, 0xac, 0x24, 0x7b, 0xb3, 0x93, 0x68,
+	0x3c, 0xbf, 0x1a, 0xe5, 0xf6, 0x1e, 0x50, 0x32, 0x96, 0x1a, 0x89, 0x6f, 0x1e, 0xfc, 0x8c, 0x48,
+	0xbf, 0x36, 0x18, 0x71, 0x5f, 0xc2, 0x56, 0x89, 0xe7, 0xd5, 0xd4, 0x7b, 0xfe, 0x77, 0x04, 0x6d,
+	0x71, 0x5b, 0xd0, 0x6b, 0xe8, 0x6a, 0xe3, 0x25, 0x64, 0x34, 0x58, 0xe5, 0x09, 0x96, 0x73, 0xaf,
+	0x76, 0x5d, 0x6a, 0x72, 0x04, 0x90, 0x8f, 0x8f, 0x90, 0xf1, 0x70, 0x95, 0x66, 0x4d, 0xce, 0xdd,
+	0xba, 0xe5, 0x94, 0xd9, 0x33, 0x0b, 0x7d, 0x09, 0x90, 0x0f, 0x8c, 0x4c, 0x76, 0xa5, 0xa9, 0x93,
+	0x73, 0xb7, 0x6e, 0x59, 0xea, 0xf6, 0x6b, 0x58, 0x92, 0x8c, 0x6e, 0xe9, 0x94, 0x26, 0x13, 0xa7,
+	0x6a, 0x49, 0x32, 0x38, 0x86, 0x9e, 0x3e, 0x79, 0x42, 0xf7, 0x0a, 0xfa, 0x17, 0x47, 0x55, 0x4e,
+	0xbf, 0x9e, 0x20, 0x3b, 0xe2, 0x5b, 0x58, 0x35, 0xa7, 0x48, 0xe8, 0x7e, 0xa1, 0xaa, 0x2a, 0x8f,
+	0xaa, 0x1c, 0x77, 0x1e, 0x89, 0xd4, 0xf6, 0x57, 0xd0, 0x16, 0xc3, 0x22, 0x64, 0x84, 0x83, 0x3e,
+	0x87, 0x72, 0x6e, 0x55, 0xac, 0xc8, 0xdd, 0x1f, 0x43, 0x8b, 0x0f, 0x8d, 0xd0, 0x96, 0x21, 0x29,
+	0x9f, 0x2a, 0x39, 0x76, 0x79, 0x41, 0x6e, 0x3d, 0x80, 0x65, 0xd5, 0x08, 0xa3, 0xdb, 0x05, 0x0b,
+	0x18, 0xe6, 0xd9, 0xae, 0x5e, 0xcc, 0x4c, 0xf3, 0x31, 0xb4, 0x78, 0x94, 0x9a, 0x3a, 0x68, 0xc3,
+	0x1f, 0xc7, 0x2e, 0x2f, 0xe4, 0xea, 0xf3, 0x39, 0x8b, 0xb9, 0x55, 0x1b, 0xdc, 0x38, 0x76, 0x79,
+	0x41, 0x6e, 0x1d, 0x88, 0x47, 0xa6, 0x38, 0xca, 0x40, 0x8f, 0x0b, 0xca, 0xd6, 0xcc, 0x60, 0x9c,
+	0x27, 0x0b, 0xe9, 0xa4, 0x9c, 0xb7, 0xb0, 0x6a, 0xce, 0x0b, 0x4c, 0xc7, 0x57, 0x0e, 0x19, 0x1c,
+	0x77, 0x1e, 0x89, 0x64, 0xfc, 0x35, 0xac, 0x97, 0x7a, 0x66, 0xf4, 0xb0, 0x32, 0x62, 0x0a, 0xbd,
+	0xb2, 0xf3, 0x68, 0x01, 0x95, 0x94, 0xf0, 0x1a, 0xba, 0x5a, 0xf3, 0x8c, 0x8a, 0xf7, 0xb8, 0xc8,
+	0xf5, 0x5e, 0xed, 0x7a, 0x6e, 0xf2, 0x8a, 0xbe, 0xcb, 0x34, 0x79, 0x7d, 0xd7, 0xe7, 0x3c, 0x59,
+	0x48, 0x27, 0xe5, 0x04, 0xa2, 0x14, 0x2f, 0x34, 0x59, 0xe8, 0x51, 0xc9, 0xa6, 0x55, 0x0d, 0x9d,
+	0xf3, 0x78, 0x11, 0x99, 0x14, 0x72, 0x02, 0x2b, 0x46, 0x63, 0x80, 0xfa, 0x95, 0xea, 0x69, 0x2d,
+	0x88, 0x73, 0x7f, 0x0e, 0x85, 0x61, 0x72, 0xd5, 0x2e, 0x94, 0x4c, 0x5e, 0x68, 0x2f, 0x9c, 0x7b,
+	0xb5, 0xeb, 0x92, 0xdf, 0x1f, 0xe1, 0x7a, 0xa1, 0x08, 0x46, 0x46, 0x6c, 0x55, 0xd7, 0xda, 0xce,
+	0x83, 0xb9, 0x34, 0x92, 0xf7, 0xef, 0x44, 0x9e, 0xcc, 0x8a, 0xc2, 0x52, 0x9e, 0x2c, 0x56, 0xbf,
+	0x4e, 0xbf, 0x9e, 0x20, 0xcf, 0x29, 0xaa, 0x24, 0x35, 0x73, 0x4a, 0xa1, 0xa2, 0x75, 0xb6, 0xab,
+	0x17, 0xb3, 0x9c, 0x72, 0x08, 0x9d, 0xac, 0xce, 0x44, 0x06, 0x71, 0xb1, 0x50, 0x75, 0xee, 0xd4,
+	0xac, 0x1a, 0xa7, 0xcc, 0x8a, 0xce, 0xd2, 0x29, 0x8b, 0x65, 0xaa, 0xd3, 0xaf, 0x27, 0x90, 0x2c,
+	0x0f, 0xa1, 0x93, 0xd5, 0x6d, 0xa6, 0x72, 0xc5, 0xf2, 0xd1, 0xb9, 0x53, 0xb3, 0x2a, 0x39, 0x8d,
+	0xc4, 0x94, 0xb2, 0x5c, 0x66, 0xa1, 0x9d, 0x82, 0x12, 0xb5, 0xf5, 0x9c, 0xf3, 0xde, 0x25, 0x28,
+	0xa5, 0xb4, 0xbf, 0xc0, 0x5a, 0x4e, 0x20, 0xdf, 0xd8, 0x07, 0xd5, 0xdb, 0xcd, 0xd7, 0xf6, 0xe1,
+	0x7c, 0x22, 0xc9, 0xfe, 0xcf, 0x70, 0x5d, 0xad, 0xc9, 0xca, 0x07, 0xb9, 0x55, 0x1b, 0xcd, 0x52,
+	0xcb, 0x79, 0x30, 0x97, 0x46, 0x45, 0xc4, 0xe9, 0x92, 0xf8, 0xfb, 0xfc, 0xc1, 0xff, 0x06, 0x00,
+	0x2d, 0xb6, 0x8a, 0x68, 0x8c, 0x1e, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// MinerClient is the client API for Miner service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MinerClient interface {
+	SendAddress(ctx context.Context, in *SendAddressRequest, opts ...grpc.CallOption) (*SendAddressResponse, error)
+	GetAddress(ctx context.Context, in *GetAddressRequest, opts ...grpc.CallOption) (Miner_GetAddressClient, error)
+	FullHeight(ctx context.Context, in *FullHeightRequest, opts ...grpc.CallOption) (*FullHeightResponse, error)
+	Height(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*HeightResponse, error)
+	GetFullChain(ctx context.Context, in *GetFullChainRequest, opts ...grpc.CallOption) (Miner_GetFullChainClient, error)
+	PropagateBlock(ctx context.Context, in *PropagateBlockRequest, opts ...grpc.CallOption) (*PropagateBlockResponse, error)
+	Token(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*TokenResponse, error)
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	GetChain(ctx context.Context, in *GetChainRequest, opts ...grpc.CallOption) (Miner_GetChainClient, error)
+	Mine(ctx context.Context, in *MineRequest, opts ...grpc.CallOption) (*MineResponse, error)
+	Test(ctx context.Context, in *TestRequest, opts ...grpc.CallOption) (*TestResponse, error)
+	GetTransactionProof(ctx context.Context, in *GetTransactionProofRequest, opts ...grpc.CallOption) (*GetTransactionProofResponse, error)
+	GetChainParams(ctx context.Context, in *GetChainParamsRequest, opts ...grpc.CallOption) (*GetChainParamsResponse, error)
+	PropagateEvidence(ctx context.Context, in *PropagateEvidenceRequest, opts ...grpc.CallOption) (*PropagateEvidenceResponse, error)
+	GetEvidence(ctx context.Context, in *GetEvidenceRequest, opts ...grpc.CallOption) (*GetEvidenceResponse, error)
+	PropagateCheckpoint(ctx context.Context, in *PropagateCheckpointRequest, opts ...grpc.CallOption) (*PropagateCheckpointResponse, error)
+	GetCheckpointProof(ctx context.Context, in *GetCheckpointProofRequest, opts ...grpc.CallOption) (*GetCheckpointProofResponse, error)
+	PropagateVote(ctx context.Context, in *PropagateVoteRequest, opts ...grpc.CallOption) (*PropagateVoteResponse, error)
+	GetFinality(ctx context.Context, in *GetFinalityRequest, opts ...grpc.CallOption) (*GetFinalityResponse, error)
+	SubmitMiningJob(ctx context.Context, in *SubmitMiningJobRequest, opts ...grpc.CallOption) (*SubmitMiningJobResponse, error)
+	GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*GetJobStatusResponse, error)
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (Miner_WatchJobClient, error)
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	GetMinerLoad(ctx context.Context, in *GetMinerLoadRequest, opts ...grpc.CallOption) (*GetMinerLoadResponse, error)
+	MineShare(ctx context.Context, in *MineShareRequest, opts ...grpc.CallOption) (*MineShareResponse, error)
+	GetBlockByTransaction(ctx context.Context, in *GetBlockByTransactionRequest, opts ...grpc.CallOption) (*GetBlockByTransactionResponse, error)
+	GetBlockByHeight(ctx context.Context, in *GetBlockByHeightRequest, opts ...grpc.CallOption) (*GetBlockByHeightResponse, error)
+	GetBlocksByTime(ctx context.Context, in *GetBlocksByTimeRequest, opts ...grpc.CallOption) (Miner_GetBlocksByTimeClient, error)
+}
+
+type minerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMinerClient(cc grpc.ClientConnInterface) MinerClient {
+	return &minerClient{cc}
+}
+
+func (c *minerClient) SendAddress(ctx context.Context, in *SendAddressRequest, opts ...grpc.CallOption) (*SendAddressResponse, error) {
 	out := new(SendAddressResponse)
 	err := c.cc.Invoke(ctx, "/blockchain.Miner/SendAddress", in, out, opts...)
 	if err != nil {
@@ -984,62 +3267,261 @@ func (c *minerClient) SendAddress(ctx context.Context, in *SendAddressRequest, o
 	return out, nil
 }
 
-func (c *minerClient) GetAddress(ctx context.Context, in *GetAddressRequest, opts ...grpc.CallOption) (Miner_GetAddressClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[0], "/blockchain.Miner/GetAddress", opts...)
+func (c *minerClient) GetAddress(ctx context.Context, in *GetAddressRequest, opts ...grpc.CallOption) (Miner_GetAddressClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[0], "/blockchain.Miner/GetAddress", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerGetAddressClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Miner_GetAddressClient interface {
+	Recv() (*GetAddressResponse, error)
+	grpc.ClientStream
+}
+
+type minerGetAddressClient struct {
+	grpc.ClientStream
+}
+
+func (x *minerGetAddressClient) Recv() (*GetAddressResponse, error) {
+	m := new(GetAddressResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *minerClient) FullHeight(ctx context.Context, in *FullHeightRequest, opts ...grpc.CallOption) (*FullHeightResponse, error) {
+	out := new(FullHeightResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/FullHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) Height(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*HeightResponse, error) {
+	out := new(HeightResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/Height", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) GetFullChain(ctx context.Context, in *GetFullChainRequest, opts ...grpc.CallOption) (Miner_GetFullChainClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[1], "/blockchain.Miner/GetFullChain", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerGetFullChainClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Miner_GetFullChainClient interface {
+	Recv() (*GetFullChainResponse, error)
+	grpc.ClientStream
+}
+
+type minerGetFullChainClient struct {
+	grpc.ClientStream
+}
+
+func (x *minerGetFullChainClient) Recv() (*GetFullChainResponse, error) {
+	m := new(GetFullChainResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *minerClient) PropagateBlock(ctx context.Context, in *PropagateBlockRequest, opts ...grpc.CallOption) (*PropagateBlockResponse, error) {
+	out := new(PropagateBlockResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/PropagateBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) Token(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*TokenResponse, error) {
+	out := new(TokenResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/Token", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) GetChain(ctx context.Context, in *GetChainRequest, opts ...grpc.CallOption) (Miner_GetChainClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[2], "/blockchain.Miner/GetChain", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerGetChainClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Miner_GetChainClient interface {
+	Recv() (*GetChainResponse, error)
+	grpc.ClientStream
+}
+
+type minerGetChainClient struct {
+	grpc.ClientStream
+}
+
+func (x *minerGetChainClient) Recv() (*GetChainResponse, error) {
+	m := new(GetChainResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *minerClient) Mine(ctx context.Context, in *MineRequest, opts ...grpc.CallOption) (*MineResponse, error) {
+	out := new(MineResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/Mine", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) Test(ctx context.Context, in *TestRequest, opts ...grpc.CallOption) (*TestResponse, error) {
+	out := new(TestResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/Test", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) GetTransactionProof(ctx context.Context, in *GetTransactionProofRequest, opts ...grpc.CallOption) (*GetTransactionProofResponse, error) {
+	out := new(GetTransactionProofResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetTransactionProof", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &minerGetAddressClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
+	return out, nil
+}
+
+func (c *minerClient) GetChainParams(ctx context.Context, in *GetChainParamsRequest, opts ...grpc.CallOption) (*GetChainParamsResponse, error) {
+	out := new(GetChainParamsResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetChainParams", in, out, opts...)
+	if err != nil {
 		return nil, err
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
+	return out, nil
+}
+
+func (c *minerClient) PropagateEvidence(ctx context.Context, in *PropagateEvidenceRequest, opts ...grpc.CallOption) (*PropagateEvidenceResponse, error) {
+	out := new(PropagateEvidenceResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/PropagateEvidence", in, out, opts...)
+	if err != nil {
 		return nil, err
 	}
-	return x, nil
+	return out, nil
 }
 
-type Miner_GetAddressClient interface {
-	Recv() (*GetAddressResponse, error)
-	grpc.ClientStream
+func (c *minerClient) GetEvidence(ctx context.Context, in *GetEvidenceRequest, opts ...grpc.CallOption) (*GetEvidenceResponse, error) {
+	out := new(GetEvidenceResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetEvidence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-type minerGetAddressClient struct {
-	grpc.ClientStream
+func (c *minerClient) PropagateCheckpoint(ctx context.Context, in *PropagateCheckpointRequest, opts ...grpc.CallOption) (*PropagateCheckpointResponse, error) {
+	out := new(PropagateCheckpointResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/PropagateCheckpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (x *minerGetAddressClient) Recv() (*GetAddressResponse, error) {
-	m := new(GetAddressResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
+func (c *minerClient) GetCheckpointProof(ctx context.Context, in *GetCheckpointProofRequest, opts ...grpc.CallOption) (*GetCheckpointProofResponse, error) {
+	out := new(GetCheckpointProofResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetCheckpointProof", in, out, opts...)
+	if err != nil {
 		return nil, err
 	}
-	return m, nil
+	return out, nil
 }
 
-func (c *minerClient) FullHeight(ctx context.Context, in *FullHeightRequest, opts ...grpc.CallOption) (*FullHeightResponse, error) {
-	out := new(FullHeightResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/FullHeight", in, out, opts...)
+func (c *minerClient) PropagateVote(ctx context.Context, in *PropagateVoteRequest, opts ...grpc.CallOption) (*PropagateVoteResponse, error) {
+	out := new(PropagateVoteResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/PropagateVote", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *minerClient) Height(ctx context.Context, in *HeightRequest, opts ...grpc.CallOption) (*HeightResponse, error) {
-	out := new(HeightResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/Height", in, out, opts...)
+func (c *minerClient) GetFinality(ctx context.Context, in *GetFinalityRequest, opts ...grpc.CallOption) (*GetFinalityResponse, error) {
+	out := new(GetFinalityResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetFinality", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *minerClient) GetFullChain(ctx context.Context, in *GetFullChainRequest, opts ...grpc.CallOption) (Miner_GetFullChainClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[1], "/blockchain.Miner/GetFullChain", opts...)
+func (c *minerClient) SubmitMiningJob(ctx context.Context, in *SubmitMiningJobRequest, opts ...grpc.CallOption) (*SubmitMiningJobResponse, error) {
+	out := new(SubmitMiningJobResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/SubmitMiningJob", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &minerGetFullChainClient{stream}
+	return out, nil
+}
+
+func (c *minerClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*GetJobStatusResponse, error) {
+	out := new(GetJobStatusResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetJobStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (Miner_WatchJobClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[3], "/blockchain.Miner/WatchJob", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerWatchJobClient{stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -1049,56 +3531,74 @@ func (c *minerClient) GetFullChain(ctx context.Context, in *GetFullChainRequest,
 	return x, nil
 }
 
-type Miner_GetFullChainClient interface {
-	Recv() (*GetFullChainResponse, error)
+type Miner_WatchJobClient interface {
+	Recv() (*WatchJobResponse, error)
 	grpc.ClientStream
 }
 
-type minerGetFullChainClient struct {
+type minerWatchJobClient struct {
 	grpc.ClientStream
 }
 
-func (x *minerGetFullChainClient) Recv() (*GetFullChainResponse, error) {
-	m := new(GetFullChainResponse)
+func (x *minerWatchJobClient) Recv() (*WatchJobResponse, error) {
+	m := new(WatchJobResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func (c *minerClient) PropagateBlock(ctx context.Context, in *PropagateBlockRequest, opts ...grpc.CallOption) (*PropagateBlockResponse, error) {
-	out := new(PropagateBlockResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/PropagateBlock", in, out, opts...)
+func (c *minerClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/CancelJob", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *minerClient) Token(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*TokenResponse, error) {
-	out := new(TokenResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/Token", in, out, opts...)
+func (c *minerClient) GetMinerLoad(ctx context.Context, in *GetMinerLoadRequest, opts ...grpc.CallOption) (*GetMinerLoadResponse, error) {
+	out := new(GetMinerLoadResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetMinerLoad", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *minerClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
-	out := new(PingResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/Ping", in, out, opts...)
+func (c *minerClient) MineShare(ctx context.Context, in *MineShareRequest, opts ...grpc.CallOption) (*MineShareResponse, error) {
+	out := new(MineShareResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/MineShare", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *minerClient) GetChain(ctx context.Context, in *GetChainRequest, opts ...grpc.CallOption) (Miner_GetChainClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[2], "/blockchain.Miner/GetChain", opts...)
+func (c *minerClient) GetBlockByTransaction(ctx context.Context, in *GetBlockByTransactionRequest, opts ...grpc.CallOption) (*GetBlockByTransactionResponse, error) {
+	out := new(GetBlockByTransactionResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetBlockByTransaction", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &minerGetChainClient{stream}
+	return out, nil
+}
+
+func (c *minerClient) GetBlockByHeight(ctx context.Context, in *GetBlockByHeightRequest, opts ...grpc.CallOption) (*GetBlockByHeightResponse, error) {
+	out := new(GetBlockByHeightResponse)
+	err := c.cc.Invoke(ctx, "/blockchain.Miner/GetBlockByHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *minerClient) GetBlocksByTime(ctx context.Context, in *GetBlocksByTimeRequest, opts ...grpc.CallOption) (Miner_GetBlocksByTimeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Miner_serviceDesc.Streams[4], "/blockchain.Miner/GetBlocksByTime", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerGetBlocksByTimeClient{stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -1108,41 +3608,23 @@ func (c *minerClient) GetChain(ctx context.Context, in *GetChainRequest, opts ..
 	return x, nil
 }
 
-type Miner_GetChainClient interface {
-	Recv() (*GetChainResponse, error)
+type Miner_GetBlocksByTimeClient interface {
+	Recv() (*GetBlocksByTimeResponse, error)
 	grpc.ClientStream
 }
 
-type minerGetChainClient struct {
+type minerGetBlocksByTimeClient struct {
 	grpc.ClientStream
 }
 
-func (x *minerGetChainClient) Recv() (*GetChainResponse, error) {
-	m := new(GetChainResponse)
+func (x *minerGetBlocksByTimeClient) Recv() (*GetBlocksByTimeResponse, error) {
+	m := new(GetBlocksByTimeResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func (c *minerClient) Mine(ctx context.Context, in *MineRequest, opts ...grpc.CallOption) (*MineResponse, error) {
-	out := new(MineResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/Mine", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-func (c *minerClient) Test(ctx context.Context, in *TestRequest, opts ...grpc.CallOption) (*TestResponse, error) {
-	out := new(TestResponse)
-	err := c.cc.Invoke(ctx, "/blockchain.Miner/Test", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
 // MinerServer is the server API for Miner service.
 type MinerServer interface {
 	SendAddress(context.Context, *SendAddressRequest) (*SendAddressResponse, error)
@@ -1156,6 +3638,23 @@ type MinerServer interface {
 	GetChain(*GetChainRequest, Miner_GetChainServer) error
 	Mine(context.Context, *MineRequest) (*MineResponse, error)
 	Test(context.Context, *TestRequest) (*TestResponse, error)
+	GetTransactionProof(context.Context, *GetTransactionProofRequest) (*GetTransactionProofResponse, error)
+	GetChainParams(context.Context, *GetChainParamsRequest) (*GetChainParamsResponse, error)
+	PropagateEvidence(context.Context, *PropagateEvidenceRequest) (*PropagateEvidenceResponse, error)
+	GetEvidence(context.Context, *GetEvidenceRequest) (*GetEvidenceResponse, error)
+	PropagateCheckpoint(context.Context, *PropagateCheckpointRequest) (*PropagateCheckpointResponse, error)
+	GetCheckpointProof(context.Context, *GetCheckpointProofRequest) (*GetCheckpointProofResponse, error)
+	PropagateVote(context.Context, *PropagateVoteRequest) (*PropagateVoteResponse, error)
+	GetFinality(context.Context, *GetFinalityRequest) (*GetFinalityResponse, error)
+	SubmitMiningJob(context.Context, *SubmitMiningJobRequest) (*SubmitMiningJobResponse, error)
+	GetJobStatus(context.Context, *GetJobStatusRequest) (*GetJobStatusResponse, error)
+	WatchJob(*WatchJobRequest, Miner_WatchJobServer) error
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	GetMinerLoad(context.Context, *GetMinerLoadRequest) (*GetMinerLoadResponse, error)
+	MineShare(context.Context, *MineShareRequest) (*MineShareResponse, error)
+	GetBlockByTransaction(context.Context, *GetBlockByTransactionRequest) (*GetBlockByTransactionResponse, error)
+	GetBlockByHeight(context.Context, *GetBlockByHeightRequest) (*GetBlockByHeightResponse, error)
+	GetBlocksByTime(*GetBlocksByTimeRequest, Miner_GetBlocksByTimeServer) error
 }
 
 // UnimplementedMinerServer can be embedded to have forward compatible implementations.
@@ -1195,6 +3694,57 @@ func (*UnimplementedMinerServer) Mine(ctx context.Context, req *MineRequest) (*M
 func (*UnimplementedMinerServer) Test(ctx context.Context, req *TestRequest) (*TestResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Test not implemented")
 }
+func (*UnimplementedMinerServer) GetTransactionProof(ctx context.Context, req *GetTransactionProofRequest) (*GetTransactionProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionProof not implemented")
+}
+func (*UnimplementedMinerServer) GetChainParams(ctx context.Context, req *GetChainParamsRequest) (*GetChainParamsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChainParams not implemented")
+}
+func (*UnimplementedMinerServer) PropagateEvidence(ctx context.Context, req *PropagateEvidenceRequest) (*PropagateEvidenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PropagateEvidence not implemented")
+}
+func (*UnimplementedMinerServer) GetEvidence(ctx context.Context, req *GetEvidenceRequest) (*GetEvidenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvidence not implemented")
+}
+func (*UnimplementedMinerServer) PropagateCheckpoint(ctx context.Context, req *PropagateCheckpointRequest) (*PropagateCheckpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PropagateCheckpoint not implemented")
+}
+func (*UnimplementedMinerServer) GetCheckpointProof(ctx context.Context, req *GetCheckpointProofRequest) (*GetCheckpointProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCheckpointProof not implemented")
+}
+func (*UnimplementedMinerServer) PropagateVote(ctx context.Context, req *PropagateVoteRequest) (*PropagateVoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PropagateVote not implemented")
+}
+func (*UnimplementedMinerServer) GetFinality(ctx context.Context, req *GetFinalityRequest) (*GetFinalityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFinality not implemented")
+}
+func (*UnimplementedMinerServer) SubmitMiningJob(ctx context.Context, req *SubmitMiningJobRequest) (*SubmitMiningJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitMiningJob not implemented")
+}
+func (*UnimplementedMinerServer) GetJobStatus(ctx context.Context, req *GetJobStatusRequest) (*GetJobStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJobStatus not implemented")
+}
+func (*UnimplementedMinerServer) WatchJob(req *WatchJobRequest, srv Miner_WatchJobServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (*UnimplementedMinerServer) CancelJob(ctx context.Context, req *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (*UnimplementedMinerServer) GetMinerLoad(ctx context.Context, req *GetMinerLoadRequest) (*GetMinerLoadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMinerLoad not implemented")
+}
+func (*UnimplementedMinerServer) MineShare(ctx context.Context, req *MineShareRequest) (*MineShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MineShare not implemented")
+}
+func (*UnimplementedMinerServer) GetBlockByTransaction(ctx context.Context, req *GetBlockByTransactionRequest) (*GetBlockByTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByTransaction not implemented")
+}
+func (*UnimplementedMinerServer) GetBlockByHeight(ctx context.Context, req *GetBlockByHeightRequest) (*GetBlockByHeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockByHeight not implemented")
+}
+func (*UnimplementedMinerServer) GetBlocksByTime(req *GetBlocksByTimeRequest, srv Miner_GetBlocksByTimeServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetBlocksByTime not implemented")
+}
 
 func RegisterMinerServer(s *grpc.Server, srv MinerServer) {
 	s.RegisterService(&_Miner_serviceDesc, srv)
@@ -1206,207 +3756,519 @@ func _Miner_SendAddress_Handler(srv interface{}, ctx context.Context, dec func(i
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).SendAddress(ctx, in)
+		return srv.(MinerServer).SendAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/SendAddress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).SendAddress(ctx, req.(*SendAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_GetAddress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetAddressRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MinerServer).GetAddress(m, &minerGetAddressServer{stream})
+}
+
+type Miner_GetAddressServer interface {
+	Send(*GetAddressResponse) error
+	grpc.ServerStream
+}
+
+type minerGetAddressServer struct {
+	grpc.ServerStream
+}
+
+func (x *minerGetAddressServer) Send(m *GetAddressResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Miner_FullHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FullHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).FullHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/FullHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).FullHeight(ctx, req.(*FullHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_Height_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).Height(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/Height",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).Height(ctx, req.(*HeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_GetFullChain_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetFullChainRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MinerServer).GetFullChain(m, &minerGetFullChainServer{stream})
+}
+
+type Miner_GetFullChainServer interface {
+	Send(*GetFullChainResponse) error
+	grpc.ServerStream
+}
+
+type minerGetFullChainServer struct {
+	grpc.ServerStream
+}
+
+func (x *minerGetFullChainServer) Send(m *GetFullChainResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Miner_PropagateBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropagateBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).PropagateBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/PropagateBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).PropagateBlock(ctx, req.(*PropagateBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_Token_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).Token(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/Token",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).Token(ctx, req.(*TokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).Ping(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/SendAddress",
+		FullMethod: "/blockchain.Miner/Ping",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).SendAddress(ctx, req.(*SendAddressRequest))
+		return srv.(MinerServer).Ping(ctx, req.(*PingRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_GetAddress_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(GetAddressRequest)
+func _Miner_GetChain_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetChainRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(MinerServer).GetAddress(m, &minerGetAddressServer{stream})
+	return srv.(MinerServer).GetChain(m, &minerGetChainServer{stream})
 }
 
-type Miner_GetAddressServer interface {
-	Send(*GetAddressResponse) error
+type Miner_GetChainServer interface {
+	Send(*GetChainResponse) error
 	grpc.ServerStream
 }
 
-type minerGetAddressServer struct {
+type minerGetChainServer struct {
 	grpc.ServerStream
 }
 
-func (x *minerGetAddressServer) Send(m *GetAddressResponse) error {
+func (x *minerGetChainServer) Send(m *GetChainResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func _Miner_FullHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(FullHeightRequest)
+func _Miner_Mine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MineRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).FullHeight(ctx, in)
+		return srv.(MinerServer).Mine(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/FullHeight",
+		FullMethod: "/blockchain.Miner/Mine",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).FullHeight(ctx, req.(*FullHeightRequest))
+		return srv.(MinerServer).Mine(ctx, req.(*MineRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_Height_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(HeightRequest)
+func _Miner_Test_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).Height(ctx, in)
+		return srv.(MinerServer).Test(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/Height",
+		FullMethod: "/blockchain.Miner/Test",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).Height(ctx, req.(*HeightRequest))
+		return srv.(MinerServer).Test(ctx, req.(*TestRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_GetFullChain_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(GetFullChainRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func _Miner_GetTransactionProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return srv.(MinerServer).GetFullChain(m, &minerGetFullChainServer{stream})
+	if interceptor == nil {
+		return srv.(MinerServer).GetTransactionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetTransactionProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetTransactionProof(ctx, req.(*GetTransactionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type Miner_GetFullChainServer interface {
-	Send(*GetFullChainResponse) error
-	grpc.ServerStream
+func _Miner_GetChainParams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChainParamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetChainParams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetChainParams",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetChainParams(ctx, req.(*GetChainParamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type minerGetFullChainServer struct {
-	grpc.ServerStream
+func _Miner_PropagateEvidence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropagateEvidenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).PropagateEvidence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/PropagateEvidence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).PropagateEvidence(ctx, req.(*PropagateEvidenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (x *minerGetFullChainServer) Send(m *GetFullChainResponse) error {
-	return x.ServerStream.SendMsg(m)
+func _Miner_GetEvidence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEvidenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetEvidence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetEvidence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetEvidence(ctx, req.(*GetEvidenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_PropagateBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PropagateBlockRequest)
+func _Miner_PropagateCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropagateCheckpointRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).PropagateBlock(ctx, in)
+		return srv.(MinerServer).PropagateCheckpoint(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/PropagateBlock",
+		FullMethod: "/blockchain.Miner/PropagateCheckpoint",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).PropagateBlock(ctx, req.(*PropagateBlockRequest))
+		return srv.(MinerServer).PropagateCheckpoint(ctx, req.(*PropagateCheckpointRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_Token_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(TokenRequest)
+func _Miner_GetCheckpointProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCheckpointProofRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).Token(ctx, in)
+		return srv.(MinerServer).GetCheckpointProof(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/Token",
+		FullMethod: "/blockchain.Miner/GetCheckpointProof",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).Token(ctx, req.(*TokenRequest))
+		return srv.(MinerServer).GetCheckpointProof(ctx, req.(*GetCheckpointProofRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PingRequest)
+func _Miner_PropagateVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PropagateVoteRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).Ping(ctx, in)
+		return srv.(MinerServer).PropagateVote(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/Ping",
+		FullMethod: "/blockchain.Miner/PropagateVote",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).Ping(ctx, req.(*PingRequest))
+		return srv.(MinerServer).PropagateVote(ctx, req.(*PropagateVoteRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_GetChain_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(GetChainRequest)
+func _Miner_GetFinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFinalityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetFinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetFinality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetFinality(ctx, req.(*GetFinalityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_SubmitMiningJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitMiningJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).SubmitMiningJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/SubmitMiningJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).SubmitMiningJob(ctx, req.(*SubmitMiningJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetJobStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetJobStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetJobStatus(ctx, req.(*GetJobStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(MinerServer).GetChain(m, &minerGetChainServer{stream})
+	return srv.(MinerServer).WatchJob(m, &minerWatchJobServer{stream})
 }
 
-type Miner_GetChainServer interface {
-	Send(*GetChainResponse) error
+type Miner_WatchJobServer interface {
+	Send(*WatchJobResponse) error
 	grpc.ServerStream
 }
 
-type minerGetChainServer struct {
+type minerWatchJobServer struct {
 	grpc.ServerStream
 }
 
-func (x *minerGetChainServer) Send(m *GetChainResponse) error {
+func (x *minerWatchJobServer) Send(m *WatchJobResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func _Miner_Mine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MineRequest)
+func _Miner_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).Mine(ctx, in)
+		return srv.(MinerServer).CancelJob(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/Mine",
+		FullMethod: "/blockchain.Miner/CancelJob",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).Mine(ctx, req.(*MineRequest))
+		return srv.(MinerServer).CancelJob(ctx, req.(*CancelJobRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Miner_Test_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(TestRequest)
+func _Miner_GetMinerLoad_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMinerLoadRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(MinerServer).Test(ctx, in)
+		return srv.(MinerServer).GetMinerLoad(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/blockchain.Miner/Test",
+		FullMethod: "/blockchain.Miner/GetMinerLoad",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MinerServer).Test(ctx, req.(*TestRequest))
+		return srv.(MinerServer).GetMinerLoad(ctx, req.(*GetMinerLoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_MineShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MineShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).MineShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/MineShare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).MineShare(ctx, req.(*MineShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_GetBlockByTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockByTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetBlockByTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetBlockByTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetBlockByTransaction(ctx, req.(*GetBlockByTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Miner_GetBlockByHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockByHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).GetBlockByHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/blockchain.Miner/GetBlockByHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).GetBlockByHeight(ctx, req.(*GetBlockByHeightRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Miner_GetBlocksByTime_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetBlocksByTimeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MinerServer).GetBlocksByTime(m, &minerGetBlocksByTimeServer{stream})
+}
+
+type Miner_GetBlocksByTimeServer interface {
+	Send(*GetBlocksByTimeResponse) error
+	grpc.ServerStream
+}
+
+type minerGetBlocksByTimeServer struct {
+	grpc.ServerStream
+}
+
+func (x *minerGetBlocksByTimeServer) Send(m *GetBlocksByTimeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Miner_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "blockchain.Miner",
 	HandlerType: (*MinerServer)(nil),
@@ -1443,6 +4305,66 @@ var _Miner_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Test",
 			Handler:    _Miner_Test_Handler,
 		},
+		{
+			MethodName: "GetTransactionProof",
+			Handler:    _Miner_GetTransactionProof_Handler,
+		},
+		{
+			MethodName: "GetChainParams",
+			Handler:    _Miner_GetChainParams_Handler,
+		},
+		{
+			MethodName: "PropagateEvidence",
+			Handler:    _Miner_PropagateEvidence_Handler,
+		},
+		{
+			MethodName: "GetEvidence",
+			Handler:    _Miner_GetEvidence_Handler,
+		},
+		{
+			MethodName: "PropagateCheckpoint",
+			Handler:    _Miner_PropagateCheckpoint_Handler,
+		},
+		{
+			MethodName: "GetCheckpointProof",
+			Handler:    _Miner_GetCheckpointProof_Handler,
+		},
+		{
+			MethodName: "PropagateVote",
+			Handler:    _Miner_PropagateVote_Handler,
+		},
+		{
+			MethodName: "GetFinality",
+			Handler:    _Miner_GetFinality_Handler,
+		},
+		{
+			MethodName: "SubmitMiningJob",
+			Handler:    _Miner_SubmitMiningJob_Handler,
+		},
+		{
+			MethodName: "GetJobStatus",
+			Handler:    _Miner_GetJobStatus_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Miner_CancelJob_Handler,
+		},
+		{
+			MethodName: "GetMinerLoad",
+			Handler:    _Miner_GetMinerLoad_Handler,
+		},
+		{
+			MethodName: "MineShare",
+			Handler:    _Miner_MineShare_Handler,
+		},
+		{
+			MethodName: "GetBlockByTransaction",
+			Handler:    _Miner_GetBlockByTransaction_Handler,
+		},
+		{
+			MethodName: "GetBlockByHeight",
+			Handler:    _Miner_GetBlockByHeight_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1460,6 +4382,16 @@ var _Miner_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Miner_GetChain_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchJob",
+			Handler:       _Miner_WatchJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetBlocksByTime",
+			Handler:       _Miner_GetBlocksByTime_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "miner.proto",
 }
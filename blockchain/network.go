@@ -1,25 +1,40 @@
 package blockchain
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/dgraph-io/badger"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
 )
 
 var (
 	// KnownNodes holds known nodes address
 	KnownNodes = make(map[string]struct{})
-	// ConnectedNodes holds the address of connected nodes
+	// ConnectedNodes holds the address of connected nodes, it is written by
+	// request handlers so it is only accessed with connectedMutex held
 	ConnectedNodes = make(map[string]*grpc.ClientConn)
+	connectedMutex sync.RWMutex
+	// SubmitAttempts is the number of rounds a client tries the miners when
+	// all of them are busy
+	SubmitAttempts = 3
 	// Protocol defination
 	Protocol = "tcp"
 
@@ -29,25 +44,72 @@ var (
 
 const (
 	port = "8000"
+
+	// messageOverhead is the room left for request fields around a block
+	messageOverhead = 64 << 10
 )
 
-// Network structure
+// Network structure, Chain is the local chain that blocks are built on and
+// downloaded to
 type Network struct {
 	Version        int
 	Protocol       string
 	NodeAddress    string
 	KnownNodes     []string
 	ConnectedNodes []string
+	Chain          *BlockChain
 }
 
-// Serve serves
-func (network *Network) Serve(addr string) {
+// knownPeer reports whether the caller of a request is a connected or known
+// node. Nodes dial from another port than they listen on, so only hosts are
+// compared, host names of nodes are resolved.
+func knownPeer(ctx context.Context) bool {
+	caller, ok := peer.FromContext(ctx)
+	if !ok || caller.Addr == nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(caller.Addr.String())
+	if err != nil {
+		return false
+	}
+	callerIP := net.ParseIP(host)
+
+	var nodes []string
+	for addr := range connectedNodes() {
+		nodes = append(nodes, addr)
+	}
+	for addr := range KnownNodes {
+		nodes = append(nodes, addr)
+	}
+	for _, node := range nodes {
+		nodeHost, _, err := net.SplitHostPort(node)
+		if err != nil {
+			continue
+		}
+		if nodeHost == host {
+			return true
+		}
+		ips, err := net.LookupHost(nodeHost)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			if net.ParseIP(ip).Equal(callerIP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Serve serves srv on addr
+func (network *Network) Serve(addr string, srv *Server) {
 	lis, err := net.Listen(Protocol, addr)
 	if err != nil {
 		logrus.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	RegisterMinerServer(s, &Server{})
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(Params.MaxBlockBytes + messageOverhead))
+	RegisterMinerServer(s, srv)
 
 	logrus.Info("Server started : ", addr)
 	if err := s.Serve(lis); err != nil {
@@ -55,38 +117,73 @@ func (network *Network) Serve(addr string) {
 	}
 }
 
-// Connect establish a connection to a grpc server and returns the connection and error
+// Connect establish a connection to a grpc server and returns the connection and error.
+// The connection is refused when the server runs with other chain parameters.
 func (network *Network) Connect(srvAddr string) (*grpc.ClientConn, error) {
 	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return conn, err
+	}
+
+	err = network.CheckChainParams(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
 
-	return conn, err
+// CheckChainParams fetches the chain parameters of a server and compares them with ours
+func (network *Network) CheckChainParams(conn *grpc.ClientConn) error {
+	client := NewMinerClient(conn)
+
+	response, err := client.GetChainParams(context.Background(), &GetChainParamsRequest{})
+	if err != nil {
+		return err
+	}
+	var remote ChainParams
+	err = json.Unmarshal(response.Params, &remote)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(remote.Hash(), Params.Hash()) {
+		logrus.Warnf("%v runs network %q with parameters:\n%s\n", conn.Target(), remote.NetworkID, &remote)
+		return &ChainError{
+			StatusCode: ErrorParamsMismatch,
+			Err:        fmt.Errorf("Chain parameters of %v don't match ours", conn.Target()),
+		}
+	}
+	return nil
 }
 
 // SendAddress sends addr to a server
 func (network *Network) SendAddress(srvAddr string) {
 	conn, err := network.Connect(srvAddr)
 	if err != nil {
+		logrus.Warnf("%v\n", err)
 		return
 	}
 
 	clinet := NewMinerClient(conn)
 
-	response, err := clinet.SendAddress(context.Background(), &SendAddressRequest{Addr: NodeAddress})
+	response, err := clinet.SendAddress(context.Background(), &SendAddressRequest{Addr: NodeAddress, ParamsHash: Params.Hash()})
 	if err != nil {
 		return
 	}
-	if response.StatusCode == 200 {
-		ConnectedNodes[conn.Target()] = conn
-		logrus.Infof("Connected to %v\n", conn.Target())
+	if response.StatusCode != 200 {
+		logrus.Warnf("%v refused connection: %v\n", conn.Target(), response.ResponseText)
+		conn.Close()
+		return
 	}
-	return
+	addConnectedNode(conn)
+	logrus.Infof("Connected to %v\n", conn.Target())
 }
 
 // GetAddress gets addresses from micro services
 func (network *Network) GetAddress(srvAddr string) []string {
 	var addrList []string
 
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+	client := NewMinerClient(connectedNode(srvAddr))
 
 	stream, err := client.GetAddress(context.Background(), &GetAddressRequest{})
 	if err != nil {
@@ -107,7 +204,7 @@ func (network *Network) GetAddress(srvAddr string) []string {
 // DiscoverAndConnect connects to all nodes
 func (network *Network) DiscoverAndConnect() {
 	queue := []string{}
-	for key := range ConnectedNodes {
+	for key := range connectedNodes() {
 		queue = append(queue, key)
 	}
 
@@ -117,7 +214,7 @@ func (network *Network) DiscoverAndConnect() {
 		addrList := network.GetAddress(addr)
 
 		for _, newAddresses := range addrList {
-			if newAddresses != NodeAddress && !contains(ConnectedNodes, newAddresses) {
+			if newAddresses != NodeAddress && connectedNode(newAddresses) == nil {
 				network.SendAddress(newAddresses)
 				queue = append(queue, newAddresses)
 			}
@@ -126,14 +223,14 @@ func (network *Network) DiscoverAndConnect() {
 }
 
 func (network *Network) discoverNodes(srvAddr string) {
-	if !contains(ConnectedNodes, srvAddr) {
+	if connectedNode(srvAddr) == nil {
 		conn, err := network.Connect(srvAddr)
 		if err == nil {
-			ConnectedNodes[conn.Target()] = conn
+			addConnectedNode(conn)
 		}
 	}
 	queue := []string{}
-	for srvAddr := range ConnectedNodes {
+	for srvAddr := range connectedNodes() {
 		queue = append(queue, srvAddr)
 	}
 
@@ -144,13 +241,13 @@ func (network *Network) discoverNodes(srvAddr string) {
 		addrList := network.GetAddress(addr)
 
 		for _, newAddresses := range addrList {
-			if !contains(ConnectedNodes, newAddresses) {
+			if connectedNode(newAddresses) == nil {
 				queue = append(queue, newAddresses)
 				conn, err := network.Connect(newAddresses)
 				if err != nil {
 					continue
 				}
-				ConnectedNodes[conn.Target()] = conn
+				addConnectedNode(conn)
 			}
 		}
 	}
@@ -160,11 +257,11 @@ func (network *Network) discoverNodes(srvAddr string) {
 func (network *Network) DiscoverAndDownload(srvAddr string, token []byte) error {
 	network.discoverNodes(srvAddr)
 	fmt.Println(" --- Discovered nodes")
-	for key := range ConnectedNodes {
+	for key := range connectedNodes() {
 		fmt.Println(key)
 	}
 
-	myHeight, err := Chain.Height(token)
+	myHeight, err := network.Chain.Height(token)
 	if err != nil {
 		return err
 	}
@@ -184,108 +281,474 @@ func (network *Network) DiscoverAndDownload(srvAddr string, token []byte) error
 	return nil
 }
 
-// CreateBlock creates block and send to a miner
-func (network *Network) CreateBlock(srvAddr string, token []byte, transData []string) error {
-	network.discoverNodes(srvAddr)
-	if len(ConnectedNodes) == 0 {
-		return errors.New("Unable to discover at lest one miner node")
+// newBlock builds a block of trans on the tip of the chain of token and
+// signs it with the device key. While a block of the device is pending as a
+// mining job its successor would fork the chain, so none is built.
+func (network *Network) newBlock(token []byte, trans []*Transaction) (*Block, error) {
+	jobID, err := pendingJobFor(token)
+	if err != nil {
+		return nil, err
 	}
-	discoveredNodeListString := []string{}
-	for addr := range ConnectedNodes {
-		discoveredNodeListString = append(discoveredNodeListString, addr)
+	if jobID != nil {
+		return nil, fmt.Errorf("Block of job %x is pending, check back with the job command first", jobID)
 	}
-
-	var trans []*Transaction
-	for _, data := range transData {
-		trans = append(trans, &Transaction{Data: []byte(data)})
+	for _, tx := range trans {
+		if err := tx.Validate(); err != nil {
+			return nil, fmt.Errorf("Transaction %X: %v", tx.ID, err)
+		}
 	}
-	lastHash, err := Chain.LastHash(token)
+	lastHash, err := network.Chain.LastHash(token)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	prevBlock, err := network.Chain.GetBlock(lastHash)
+	if err != nil {
+		return nil, err
 	}
 	key, err := LoadKey(KEYPATH)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	block := Block{
+		Version:      BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Height:       prevBlock.Height + 1,
 		Transactions: trans,
 		Token:        token,
 		PrevHash:     lastHash,
 		PublicKey:    key.PublicKey,
 	}
-	for {
-		logrus.Infoln("Sigining Block")
-		err = block.Sign(key.PrivateKey)
+	block.MerkleRoot = block.HashTransactions()
+	err = network.Chain.Engine.Prepare(network.Chain, &block)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infoln("Sigining Block")
+	err = block.Sign(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if !block.VerifySignature() {
+		return nil, errors.New("Signature verification failed, key file may be corrupted")
+	}
+	logrus.Infoln("Signing Success..")
+
+	err = CheckLimits(&block)
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// discoveredNodes discovers the network through srvAddr and returns the
+// connected miners, least loaded first. Miners that don't report their load
+// come last in random order.
+func (network *Network) discoveredNodes(srvAddr string) ([]string, error) {
+	network.discoverNodes(srvAddr)
+	nodes := connectedNodes()
+	if len(nodes) == 0 {
+		return nil, errors.New("Unable to discover at lest one miner node")
+	}
+	discoveredNodeListString := []string{}
+	for addr := range nodes {
+		discoveredNodeListString = append(discoveredNodeListString, addr)
+	}
+	rand.Shuffle(len(discoveredNodeListString), func(i, j int) {
+		discoveredNodeListString[i], discoveredNodeListString[j] = discoveredNodeListString[j], discoveredNodeListString[i]
+	})
+
+	depths := make(map[string]float64)
+	for _, addr := range discoveredNodeListString {
+		load, err := network.MinerLoad(addr)
 		if err != nil {
-			return err
+			logrus.Warnf("Unable to get the load of %v: %v\n", addr, err)
+			depths[addr] = math.Inf(1)
+			continue
+		}
+		depths[addr] = load.Depth()
+	}
+	sort.SliceStable(discoveredNodeListString, func(i, j int) bool {
+		return depths[discoveredNodeListString[i]] < depths[discoveredNodeListString[j]]
+	})
+	return discoveredNodeListString, nil
+}
+
+// MinerLoad fetches the state of the mining queue of a connected miner
+func (network *Network) MinerLoad(srvAddr string) (MinerLoad, error) {
+	client := NewMinerClient(connectedNode(srvAddr))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := client.GetMinerLoad(ctx, &GetMinerLoadRequest{})
+	if err != nil {
+		return MinerLoad{}, err
+	}
+	if resp.Workers < 1 {
+		return MinerLoad{}, errors.New("Miner reported no workers")
+	}
+	return MinerLoad{
+		Queued:     int(resp.Queued),
+		Running:    int(resp.Running),
+		Workers:    int(resp.Workers),
+		Capacity:   int(resp.Capacity),
+		RetryAfter: time.Duration(resp.RetryAfter) * time.Millisecond,
+	}, nil
+}
+
+// submitJob submits block as a mining job to the first miner in order that
+// accepts it. When every miner is busy it waits for the shortest retry after
+// hint and tries again, up to SubmitAttempts rounds.
+func (network *Network) submitJob(miners []string, block *Block) (string, []byte, error) {
+	for attempt := 1; attempt <= SubmitAttempts; attempt++ {
+		var retryAfter time.Duration
+		for _, selectedAddr := range miners {
+			logrus.Infof("Choosen miner address: %v\n", selectedAddr)
+			client := NewMinerClient(connectedNode(selectedAddr))
+			resp, err := client.SubmitMiningJob(context.Background(), &SubmitMiningJobRequest{Block: block.Proto()})
+			if err != nil {
+				logrus.Errorf("Unable to mine this node: %v\n", err.Error())
+				logrus.Info("Retrying....")
+				continue
+			}
+			if resp.Busy {
+				hint := time.Duration(resp.RetryAfter) * time.Millisecond
+				logrus.Warnf("Miner %v busy with %d queued jobs, retry after %v\n", selectedAddr, resp.Queued, hint)
+				if retryAfter == 0 || hint < retryAfter {
+					retryAfter = hint
+				}
+				continue
+			}
+			logrus.Infof("Submitted mining job %X, %d jobs queued\n", resp.JobId, resp.Queued)
+			return selectedAddr, resp.JobId, nil
 		}
-		if ok := block.VerifySignature(); ok {
-			logrus.Warnln("Sigining verification failed, retrying...")
+		if retryAfter == 0 || attempt == SubmitAttempts {
 			break
 		}
-		logrus.Infoln("Signing Success..")
+		logrus.Infof("All miners busy, retrying after %v\n", retryAfter)
+		time.Sleep(retryAfter)
 	}
+	return "", nil, errors.New("Unable to submit block")
+}
 
-	serializedBlock, err := block.Serialize()
+// CreateBlock creates block and send to a miner. The block is submitted as
+// a mining job and watched until it is mined. Another miner is only tried
+// when submitting fails or the job failed, a job that may still be running
+// is never submitted twice. Until the mined block is added the block is kept
+// as a pending job, see SubmitBlock.
+func (network *Network) CreateBlock(srvAddr string, token []byte, trans []*Transaction) error {
+	miners, err := network.discoveredNodes(srvAddr)
+	if err != nil {
+		return err
+	}
+	block, err := network.newBlock(token, trans)
 	if err != nil {
 		return err
 	}
 
-	try := 1
-	for {
-		if try == 5 {
-			return errors.New("Unable to mine block")
+	// every miner is tried once, under proof of authority only the
+	// authority in turn can seal the block
+	for len(miners) > 0 {
+		selectedAddr, jobID, err := network.submitJob(miners, block)
+		if err != nil {
+			return err
 		}
-		selectedAddr := discoveredNodeListString[rand.Intn(len(discoveredNodeListString))]
-		logrus.Infof("Choosen miner address: %v\n", selectedAddr)
-		err := network.Mine(selectedAddr, serializedBlock)
-		try++
+		err = savePendingJob(jobID, block)
 		if err != nil {
-			logrus.Errorf("Unable to mine this node: %v\n", err.Error())
-			logrus.Info("Retrying....")
-			continue
+			return err
+		}
+
+		var last *JobStatus
+		client := NewMinerClient(connectedNode(selectedAddr))
+		err = watchJob(client, jobID, func(status *JobStatus) error {
+			logrus.Infof("%s\n", status)
+			last = status
+			if status.Block != nil {
+				return errStopWatch
+			}
+			return nil
+		})
+		if err != nil && err != errStopWatch {
+			return fmt.Errorf("Lost mining job %X on %v, check back with the job command: %v", jobID, selectedAddr, err)
+		}
+		if last == nil {
+			return fmt.Errorf("Mining job %X on %v ended without a status, check back with the job command", jobID, selectedAddr)
+		}
+		if last.Block != nil {
+			return network.AddJobBlock(jobID, last.Block)
+		}
+		err = dropPendingJob(jobID)
+		if err != nil {
+			return err
+		}
+
+		logrus.Errorf("Unable to mine this node: %v\n", last.Error)
+		logrus.Info("Retrying....")
+		for i := range miners {
+			if miners[i] == selectedAddr {
+				miners = append(miners[:i:i], miners[i+1:]...)
+				break
+			}
 		}
-		break
 	}
+	return errors.New("Unable to mine block")
+}
 
-	return nil
+// SubmitBlock creates a block and submits it as a mining job to the least
+// loaded miner without waiting for it. It returns the miner and the job ID
+// to check back with. The block is kept under JOBPATH, so the block the
+// miner returns can be checked against it.
+func (network *Network) SubmitBlock(srvAddr string, token []byte, trans []*Transaction) (string, []byte, error) {
+	miners, err := network.discoveredNodes(srvAddr)
+	if err != nil {
+		return "", nil, err
+	}
+	block, err := network.newBlock(token, trans)
+	if err != nil {
+		return "", nil, err
+	}
+	minerAddr, jobID, err := network.submitJob(miners, block)
+	if err != nil {
+		return "", nil, err
+	}
+	err = savePendingJob(jobID, block)
+	if err != nil {
+		return "", nil, err
+	}
+	return minerAddr, jobID, nil
 }
 
-// Mine send mine request to a miner
-func (network *Network) Mine(srvAddr string, block []byte) error {
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+// AddJobBlock checks the block a miner returned for the job with jobID
+// against the block submitted for it, see checkMinedBlock, and adds it to the
+// local chain. The job stops pending once its block is added or rejected.
+func (network *Network) AddJobBlock(jobID []byte, mined *Block) error {
+	submitted, err := loadPendingJob(jobID)
+	if err != nil {
+		return err
+	}
+	err = checkMinedBlock(network.Chain, submitted, mined)
+	if err != nil {
+		dropPendingJob(jobID)
+		return err
+	}
+	logrus.Info("returned block is valid")
+
+	// the block is already here when the chain was synced meanwhile
+	_, err = network.Chain.GetBlock(mined.Hash)
+	if err != nil {
+		err = network.Chain.AddBlock(mined)
+		if err != nil {
+			dropPendingJob(jobID)
+			return err
+		}
+	}
+	fmt.Println("-- Mined Block")
+	fmt.Printf("%s\n", mined)
+	return dropPendingJob(jobID)
+}
+
+// DropJob forgets the block submitted as job jobID, for jobs that failed or
+// were cancelled
+func (network *Network) DropJob(jobID []byte) error {
+	return dropPendingJob(jobID)
+}
+
+// pendingJobPath returns the file the block submitted as job jobID is kept in
+func pendingJobPath(jobID []byte) string {
+	return filepath.Join(JOBPATH, hex.EncodeToString(jobID))
+}
 
-	response, err := client.Mine(context.Background(), &MineRequest{Block: block})
+// savePendingJob keeps block, submitted as job jobID
+func savePendingJob(jobID []byte, block *Block) error {
+	data, err := block.Serialize()
 	if err != nil {
 		return err
 	}
-	deserilizedBlock, err := Deserialize(response.Block)
+	err = os.MkdirAll(JOBPATH, 0755)
 	if err != nil {
 		return err
 	}
+	return ioutil.WriteFile(pendingJobPath(jobID), data, 0644)
+}
 
-	pow := NewProof(deserilizedBlock)
-	validat := pow.Validate()
-	if !validat {
-		logrus.Error("Returned block pow invalid")
-	} else {
-		logrus.Info("returned block is valid")
+// loadPendingJob returns the block submitted as job jobID
+func loadPendingJob(jobID []byte) (*Block, error) {
+	data, err := ioutil.ReadFile(pendingJobPath(jobID))
+	if os.IsNotExist(err) {
+		return nil, &ChainError{
+			StatusCode: ErrorJobNotFound,
+			Err:        fmt.Errorf("Job %X is not pending here", jobID),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	block, err := Deserialize(data)
+	if err != nil {
+		return nil, err
 	}
+	if !bytes.Equal(block.SigningDigest(), jobID) {
+		return nil, fmt.Errorf("Submitted block of job %X is corrupted", jobID)
+	}
+	return block, nil
+}
 
-	err = Chain.AddBlock(deserilizedBlock)
+// dropPendingJob removes the block submitted as job jobID
+func dropPendingJob(jobID []byte) error {
+	err := os.Remove(pendingJobPath(jobID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// pendingJobFor returns the ID of the job a block of token is pending in,
+// nil when there is none. Jobs older than MineTimeout and JobRetention
+// together timed out and were forgotten by their miner, they are dropped.
+// Files that can't be read are skipped.
+func pendingJobFor(token []byte) ([]byte, error) {
+	files, err := ioutil.ReadDir(JOBPATH)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	expired := time.Now().Add(-(MineTimeout + JobRetention))
+	for _, file := range files {
+		jobID, err := hex.DecodeString(file.Name())
+		if err != nil {
+			continue
+		}
+		if file.ModTime().Before(expired) {
+			logrus.Warnf("Dropped pending job %X, it timed out\n", jobID)
+			dropPendingJob(jobID)
+			continue
+		}
+		block, err := loadPendingJob(jobID)
+		if err != nil {
+			logrus.Warnf("Skipped pending job %X: %v\n", jobID, err)
+			continue
+		}
+		if bytes.Equal(block.Token, token) {
+			return jobID, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkMinedBlock checks that mined is submitted with only the seal filled
+// in, the nonce and hash or the sealer and seal, and that both the device
+// signature and the seal verify on chain
+func checkMinedBlock(chain *BlockChain, submitted, mined *Block) error {
+	unsealed := func(block *Block) ([]byte, error) {
+		header := *block
+		header.Nonce = 0
+		header.Hash = nil
+		header.Sealer = nil
+		header.Seal = nil
+		return header.Serialize()
+	}
+	want, err := unsealed(submitted)
 	if err != nil {
 		return err
 	}
-	fmt.Println("-- Mined Block")
-	fmt.Printf("%s\n", deserilizedBlock)
-	return nil
+	got, err := unsealed(mined)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return &ChainError{
+			StatusCode: ErrorBlockTampered,
+			Err:        errors.New("Miner returned a different block than it was given"),
+		}
+	}
+	if !mined.VerifySignature() {
+		return &ChainError{
+			StatusCode: ErrorInvalidSignature,
+			Err:        errors.New("Returned block signature can't be verified"),
+		}
+	}
+	return chain.Engine.Verify(chain, mined)
+}
+
+// watchJob streams the status of a mining job to fn until the job is done or
+// fn returns an error
+func watchJob(client MinerClient, jobID []byte, fn func(*JobStatus) error) error {
+	stream, err := client.WatchJob(context.Background(), &WatchJobRequest{JobId: jobID})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Status == nil {
+			return errors.New("Job status is missing")
+		}
+		err = fn(JobStatusFromProto(resp.Status))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// JobStatus fetches the status of a mining job from a miner
+func (network *Network) JobStatus(srvAddr string, jobID []byte) (*JobStatus, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetJobStatus(context.Background(), &GetJobStatusRequest{JobId: jobID})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, errors.New("Job status is missing")
+	}
+	return JobStatusFromProto(resp.Status), nil
+}
+
+// WatchJob streams the status of a mining job from a miner to fn until the
+// job is done
+func (network *Network) WatchJob(srvAddr string, jobID []byte, fn func(*JobStatus) error) error {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return watchJob(NewMinerClient(conn), jobID, fn)
+}
+
+// CancelJob asks a miner to stop a mining job, it returns false when the
+// block was already mined
+func (network *Network) CancelJob(srvAddr string, jobID []byte) (bool, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.CancelJob(context.Background(), &CancelJobRequest{JobId: jobID})
+	if err != nil {
+		return false, err
+	}
+	return resp.Ok, nil
 }
 
 // GetFullHeight gets full height from a node
 func GetFullHeight(srvAddr string, myHeight int64) (int64, error) {
 
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+	client := NewMinerClient(connectedNode(srvAddr))
 
 	response, err := client.FullHeight(context.Background(), &FullHeightRequest{Height: myHeight})
 	if err != nil {
@@ -298,10 +761,10 @@ func GetFullHeight(srvAddr string, myHeight int64) (int64, error) {
 func (network *Network) FindBestHeightNode() string {
 	var addr string
 
-	myHeight := Chain.FullHeight()
+	myHeight := network.Chain.FullHeight()
 	max := myHeight
 
-	for srvAddr := range ConnectedNodes {
+	for srvAddr := range connectedNodes() {
 		height, err := GetFullHeight(srvAddr, myHeight)
 		if err != nil {
 			logrus.Warnf("Error: %v", err)
@@ -320,7 +783,7 @@ func (network *Network) FindBestHeightNodeByToken(token []byte, myHeight int64)
 	var addr string
 	max := myHeight
 
-	for srvAddr := range ConnectedNodes {
+	for srvAddr := range connectedNodes() {
 		height, err := Getheight(srvAddr, token)
 		if err != nil {
 			logrus.Warnf("Error: %v", err)
@@ -337,7 +800,7 @@ func (network *Network) FindBestHeightNodeByToken(token []byte, myHeight int64)
 // Getheight get heights of a chain
 func Getheight(srvAddr string, token []byte) (int64, error) {
 
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+	client := NewMinerClient(connectedNode(srvAddr))
 	resp, err := client.Height(context.Background(), &HeightRequest{Token: token})
 	if err != nil {
 		return 0, err
@@ -345,35 +808,27 @@ func Getheight(srvAddr string, token []byte) (int64, error) {
 	return resp.Height, nil
 }
 
-// GetFullChain downloads full blockchain from srvAddr node
+// GetFullChain downloads full blockchain from srvAddr node and verifies it
+// record by record, see importChain
 func (network *Network) GetFullChain(srvAddr string) error {
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+	client := NewMinerClient(connectedNode(srvAddr))
 	stream, err := client.GetFullChain(context.Background(), &GetFullChainRequest{})
 	if err != nil {
 		return err
 	}
-
-	for {
+	return network.Chain.importChain(func() ([]byte, []byte, error) {
 		response, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
-		err = Chain.Database.Update(func(txn *badger.Txn) error {
-			txn.Set(response.Key, response.Value)
-			logrus.Info("Db Updating")
-			return nil
-		})
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-	}
-	return nil
+		return response.Key, response.Value, nil
+	})
 }
 
 // GetChain gets chain from server/miner
 func (network *Network) GetChain(srvAddr string, token []byte) error {
 
-	client := NewMinerClient(ConnectedNodes[srvAddr])
+	client := NewMinerClient(connectedNode(srvAddr))
 	stream, err := client.GetChain(context.Background(), &GetChainRequest{Token: token})
 	if err != nil {
 		return err
@@ -387,17 +842,14 @@ func (network *Network) GetChain(srvAddr string, token []byte) error {
 		if err != nil {
 			return err
 		}
-		block, err := Deserialize(resp.Block)
-		if err != nil {
-			return err
-		}
+		block := BlockFromProto(resp.Block)
 		if block.IsGenesis() {
-			err := Chain.AddGenesis(block)
+			err := network.Chain.AddGenesis(block)
 			if err != nil {
 				return err
 			}
 		} else {
-			err := Chain.AddBlock(block)
+			err := network.Chain.AddBlock(block)
 			if err != nil {
 				return err
 			}
@@ -406,15 +858,198 @@ func (network *Network) GetChain(srvAddr string, token []byte) error {
 	return nil
 }
 
-// PropagateBlock propagates a block accross the network
-func (network *Network) PropagateBlock(block []byte, srvAddr string) {
-	client := NewMinerClient(ConnectedNodes[srvAddr])
-	_, err := client.PropagateBlock(context.Background(), &PropagateBlockRequest{Block: block})
+// PropagateBlock propagates a block accross the network, a peer gets
+// PropagateTimeout to take it
+func (network *Network) PropagateBlock(block *BlockMessage, srvAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), PropagateTimeout)
+	defer cancel()
+
+	client := NewMinerClient(connectedNode(srvAddr))
+	_, err := client.PropagateBlock(ctx, &PropagateBlockRequest{Block: block})
+	if err != nil {
+		logrus.Warnf("%v\n", err)
+	}
+}
+
+// PropagateEvidence sends equivocation evidence to a node
+func (network *Network) PropagateEvidence(evidence *EvidenceMessage, srvAddr string) {
+	client := NewMinerClient(connectedNode(srvAddr))
+	_, err := client.PropagateEvidence(context.Background(), &PropagateEvidenceRequest{Evidence: evidence})
+	if err != nil {
+		logrus.Warnf("%v\n", err)
+	}
+}
+
+// GetEvidence fetches the equivocation evidence a miner recorded against the
+// chain of token, or all evidence when token is empty. Evidence that doesn't
+// verify is dropped.
+func (network *Network) GetEvidence(srvAddr string, token []byte) ([]*Evidence, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetEvidence(context.Background(), &GetEvidenceRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	var evidenceList []*Evidence
+	for _, msg := range resp.Evidence {
+		evidence := EvidenceFromProto(msg)
+		if err := evidence.Verify(); err != nil {
+			logrus.Warnf("Dropped invalid evidence: %v\n", err)
+			continue
+		}
+		evidenceList = append(evidenceList, evidence)
+	}
+	return evidenceList, nil
+}
+
+// PropagateCheckpoint sends a checkpoint to a node
+func (network *Network) PropagateCheckpoint(checkpoint *CheckpointMessage, srvAddr string) {
+	client := NewMinerClient(connectedNode(srvAddr))
+	_, err := client.PropagateCheckpoint(context.Background(), &PropagateCheckpointRequest{Checkpoint: checkpoint})
 	if err != nil {
 		logrus.Warnf("%v\n", err)
 	}
 }
 
+// PropagateVote sends a checkpoint vote to a node
+func (network *Network) PropagateVote(vote *CheckpointVoteMessage, srvAddr string) {
+	client := NewMinerClient(connectedNode(srvAddr))
+	_, err := client.PropagateVote(context.Background(), &PropagateVoteRequest{Vote: vote})
+	if err != nil {
+		logrus.Warnf("%v\n", err)
+	}
+}
+
+// GetFinality fetches the last final checkpoint header of a miner with its
+// votes and checks that a quorum of known miners signed it
+func (network *Network) GetFinality(srvAddr string) (*Checkpoint, []*CheckpointVote, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetFinality(context.Background(), &GetFinalityRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Checkpoint == nil {
+		return nil, nil, nil
+	}
+
+	header := CheckpointFromProto(resp.Checkpoint)
+	err = header.VerifyHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	var votes []*CheckpointVote
+	for _, msg := range resp.Votes {
+		votes = append(votes, CheckpointVoteFromProto(msg))
+	}
+	err = VerifyQuorum(header, votes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, votes, nil
+}
+
+// RunCheckpoints creates a checkpoint signed with key whenever the last one
+// is older than Params.CheckpointInterval, and gossips it to connected nodes.
+// Miners check at random offsets so they rarely race for the same height.
+// The last checkpoint is voted for once it is a quarter of the interval old.
+// Nodes whose key may not create checkpoints return at once.
+func (network *Network) RunCheckpoints(key *ecdsa.PrivateKey) {
+	if !Params.CanCheckpoint(EncodePublicKey(&key.PublicKey)) {
+		logrus.Info("Node key is not a listed miner, not creating checkpoints")
+		return
+	}
+	interval := time.Duration(Params.CheckpointInterval) * time.Millisecond
+	for {
+		time.Sleep(interval/2 + time.Duration(rand.Int63n(int64(interval/2)+1)))
+
+		last, err := network.Chain.Checkpoint(-1)
+		if err != nil {
+			logrus.Errorf("Can't load last checkpoint: %v\n", err)
+			continue
+		}
+		if last != nil && time.Since(time.Unix(0, last.Timestamp)) >= interval/4 {
+			// rivals created about the same time have arrived by now, so
+			// miners vote for the checkpoint every node keeps
+			network.Chain.endorseCheckpoint(last)
+		}
+		if last != nil && time.Since(time.Unix(0, last.Timestamp)) < interval {
+			continue
+		}
+
+		checkpoint, err := network.Chain.CreateCheckpoint(key)
+		if err != nil {
+			logrus.Errorf("Can't create checkpoint: %v\n", err)
+			continue
+		}
+		_, err = network.Chain.AddCheckpoint(checkpoint)
+		if err != nil {
+			logrus.Errorf("Can't add checkpoint: %v\n", err)
+			continue
+		}
+		for addr := range connectedNodes() {
+			go network.PropagateCheckpoint(checkpoint.Proto(), addr)
+		}
+	}
+}
+
+// GetCheckpointProof fetches the tip of the device chain of token from the
+// checkpoint at height, the last one when height is negative, and verifies
+// that the checkpoint header commits to it
+func (network *Network) GetCheckpointProof(srvAddr string, token []byte, height int64) (*Checkpoint, CheckpointTip, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, CheckpointTip{}, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetCheckpointProof(context.Background(), &GetCheckpointProofRequest{Height: height, Token: token})
+	if err != nil {
+		return nil, CheckpointTip{}, err
+	}
+	if resp.Checkpoint == nil || resp.Tip == nil {
+		return nil, CheckpointTip{}, errors.New("Checkpoint proof is incomplete")
+	}
+
+	header := CheckpointFromProto(resp.Checkpoint)
+	err = header.VerifyHeader()
+	if err != nil {
+		return nil, CheckpointTip{}, err
+	}
+	if height >= 0 && header.Height != height {
+		return nil, CheckpointTip{}, errors.New("Returned checkpoint is not the requested checkpoint")
+	}
+	address, err := Address(token)
+	if err != nil {
+		return nil, CheckpointTip{}, err
+	}
+	tip := CheckpointTipFromProto(resp.Tip)
+	if !bytes.Equal(tip.Address, address) {
+		return nil, CheckpointTip{}, errors.New("Returned tip is not the requested device chain")
+	}
+
+	var proof MerkleProof
+	for _, step := range resp.Path {
+		proof = append(proof, MerkleProofStep{Hash: step.Hash, Left: step.Left})
+	}
+	if !VerifyTipProof(header, int(resp.Index), tip, proof) {
+		return nil, CheckpointTip{}, errors.New("Tip is not committed to by the checkpoint")
+	}
+	return header, tip, nil
+}
+
 // GetToken gets token from a miner
 func (network *Network) GetToken(username, password, srvAddr string) ([]byte, error) {
 	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
@@ -432,44 +1067,180 @@ func (network *Network) GetToken(username, password, srvAddr string) ([]byte, er
 	return resp.Token, nil
 }
 
-// Printchain prints the chain of an address
-func (network *Network) Printchain(token []byte) error {
-	address, err := Address(token)
+// GetTransactionProof fetches a transaction with its merkle audit path from
+// a miner and verifies it against the returned block header. The header
+// itself must carry a valid hash, seal under engine and signature.
+func (network *Network) GetTransactionProof(srvAddr string, engine Engine, blockHash, txID []byte) (*Block, *Transaction, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetTransactionProof(context.Background(), &GetTransactionProofRequest{BlockHash: blockHash, TransactionId: txID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := BlockFromProto(resp.Header)
+	if !bytes.Equal(header.Hash, blockHash) {
+		return nil, nil, errors.New("Returned header is not the requested block")
+	}
+	err = engine.Verify(nil, header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Returned header has invalid seal: %v", err)
+	}
+	if !header.VerifySignature() {
+		return nil, nil, errors.New("Returned header has invalid signature")
+	}
+
+	tx := TransactionFromProto(resp.Transaction)
+	if !bytes.Equal(tx.ID, txID) {
+		return nil, nil, errors.New("Returned transaction is not the requested transaction")
+	}
+	if err := tx.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var proof MerkleProof
+	for _, step := range resp.Path {
+		proof = append(proof, MerkleProofStep{Hash: step.Hash, Left: step.Left})
+	}
+	if !VerifyProof(header.MerkleRoot, tx.ID, int(resp.Index), int(resp.Count), proof) {
+		return nil, nil, errors.New("Merkle proof doesn't verify against block header")
+	}
+	return header, tx, nil
+}
+
+// verifyQueriedBlock checks a block returned by a lookup on another node
+// against its own seal, signature and merkle root
+func verifyQueriedBlock(engine Engine, block *Block) error {
+	err := checkHeader(block)
 	if err != nil {
 		return err
 	}
-	var lastHash []byte
-	err = Chain.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(address)
+	err = engine.Verify(nil, block)
+	if err != nil {
+		return fmt.Errorf("Returned block has invalid seal: %v", err)
+	}
+	if !block.VerifySignature() {
+		return errors.New("Returned block has invalid signature")
+	}
+	return nil
+}
+
+// GetBlockByTransaction asks srvAddr for the block holding the transaction
+// with txID and verifies it
+func (network *Network) GetBlockByTransaction(srvAddr string, engine Engine, txID []byte) (*Block, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetBlockByTransaction(context.Background(), &GetBlockByTransactionRequest{TransactionId: txID})
+	if err != nil {
+		return nil, err
+	}
+
+	block := BlockFromProto(resp.Block)
+	err = verifyQueriedBlock(engine, block)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := block.TransactionProof(txID); err != nil {
+		return nil, errors.New("Returned block doesn't hold the transaction")
+	}
+	return block, nil
+}
+
+// GetBlockByHeight asks srvAddr for the block at height of the device chain
+// of token and verifies it
+func (network *Network) GetBlockByHeight(srvAddr string, engine Engine, token []byte, height int64) (*Block, error) {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	resp, err := client.GetBlockByHeight(context.Background(), &GetBlockByHeightRequest{Token: token, Height: height})
+	if err != nil {
+		return nil, err
+	}
+
+	block := BlockFromProto(resp.Block)
+	err = verifyQueriedBlock(engine, block)
+	if err != nil {
+		return nil, err
+	}
+	if block.Height != height || !bytes.Equal(block.Token, token) {
+		return nil, errors.New("Returned block is not the requested block")
+	}
+	return block, nil
+}
+
+// GetBlocksByTime asks srvAddr for the blocks of the device chain of token
+// with a timestamp from from to to and calls fn with each verified block
+func (network *Network) GetBlocksByTime(srvAddr string, engine Engine, token []byte, from, to int64, fn func(block *Block) error) error {
+	conn, err := grpc.DialContext(context.Background(), srvAddr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(time.Duration(time.Second*10)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := NewMinerClient(conn)
+	stream, err := client.GetBlocksByTime(context.Background(), &GetBlocksByTimeRequest{Token: token, From: from, To: to})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
-		err = item.Value(func(val []byte) error {
-			lastHash = val
-			return nil
-		})
+		block := BlockFromProto(resp.Block)
+		err = verifyQueriedBlock(engine, block)
 		if err != nil {
 			return err
 		}
-		return nil
-	})
+		if block.Timestamp < from || block.Timestamp > to || !bytes.Equal(block.Token, token) {
+			return errors.New("Returned block is outside the requested range")
+		}
+		err = fn(block)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Printchain prints the chain of an address block by block in direction,
+// starting at height when it isn't negative. A chain that isn't stored
+// locally is reported as ErrKeyNotFound.
+func (network *Network) Printchain(token []byte, direction Direction, height int64) error {
+	itr, err := network.Chain.NewIterator(token, direction)
 	if err != nil {
 		return err
 	}
+	if height >= 0 {
+		itr.SeekHeight(height)
+	}
 
-	itr := Iterator{CurrentHash: lastHash, Database: Chain.Database}
-
-	for {
-		block := itr.Next()
-		if block == nil {
-			break
-		}
-
-		fmt.Printf("%s\n", block)
-
-		if len(block.PrevHash) == 0 {
-			break
-		}
+	printed := 0
+	for itr.Next() {
+		fmt.Printf("%s\n", itr.Block())
+		printed++
+	}
+	if itr.Err() != nil {
+		return itr.Err()
+	}
+	if printed == 0 {
+		return ErrKeyNotFound
 	}
 	return nil
 }
@@ -490,11 +1261,29 @@ func (network *Network) Ping(srvAddr string) bool {
 	return true
 }
 
-func contains(data map[string]*grpc.ClientConn, val string) bool {
-	if _, ok := data[val]; !ok {
-		return false
+// connectedNodes returns a snapshot of ConnectedNodes
+func connectedNodes() map[string]*grpc.ClientConn {
+	connectedMutex.RLock()
+	defer connectedMutex.RUnlock()
+	nodes := make(map[string]*grpc.ClientConn, len(ConnectedNodes))
+	for addr, conn := range ConnectedNodes {
+		nodes[addr] = conn
 	}
-	return true
+	return nodes
+}
+
+// connectedNode returns the connection to addr, nil when it isn't connected
+func connectedNode(addr string) *grpc.ClientConn {
+	connectedMutex.RLock()
+	defer connectedMutex.RUnlock()
+	return ConnectedNodes[addr]
+}
+
+// addConnectedNode adds conn to ConnectedNodes
+func addConnectedNode(conn *grpc.ClientConn) {
+	connectedMutex.Lock()
+	defer connectedMutex.Unlock()
+	ConnectedNodes[conn.Target()] = conn
 }
 
 // Test tests
@@ -515,19 +1304,11 @@ func (network *Network) Test(srvAddr string) {
 		Token:        []byte("token"),
 		PublicKey:    []byte("pubkey"),
 	}
-	_, err = block.Serialize()
+	resp, err := client.Test(context.Background(), &TestRequest{Block: block.Proto()})
 	if err != nil {
 		logrus.Fatal(err)
 	}
-
-	resp, err := client.Test(context.Background(), &TestRequest{})
-	if err != nil {
-		logrus.Fatal(err)
-	}
-	rblock, err := Deserialize(resp.Block)
-	if err != nil {
-		logrus.Panic(err)
-	}
+	rblock := BlockFromProto(resp.Block)
 	pow := NewProof(rblock)
 	validate := pow.Validate()
 
@@ -0,0 +1,233 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// ChainParams holds the consensus parameters every node of a network must share.
+// Difficulty is the difficulty of genesis blocks, later blocks retarget
+// within [MinDifficulty, MaxDifficulty] towards TargetBlockInterval
+// milliseconds per block, see RequiredDifficulty. The miners in Miners, or
+// the Authorities when none are listed, checkpoint all device chain tips
+// every CheckpointInterval milliseconds, a checkpoint is
+// final once FinalityQuorum of the hex encoded public keys in Miners signed
+// it, a zero quorum disables finality. Consensus selects the engine, under
+// proof of authority Authorities lists the hex encoded public keys allowed to
+// seal blocks. A block can't be earlier than the median timestamp of the
+// last MedianTimeBlocks blocks of its chain, nor more than MaxFutureDrift
+// milliseconds ahead of the clock of the node receiving it.
+type ChainParams struct {
+	NetworkID             string   `json:"networkId"`
+	Difficulty            int      `json:"difficulty"`
+	MinDifficulty         int      `json:"minDifficulty"`
+	MaxDifficulty         int      `json:"maxDifficulty"`
+	TargetBlockInterval   int64    `json:"targetBlockInterval"`
+	RetargetWindow        int      `json:"retargetWindow"`
+	MaxBlockBytes         int      `json:"maxBlockBytes"`
+	MaxTransactions       int      `json:"maxTransactions"`
+	MaxTransactionBytes   int      `json:"maxTransactionBytes"`
+	AddressVersion        byte     `json:"addressVersion"`
+	AddressChecksumLength int      `json:"addressChecksumLength"`
+	CheckpointInterval    int64    `json:"checkpointInterval"`
+	Consensus             string   `json:"consensus"`
+	Authorities           []string `json:"authorities,omitempty"`
+	Miners                []string `json:"miners,omitempty"`
+	FinalityQuorum        int      `json:"finalityQuorum"`
+	MedianTimeBlocks      int      `json:"medianTimeBlocks"`
+	MaxFutureDrift        int64    `json:"maxFutureDrift"`
+}
+
+const (
+	// ConsensusProofOfWork selects the proof of work engine
+	ConsensusProofOfWork = "pow"
+	// ConsensusProofOfAuthority selects the proof of authority engine
+	ConsensusProofOfAuthority = "poa"
+)
+
+var (
+	// PARAMSPATH is the path of the chain parameters file
+	PARAMSPATH = "tmp/params.json"
+
+	// Params holds the parameters of the chain this node belongs to
+	Params = DefaultChainParams()
+)
+
+// DefaultChainParams returns the parameters used when no file is present
+func DefaultChainParams() *ChainParams {
+	return &ChainParams{
+		NetworkID:             "iotchain",
+		Difficulty:            12,
+		MinDifficulty:         8,
+		MaxDifficulty:         20,
+		TargetBlockInterval:   5000,
+		RetargetWindow:        16,
+		MaxBlockBytes:         2 << 20,
+		MaxTransactions:       4096,
+		MaxTransactionBytes:   1 << 20,
+		AddressVersion:        0x00,
+		AddressChecksumLength: 4,
+		CheckpointInterval:    600000,
+		Consensus:             ConsensusProofOfWork,
+		MedianTimeBlocks:      11,
+		MaxFutureDrift:        120000,
+	}
+}
+
+// LoadChainParams loads chain parameters from a JSON file, fields missing
+// from the file keep their default value
+func LoadChainParams(paramsPath string) (*ChainParams, error) {
+	if _, err := os.Stat(paramsPath); os.IsNotExist(err) {
+		return nil, errors.New("Chain parameters file does not exist")
+	}
+	fileContent, err := ioutil.ReadFile(paramsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	params := DefaultChainParams()
+	err = json.Unmarshal(fileContent, params)
+	if err != nil {
+		return nil, err
+	}
+	err = params.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// SaveFile saves chain parameters into a JSON file
+func (params *ChainParams) SaveFile(paramsPath string) error {
+	data, err := json.MarshalIndent(params, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(paramsPath, data, 0644)
+}
+
+// Validate checks that the parameters describe a usable chain
+func (params *ChainParams) Validate() error {
+	if params.NetworkID == "" {
+		return errors.New("Network ID is not set")
+	}
+	if params.MinDifficulty < 1 || params.MaxDifficulty > 255 || params.MinDifficulty > params.MaxDifficulty {
+		return fmt.Errorf("Difficulty range [%d, %d] out of range [1, 255]", params.MinDifficulty, params.MaxDifficulty)
+	}
+	if params.Difficulty < params.MinDifficulty || params.Difficulty > params.MaxDifficulty {
+		return fmt.Errorf("Difficulty %d out of range [%d, %d]", params.Difficulty, params.MinDifficulty, params.MaxDifficulty)
+	}
+	if params.TargetBlockInterval <= 0 {
+		return errors.New("Target block interval must be positive")
+	}
+	if params.RetargetWindow < 2 {
+		return fmt.Errorf("Retarget window %d must be at least 2", params.RetargetWindow)
+	}
+	if params.MaxBlockBytes <= 0 || params.MaxTransactions <= 0 || params.MaxTransactionBytes <= 0 {
+		return errors.New("Block limits must be positive")
+	}
+	if params.AddressChecksumLength < 1 || params.AddressChecksumLength > sha256.Size {
+		return fmt.Errorf("Address checksum length %d out of range [1, %d]", params.AddressChecksumLength, sha256.Size)
+	}
+	if params.CheckpointInterval <= 0 {
+		return errors.New("Checkpoint interval must be positive")
+	}
+	if params.MedianTimeBlocks < 1 {
+		return fmt.Errorf("Median time blocks %d must be at least 1", params.MedianTimeBlocks)
+	}
+	if params.MaxFutureDrift < 0 {
+		return errors.New("Max future drift can't be negative")
+	}
+	if params.FinalityQuorum < 0 || params.FinalityQuorum > len(params.Miners) {
+		return fmt.Errorf("Finality quorum %d out of range [0, %d]", params.FinalityQuorum, len(params.Miners))
+	}
+	if params.FinalityQuorum > 0 && 2*params.FinalityQuorum <= len(params.Miners) {
+		return fmt.Errorf("Finality quorum %d must be a majority of %d miners", params.FinalityQuorum, len(params.Miners))
+	}
+	for _, miner := range params.Miners {
+		err := checkPublicKeyHex(miner)
+		if err != nil {
+			return fmt.Errorf("Miner %q: %v", miner, err)
+		}
+	}
+	switch params.Consensus {
+	case ConsensusProofOfWork:
+	case ConsensusProofOfAuthority:
+		if len(params.Authorities) == 0 {
+			return errors.New("Proof of authority needs at least one authority")
+		}
+		for _, authority := range params.Authorities {
+			err := checkPublicKeyHex(authority)
+			if err != nil {
+				return fmt.Errorf("Authority %q: %v", authority, err)
+			}
+		}
+	default:
+		return fmt.Errorf("Unknown consensus %q", params.Consensus)
+	}
+	return nil
+}
+
+// IsMiner reports whether publicKey is one of the miners that sign
+// checkpoints for finality
+func (params *ChainParams) IsMiner(publicKey []byte) bool {
+	for _, miner := range params.Miners {
+		if miner == hex.EncodeToString(publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanCheckpoint reports whether publicKey may create checkpoints: one of the
+// Miners, or of the Authorities under proof of authority when no miners are
+// listed
+func (params *ChainParams) CanCheckpoint(publicKey []byte) bool {
+	if len(params.Miners) > 0 {
+		return params.IsMiner(publicKey)
+	}
+	if params.Consensus != ConsensusProofOfAuthority {
+		return false
+	}
+	for _, authority := range params.Authorities {
+		if authority == hex.EncodeToString(publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPublicKeyHex checks that value is a hex encoded public key
+func checkPublicKeyHex(value string) error {
+	publicKey, err := hex.DecodeString(value)
+	if err != nil {
+		return errors.New("Public key is not hex encoded")
+	}
+	_, err = DecodePublicKey(publicKey)
+	return err
+}
+
+// Hash returns the hash of the parameters, two nodes can only join each
+// other when their hashes are equal
+func (params *ChainParams) Hash() []byte {
+	data, err := json.Marshal(params)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// String prints the chain parameters
+func (params *ChainParams) String() string {
+	data, err := json.MarshalIndent(params, "", "    ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
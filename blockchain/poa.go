@@ -0,0 +1,130 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+)
+
+// ProofOfAuthority seals blocks with the signature of an authority instead
+// of a proof of work. Authorities take turns by height, the block at height
+// h is sealed by authority h mod n. Genesis blocks may be sealed by any
+// authority, they are created by the miner a device registers with.
+type ProofOfAuthority struct {
+	Authorities [][]byte
+	Key         *ecdsa.PrivateKey
+}
+
+// NewProofOfAuthority returns a proof of authority engine for the encoded
+// public keys of authorities, key seals blocks and may be nil
+func NewProofOfAuthority(authorities [][]byte, key *ecdsa.PrivateKey) *ProofOfAuthority {
+	return &ProofOfAuthority{Authorities: authorities, Key: key}
+}
+
+// InTurn returns the public key of the authority in turn at height
+func (engine *ProofOfAuthority) InTurn(height int64) []byte {
+	return engine.Authorities[height%int64(len(engine.Authorities))]
+}
+
+// IsAuthority reports whether publicKey belongs to an authority
+func (engine *ProofOfAuthority) IsAuthority(publicKey []byte) bool {
+	for _, authority := range engine.Authorities {
+		if bytes.Equal(authority, publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prepare clears the difficulty, it has no meaning under proof of authority
+func (engine *ProofOfAuthority) Prepare(chain ChainReader, block *Block) error {
+	block.Difficulty = 0
+	return nil
+}
+
+// Seal signs the block with the key of this node when it is in turn
+func (engine *ProofOfAuthority) Seal(ctx context.Context, chain ChainReader, block *Block) error {
+	if engine.Key == nil {
+		return &ChainError{
+			StatusCode: ErrorNotInTurn,
+			Err:        errors.New("This node has no sealing key"),
+		}
+	}
+	if block.Difficulty != 0 {
+		return &ChainError{
+			StatusCode: ErrorInvalidDifficulty,
+			Err:        fmt.Errorf("Block difficulty %d, required 0", block.Difficulty),
+		}
+	}
+	sealer := EncodePublicKey(&engine.Key.PublicKey)
+	err := engine.checkTurn(block, sealer)
+	if err != nil {
+		return err
+	}
+
+	block.Nonce = 0
+	block.Sealer = sealer
+	seal, err := SignDigest(engine.Key, block.SealDigest())
+	if err != nil {
+		return err
+	}
+	block.Seal = seal
+	block.Hash = block.ComputeHash()
+	return nil
+}
+
+// Verify checks that the block is sealed by the authority in turn and that
+// the seal and block hash are valid. It doesn't need the chain.
+func (engine *ProofOfAuthority) Verify(chain ChainReader, block *Block) error {
+	if block.Difficulty != 0 {
+		return &ChainError{
+			StatusCode: ErrorInvalidDifficulty,
+			Err:        fmt.Errorf("Block difficulty %d, required 0", block.Difficulty),
+		}
+	}
+	if block.Nonce != 0 {
+		return &ChainError{
+			StatusCode: ErrorInvalidSeal,
+			Err:        errors.New("Sealed block carries a nonce"),
+		}
+	}
+	err := engine.checkTurn(block, block.Sealer)
+	if err != nil {
+		return err
+	}
+	if !VerifyDigest(block.Sealer, block.SealDigest(), block.Seal) {
+		return &ChainError{
+			StatusCode: ErrorInvalidSeal,
+			Err:        errors.New("Seal can't be verified"),
+		}
+	}
+	if !bytes.Equal(block.ComputeHash(), block.Hash) {
+		return &ChainError{
+			StatusCode: ErrorInvalidHash,
+			Err:        errors.New("Block hash doesn't match block header"),
+		}
+	}
+	return nil
+}
+
+// checkTurn checks that sealer may seal block
+func (engine *ProofOfAuthority) checkTurn(block *Block, sealer []byte) error {
+	if block.IsGenesis() {
+		if !engine.IsAuthority(sealer) {
+			return &ChainError{
+				StatusCode: ErrorNotInTurn,
+				Err:        fmt.Errorf("Sealer %X is not an authority", sealer),
+			}
+		}
+		return nil
+	}
+	if block.Height < 0 || !bytes.Equal(engine.InTurn(block.Height), sealer) {
+		return &ChainError{
+			StatusCode: ErrorNotInTurn,
+			Err:        fmt.Errorf("Sealer %X is not in turn at height %d", sealer, block.Height),
+		}
+	}
+	return nil
+}
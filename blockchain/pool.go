@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ShareSize is the number of nonces in a share of pooled mining
+	ShareSize int64 = 1 << 22
+)
+
+// PooledProofOfWorkEngine seals blocks with the proof of work of
+// ProofOfWorkEngine, but splits the nonce space into shares that this node
+// and its connected miners search together. The first valid solution wins
+// and the other shares are cancelled.
+type PooledProofOfWorkEngine struct {
+	ProofOfWorkEngine
+}
+
+// Seal searches the nonce space with this node and every connected miner
+// until one finds a nonce that meets the target or ctx is done
+func (engine PooledProofOfWorkEngine) Seal(ctx context.Context, chain ChainReader, block *Block) error {
+	err := engine.checkDifficulty(chain, block)
+	if err != nil {
+		return err
+	}
+
+	pow := NewProof(block)
+	msg := block.Proto()
+	miners := map[string]shareMiner{
+		"local": func(ctx context.Context, start, count int64) (int, []byte, error) {
+			return pow.MineRange(ctx, MiningWorkers, start, count)
+		},
+	}
+	for addr, conn := range connectedNodes() {
+		miners[addr] = remoteShareMiner(NewMinerClient(conn), msg)
+	}
+	logrus.Infof("Pooled mining with %d miners", len(miners))
+
+	nonce, hash, err := mineShares(ctx, pow, miners)
+	if err != nil {
+		return &ChainError{
+			StatusCode: ErrorMiningAborted,
+			Err:        fmt.Errorf("Mining aborted: %v", err),
+		}
+	}
+	block.Nonce = nonce
+	block.Hash = hash
+	return nil
+}
+
+// shareMiner searches the nonces in [start, start+count) for one that meets
+// the target
+type shareMiner func(ctx context.Context, start, count int64) (int, []byte, error)
+
+// remoteShareMiner searches shares of block on a connected miner, cancelling
+// ctx cancels the search there
+func remoteShareMiner(client MinerClient, block *BlockMessage) shareMiner {
+	return func(ctx context.Context, start, count int64) (int, []byte, error) {
+		resp, err := client.MineShare(ctx, &MineShareRequest{Block: block, Start: start, Count: count})
+		if err != nil {
+			return 0, nil, err
+		}
+		if !resp.Found {
+			return 0, nil, errNonceRangeExhausted
+		}
+		return int(resp.Nonce), resp.Hash, nil
+	}
+}
+
+// mineShares hands out shares of the nonce space to miners until one of
+// them finds a nonce that meets the target of pow. Solutions are checked
+// before they are taken. A miner that fails or returns an invalid solution
+// is dropped and its share is handed out again.
+func mineShares(ctx context.Context, pow *ProofOfWork, miners map[string]shareMiner) (int, []byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mutex sync.Mutex
+	var next int64
+	var retry []int64
+	take := func() (int64, bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(retry) > 0 {
+			share := retry[len(retry)-1]
+			retry = retry[:len(retry)-1]
+			return share, true
+		}
+		if next > math.MaxInt64/ShareSize-1 {
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+	giveBack := func(share int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		retry = append(retry, share)
+	}
+
+	type solution struct {
+		nonce int
+		hash  []byte
+	}
+	found := make(chan solution, len(miners))
+
+	var wg sync.WaitGroup
+	for name, mine := range miners {
+		wg.Add(1)
+		go func(name string, mine shareMiner) {
+			defer wg.Done()
+			for {
+				share, ok := take()
+				if !ok {
+					return
+				}
+				start := share * ShareSize
+				nonce, _, err := mine(ctx, start, ShareSize)
+				if err == errNonceRangeExhausted {
+					continue
+				}
+				if err != nil {
+					if ctx.Err() == nil {
+						logrus.Warnf("Pool miner %v failed on share %d: %v", name, share, err)
+						giveBack(share)
+					}
+					return
+				}
+				hash, valid := pow.Check(nonce)
+				if !valid || int64(nonce) < start || int64(nonce)-start >= ShareSize {
+					logrus.Warnf("Pool miner %v returned invalid nonce %d for share %d", name, nonce, share)
+					giveBack(share)
+					return
+				}
+				found <- solution{nonce: nonce, hash: hash}
+				return
+			}
+		}(name, mine)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case sol := <-found:
+		return sol.nonce, sol.hash, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-done:
+		select {
+		case sol := <-found:
+			return sol.nonce, sol.hash, nil
+		default:
+		}
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		return 0, nil, errors.New("No pool miner found a solution")
+	}
+}
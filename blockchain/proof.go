@@ -2,14 +2,30 @@ package blockchain
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"math"
 	"math/big"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// cancelCheckInterval is the number of nonces a worker tries between
+	// checks for cancellation
+	cancelCheckInterval = 1 << 12
 )
 
 var (
-	// Difficulty is POW difficulty
-	Difficulty = 12
+	errNonceRangeExhausted = errors.New("Nonce range exhausted")
+
+	// MiningWorkers is the number of goroutines a miner searches nonces with
+	MiningWorkers = runtime.NumCPU()
+	// MineTimeout bounds the time a miner spends on a single block
+	MineTimeout = 10 * time.Minute
 )
 
 // ProofOfWork struct
@@ -18,55 +34,139 @@ type ProofOfWork struct {
 	Target *big.Int
 }
 
-// NewProof returns new ProofOfWork
+// NewProof returns new ProofOfWork for the difficulty carried by the block.
+// A difficulty outside [1, 255] gets a zero target that nothing satisfies.
 func NewProof(b *Block) *ProofOfWork {
-	target := big.NewInt(1)
+	target := big.NewInt(0)
 
-	target.Lsh(target, uint(256-Difficulty))
+	if b.Difficulty >= 1 && b.Difficulty <= 255 {
+		target.SetInt64(1)
+		target.Lsh(target, uint(256-b.Difficulty))
+	}
 
 	pow := &ProofOfWork{Block: b, Target: target}
 	return pow
 }
 
-// InitData initiates data
+// InitData returns the proof of work preimage for nonce, the canonical
+// block header followed by the nonce
 func (pow *ProofOfWork) InitData(nonce int) []byte {
-	var tmp []byte
-	for idx := range pow.Block.Transactions {
-		tmp = append(tmp, pow.Block.Transactions[idx].Data...)
-	}
-	trsHash := sha256.Sum256(tmp)
 	data := bytes.Join(
 		[][]byte{
-			pow.Block.PrevHash,
-			trsHash[:],
+			pow.Block.HeaderBytes(),
 			ToHex(int64(nonce)),
-			ToHex(int64(Difficulty)),
 		},
 		[]byte{},
 	)
 	return data
 }
 
-// Run proof of work
+// Hash returns the hash the block must carry for its nonce
+func (pow *ProofOfWork) Hash() []byte {
+	return pow.Block.ComputeHash()
+}
+
+// Run proof of work on a single goroutine, it returns the lowest nonce that
+// meets the target
 func (pow *ProofOfWork) Run() (int, []byte) {
-	var intHash big.Int
-	var hash [32]byte
+	nonce, hash, _ := pow.Mine(context.Background(), 1)
+	return nonce, hash
+}
 
-	nonce := 0
+// Mine searches the nonce space with workers goroutines until a nonce meets
+// the target or ctx is done
+func (pow *ProofOfWork) Mine(ctx context.Context, workers int) (int, []byte, error) {
+	return pow.MineRange(ctx, workers, 0, math.MaxInt64)
+}
 
-	for nonce < math.MaxInt64 {
-		data := pow.InitData(nonce)
-		hash = sha256.Sum256(data)
+// MineRange searches the nonces in [start, start+count) with workers
+// goroutines until a nonce meets the target or ctx is done. Worker i tries
+// nonces start+i, start+i+workers and so on. The header part of the
+// preimage is encoded once up front, workers only rewrite the trailing
+// nonce.
+func (pow *ProofOfWork) MineRange(ctx context.Context, workers int, start, count int64) (int, []byte, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if start < 0 || count < 1 {
+		return 0, nil, errors.New("Empty nonce range")
+	}
+	end := int64(math.MaxInt64)
+	if count <= math.MaxInt64-start {
+		end = start + count
+	}
+	prefix := pow.Block.HeaderBytes()
 
-		intHash.SetBytes(hash[:])
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		if intHash.Cmp(pow.Target) == -1 {
-			break
-		} else {
-			nonce++
+	type solution struct {
+		nonce int
+		hash  []byte
+	}
+	found := make(chan solution, workers)
+	exhausted := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(first int64) {
+			defer wg.Done()
+
+			var intHash big.Int
+			data := make([]byte, len(prefix)+8)
+			copy(data, prefix)
+
+			step := int64(workers)
+			for nonce, tries := first, 0; nonce < end; nonce, tries = nonce+step, tries+1 {
+				if tries%cancelCheckInterval == 0 {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+					}
+				}
+
+				binary.BigEndian.PutUint64(data[len(prefix):], uint64(nonce))
+				hash := sha256.Sum256(data)
+				intHash.SetBytes(hash[:])
+
+				if intHash.Cmp(pow.Target) == -1 {
+					found <- solution{nonce: int(nonce), hash: hash[:]}
+					return
+				}
+				if nonce > end-step {
+					return
+				}
+			}
+		}(start + int64(worker))
+	}
+	go func() {
+		wg.Wait()
+		close(exhausted)
+	}()
+
+	select {
+	case sol := <-found:
+		return sol.nonce, sol.hash, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-exhausted:
+		select {
+		case sol := <-found:
+			return sol.nonce, sol.hash, nil
+		default:
+			return 0, nil, errNonceRangeExhausted
 		}
 	}
-	return nonce, hash[:]
+}
+
+// Check returns the hash for nonce and whether it meets the target
+func (pow *ProofOfWork) Check(nonce int) ([]byte, bool) {
+	var intHash big.Int
+	hash := sha256.Sum256(pow.InitData(nonce))
+	intHash.SetBytes(hash[:])
+	return hash[:], intHash.Cmp(pow.Target) == -1
 }
 
 // Validate validates the proof of work
@@ -0,0 +1,203 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SchemaVersion is the version of the key layout this node writes. Stores
+// with an older layout are migrated when they are opened.
+const SchemaVersion = 3
+
+// The key space of a store is split into namespaces:
+//   block/<hash>                        block
+//   tip/<address>                       tip hash of a device chain
+//   index/child/<parent><child>         child index of the fork choice
+//   index/work/<hash>                   total work of the chain ending at hash
+//   index/final-checkpoint              height of the last final checkpoint
+//   index/stats                         block counts of the whole store
+//   index/device-stats/<address>        block counts of a device chain
+//   index/tx/<id><hash>                 blocks holding a transaction
+//   index/time/<address>/<time><hash>   blocks of a device chain by timestamp
+//   index/height/<address>/<height>     canonical block of a device chain at height
+//   evidence/<id>                       equivocation evidence
+//   checkpoint/<height>                 checkpoint
+//   vote/<height><signer>               checkpoint vote
+//   sync/<height><hash>                 downloaded block waiting to be replayed
+//   meta/schema                         schema version of the store
+// Other namespaces are declared next to the records they hold.
+var (
+	blockPrefix = []byte("block/")
+	tipPrefix   = []byte("tip/")
+	metaPrefix  = []byte("meta/")
+	schemaKey   = []byte("meta/schema")
+
+	// migrateBatch is the number of keys moved per transaction
+	migrateBatch = 64
+)
+
+// migrations upgrade a store from schema version i to i+1 and return the
+// number of keys they touched
+var migrations = []func(store ChainStore) (int, error){
+	migrateFlatKeys,
+	rebuildStats,
+	rebuildIndexes,
+}
+
+// blockKey holds the block with hash
+func blockKey(hash []byte) []byte {
+	return append(append([]byte{}, blockPrefix...), hash...)
+}
+
+// tipKey holds the tip of the device chain at address
+func tipKey(address []byte) []byte {
+	return append(append([]byte{}, tipPrefix...), address...)
+}
+
+// storedSchema returns the schema version of store, 0 when it has none
+func storedSchema(store ChainStore) (int64, error) {
+	version := int64(0)
+	err := store.View(func(txn StoreTxn) error {
+		data, err := txn.Get(schemaKey)
+		if err == ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		version = int64(binary.BigEndian.Uint64(data))
+		return nil
+	})
+	return version, err
+}
+
+// MigrateSchema upgrades the key layout of store to SchemaVersion one
+// version at a time. Every migration can be run again after a crash. Stores
+// written by a newer node are refused.
+func MigrateSchema(store ChainStore) error {
+	version, err := storedSchema(store)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion {
+		return &ChainError{
+			StatusCode: ErrorUnsupportedSchema,
+			Err:        fmt.Errorf("Store has schema version %d, this node supports up to %d", version, SchemaVersion),
+		}
+	}
+
+	for ; version < SchemaVersion; version++ {
+		migrated, err := migrations[version](store)
+		if err != nil {
+			return fmt.Errorf("Migrating store to schema version %d: %v", version+1, err)
+		}
+		err = store.Update(func(txn StoreTxn) error {
+			return txn.Set(schemaKey, ToHex(version+1))
+		})
+		if err != nil {
+			return err
+		}
+		if migrated > 0 {
+			logrus.Infof("Migrated %d keys to schema version %d", migrated, version+1)
+		}
+	}
+	return nil
+}
+
+// migrateFlatKeys moves the keys of the unversioned layout, where blocks and
+// tips were stored under the bare hash and address, into their namespaces.
+// Keys it can't place are left alone.
+func migrateFlatKeys(store ChainStore) (int, error) {
+	legacy := []struct {
+		prefix    []byte
+		namespace []byte
+	}{
+		{[]byte("child-"), childPrefix},
+		{[]byte("work-"), workPrefix},
+		{[]byte("evidence-"), evidencePrefix},
+		{[]byte("checkpoint-"), checkpointPrefix},
+		{[]byte("vote-"), votePrefix},
+	}
+	isBlock := func(key, value []byte) bool {
+		_, err := decodeStoredBlock(key, value)
+		return err == nil
+	}
+
+	moves := make(map[string][]byte)
+	err := store.Iterate(nil, func(key, value []byte) error {
+		switch {
+		case bytes.Equal(key, []byte("final-checkpoint")):
+			moves[string(key)] = finalKey
+			return nil
+		case ValidateAddress(key):
+			moves[string(key)] = tipKey(key)
+			return nil
+		case isBlock(key, value):
+			moves[string(key)] = blockKey(key)
+			return nil
+		}
+		for _, ns := range legacy {
+			if bytes.HasPrefix(key, ns.prefix) {
+				moves[string(key)] = append(append([]byte{}, ns.namespace...), key[len(ns.prefix):]...)
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []string
+	for key := range moves {
+		keys = append(keys, key)
+	}
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > migrateBatch {
+			batch = batch[:migrateBatch]
+		}
+		err := store.Update(func(txn StoreTxn) error {
+			for _, key := range batch {
+				value, err := txn.Get([]byte(key))
+				if err != nil {
+					return err
+				}
+				err = txn.Set(moves[key], value)
+				if err != nil {
+					return err
+				}
+				err = txn.Delete([]byte(key))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == ErrTxnConflict {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		keys = keys[len(batch):]
+	}
+	return len(moves), nil
+}
+
+// decodeStoredBlock decodes the block stored under hash, blocks stay in the
+// legacy gob encoding until MigrateEncoding rewrites them
+func decodeStoredBlock(hash, value []byte) (*Block, error) {
+	block, err := Deserialize(value)
+	if err == nil && bytes.Equal(block.Hash, hash) {
+		return block, nil
+	}
+	block, err = deserializeGob(value)
+	if err == nil && bytes.Equal(block.Hash, hash) {
+		return block, nil
+	}
+	return nil, fmt.Errorf("Block %X can't be decoded", hash)
+}
@@ -0,0 +1,204 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	statsKey          = []byte("index/stats")
+	deviceStatsPrefix = []byte("index/device-stats/")
+)
+
+// ChainStats counts the blocks of a device chain, or of all device chains
+// together. Height is the number of blocks on the canonical chain, Blocks
+// and Bytes count every stored block including side branches.
+type ChainStats struct {
+	Height int64
+	Blocks int64
+	Bytes  int64
+}
+
+// deviceStatsKey holds the stats of the device chain at address
+func deviceStatsKey(address []byte) []byte {
+	return append(append([]byte{}, deviceStatsPrefix...), address...)
+}
+
+// encode encodes stats as three big endian integers
+func (stats ChainStats) encode() []byte {
+	data := make([]byte, 24)
+	binary.BigEndian.PutUint64(data[0:], uint64(stats.Height))
+	binary.BigEndian.PutUint64(data[8:], uint64(stats.Blocks))
+	binary.BigEndian.PutUint64(data[16:], uint64(stats.Bytes))
+	return data
+}
+
+// statsTxn returns the stats stored under key, zero when there are none
+func statsTxn(txn StoreTxn, key []byte) (ChainStats, error) {
+	data, err := txn.Get(key)
+	if err == ErrKeyNotFound {
+		return ChainStats{}, nil
+	}
+	if err != nil {
+		return ChainStats{}, err
+	}
+	if len(data) != 24 {
+		return ChainStats{}, errors.New("Malformed chain stats")
+	}
+	return ChainStats{
+		Height: int64(binary.BigEndian.Uint64(data[0:])),
+		Blocks: int64(binary.BigEndian.Uint64(data[8:])),
+		Bytes:  int64(binary.BigEndian.Uint64(data[16:])),
+	}, nil
+}
+
+// addStatsTxn adds delta to the stats stored under key
+func addStatsTxn(txn StoreTxn, key []byte, delta ChainStats) error {
+	stats, err := statsTxn(txn, key)
+	if err != nil {
+		return err
+	}
+	stats.Height += delta.Height
+	stats.Blocks += delta.Blocks
+	stats.Bytes += delta.Bytes
+	return txn.Set(key, stats.encode())
+}
+
+// countBlockTxn counts a newly stored block of size bytes for the device
+// chain at address and the whole store
+func countBlockTxn(txn StoreTxn, address []byte, size int) error {
+	delta := ChainStats{Blocks: 1, Bytes: int64(size)}
+	err := addStatsTxn(txn, deviceStatsKey(address), delta)
+	if err != nil {
+		return err
+	}
+	return addStatsTxn(txn, statsKey, delta)
+}
+
+// setTipTxn moves the tip of the device chain at address to block and
+// updates the canonical heights and the height index
+func setTipTxn(txn StoreTxn, address []byte, block *Block) error {
+	err := txn.Set(tipKey(address), block.Hash)
+	if err != nil {
+		return err
+	}
+	key := deviceStatsKey(address)
+	stats, err := statsTxn(txn, key)
+	if err != nil {
+		return err
+	}
+	err = indexCanonicalTxn(txn, address, block, stats.Height)
+	if err != nil {
+		return err
+	}
+	delta := block.Height + 1 - stats.Height
+	stats.Height = block.Height + 1
+	err = txn.Set(key, stats.encode())
+	if err != nil {
+		return err
+	}
+	return addStatsTxn(txn, statsKey, ChainStats{Height: delta})
+}
+
+// Stats returns the stats of the device chain of token, or of the whole
+// store when token is empty
+func (chain *BlockChain) Stats(token []byte) (ChainStats, error) {
+	key := statsKey
+	if len(token) != 0 {
+		address, err := Address(token)
+		if err != nil {
+			return ChainStats{}, err
+		}
+		key = deviceStatsKey(address)
+	}
+
+	var stats ChainStats
+	err := chain.Store.View(func(txn StoreTxn) error {
+		var err error
+		stats, err = statsTxn(txn, key)
+		return err
+	})
+	return stats, err
+}
+
+// RebuildStats counts the stats of the store again from its blocks and tips,
+// after blocks were written without going through AddBlock
+func (chain *BlockChain) RebuildStats() error {
+	_, err := rebuildStats(chain.Store)
+	return err
+}
+
+// rebuildStats replaces the stats of store with counts over its blocks and
+// tips and returns the number of device chains counted
+func rebuildStats(store ChainStore) (int, error) {
+	var total ChainStats
+	devices := make(map[string]ChainStats)
+
+	heights := make(map[string]int64)
+	err := store.Iterate(blockPrefix, func(key, value []byte) error {
+		block, err := decodeStoredBlock(key[len(blockPrefix):], value)
+		if err != nil {
+			logrus.Warnf("Not counting block: %v\n", err)
+			return nil
+		}
+		heights[string(block.Hash)] = block.Height
+		address, err := Address(block.Token)
+		if err != nil {
+			return err
+		}
+		stats := devices[string(address)]
+		stats.Blocks++
+		stats.Bytes += int64(len(value))
+		devices[string(address)] = stats
+		total.Blocks++
+		total.Bytes += int64(len(value))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	tips, err := store.Tips()
+	if err != nil {
+		return 0, err
+	}
+	for _, tip := range tips {
+		height, ok := heights[string(tip.Hash)]
+		if !ok {
+			continue
+		}
+		stats := devices[string(tip.Address)]
+		stats.Height = height + 1
+		devices[string(tip.Address)] = stats
+		total.Height += stats.Height
+	}
+
+	err = store.Update(func(txn StoreTxn) error {
+		var stale [][]byte
+		err := txn.Iterate(deviceStatsPrefix, false, func(key, value []byte) error {
+			stale = append(stale, key)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range stale {
+			err = txn.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+		for address, stats := range devices {
+			err = txn.Set(deviceStatsKey([]byte(address)), stats.encode())
+			if err != nil {
+				return err
+			}
+		}
+		return txn.Set(statsKey, total.encode())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(devices), nil
+}
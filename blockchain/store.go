@@ -0,0 +1,108 @@
+package blockchain
+
+import (
+	"errors"
+)
+
+var (
+	// ErrKeyNotFound is returned by a store when a key doesn't exist
+	ErrKeyNotFound = errors.New("Key not found")
+	// ErrTxnConflict is returned by Update when the transaction raced with
+	// another one, it can be retried
+	ErrTxnConflict = errors.New("Transaction conflict")
+
+	errStopIteration = errors.New("Stop iteration")
+)
+
+// StoreTxn reads and writes the keys of a ChainStore within a transaction
+type StoreTxn interface {
+	// Get returns the value of key, ErrKeyNotFound when it isn't set
+	Get(key []byte) ([]byte, error)
+	// Set sets key to value
+	Set(key, value []byte) error
+	// Delete removes key
+	Delete(key []byte) error
+	// Iterate calls fn with every key starting with prefix in key order,
+	// backwards when reverse is set. fn returning errStopIteration stops
+	// the iteration without an error.
+	Iterate(prefix []byte, reverse bool, fn func(key, value []byte) error) error
+}
+
+// ChainStore holds the blocks, the tips of the device chains and everything
+// recorded about them in the namespaces of the key space listed in schema.go
+type ChainStore interface {
+	// View runs fn in a read only transaction
+	View(fn func(txn StoreTxn) error) error
+	// Update runs fn in a read write transaction that is committed when fn
+	// returns nil. It returns ErrTxnConflict when it has to be retried.
+	Update(fn func(txn StoreTxn) error) error
+	// GetBlock returns the block stored under hash
+	GetBlock(hash []byte) (*Block, error)
+	// PutBlock stores block under its hash
+	PutBlock(block *Block) error
+	// Tips returns the tips of all device chains in address order
+	Tips() ([]CheckpointTip, error)
+	// Iterate calls fn with every key starting with prefix in key order
+	Iterate(prefix []byte, fn func(key, value []byte) error) error
+	// Close releases the store
+	Close() error
+}
+
+// getBlock returns the block stored under hash in store
+func getBlock(store ChainStore, hash []byte) (*Block, error) {
+	var block *Block
+	err := store.View(func(txn StoreTxn) error {
+		var err error
+		block, err = getBlockTxn(txn, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// putBlock stores block under its hash in store
+func putBlock(store ChainStore, block *Block) error {
+	data, err := block.Serialize()
+	if err != nil {
+		return err
+	}
+	return store.Update(func(txn StoreTxn) error {
+		return txn.Set(blockKey(block.Hash), data)
+	})
+}
+
+// storeTips returns the tips of all device chains in store
+func storeTips(store ChainStore) ([]CheckpointTip, error) {
+	var tips []CheckpointTip
+	err := store.View(func(txn StoreTxn) error {
+		var err error
+		tips, err = tipsTxn(txn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tips, nil
+}
+
+// iterate calls fn with every key starting with prefix in store
+func iterate(store ChainStore, prefix []byte, fn func(key, value []byte) error) error {
+	return store.View(func(txn StoreTxn) error {
+		return txn.Iterate(prefix, false, fn)
+	})
+}
+
+// prefixEnd returns the first key after all keys starting with prefix, nil
+// when there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package blockchain
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	syncPrefix = []byte("sync/")
+
+	// exportPrefixes are the namespaces sent to a node downloading the full
+	// chain, everything else it derives from them
+	exportPrefixes = [][]byte{blockPrefix, evidencePrefix, checkpointPrefix, votePrefix}
+)
+
+// syncKey stages a downloaded block until it is replayed, keys sort by height
+// so parents are replayed before their children
+func syncKey(height int64, hash []byte) []byte {
+	key := append(append([]byte{}, syncPrefix...), ToHex(height)...)
+	return append(key, hash...)
+}
+
+// exportChain calls fn with the blocks, evidence, checkpoints and votes of
+// store, the records importChain takes
+func exportChain(store ChainStore, fn func(key, value []byte) error) error {
+	for _, prefix := range exportPrefixes {
+		err := store.Iterate(prefix, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importChain adds the records of another node, read with next until it
+// returns io.EOF, as if each of them was gossiped. Blocks are replayed
+// through AddGenesis and AddBlock in height order, so tips, work and the
+// child index are computed here. Evidence, checkpoints and votes follow, and
+// a checkpoint is only final once its votes verify here. Records that don't
+// verify are dropped, other keys are ignored.
+func (chain *BlockChain) importChain(next func() ([]byte, []byte, error)) error {
+	var evidence []*Evidence
+	var checkpoints []*Checkpoint
+	var votes []*CheckpointVote
+	staged := 0
+	for {
+		key, value, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case bytes.HasPrefix(key, blockPrefix):
+			block, err := decodeStoredBlock(key[len(blockPrefix):], value)
+			if err != nil {
+				logrus.Warnf("Dropped downloaded block: %v\n", err)
+				continue
+			}
+			err = chain.Store.Update(func(txn StoreTxn) error {
+				return txn.Set(syncKey(block.Height, block.Hash), value)
+			})
+			if err != nil {
+				return err
+			}
+			staged++
+		case bytes.HasPrefix(key, evidencePrefix):
+			var msg EvidenceMessage
+			if proto.Unmarshal(value, &msg) == nil {
+				evidence = append(evidence, EvidenceFromProto(&msg))
+			}
+		case bytes.HasPrefix(key, checkpointPrefix):
+			var msg CheckpointMessage
+			if proto.Unmarshal(value, &msg) == nil {
+				checkpoints = append(checkpoints, CheckpointFromProto(&msg))
+			}
+		case bytes.HasPrefix(key, votePrefix):
+			var msg CheckpointVoteMessage
+			if proto.Unmarshal(value, &msg) == nil {
+				votes = append(votes, CheckpointVoteFromProto(&msg))
+			}
+		}
+	}
+
+	added, err := chain.replayStaged()
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Added %d of %d downloaded blocks", added, staged)
+
+	for _, record := range evidence {
+		_, err := chain.AddEvidence(record)
+		if err != nil {
+			logrus.Warnf("Dropped downloaded evidence: %v\n", err)
+		}
+	}
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Height < checkpoints[j].Height
+	})
+	for _, checkpoint := range checkpoints {
+		_, err := chain.AddCheckpoint(checkpoint)
+		if err != nil {
+			logrus.Warnf("Dropped downloaded checkpoint %d: %v\n", checkpoint.Height, err)
+		}
+	}
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].Height < votes[j].Height
+	})
+	for _, vote := range votes {
+		_, err := chain.AddVote(vote)
+		if err != nil {
+			logrus.Warnf("Dropped downloaded vote for checkpoint %d: %v\n", vote.Height, err)
+		}
+	}
+	return nil
+}
+
+// replayStaged adds the staged blocks in height order, a batch at a time,
+// and drops them from the stage. It returns the number of blocks added.
+func (chain *BlockChain) replayStaged() (int, error) {
+	added := 0
+	for {
+		var keys [][]byte
+		var blocks []*Block
+		err := chain.Store.View(func(txn StoreTxn) error {
+			return txn.Iterate(syncPrefix, false, func(key, value []byte) error {
+				if len(keys) == migrateBatch {
+					return errStopIteration
+				}
+				block, err := decodeStoredBlock(key[len(syncPrefix)+8:], value)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, key)
+				blocks = append(blocks, block)
+				return nil
+			})
+		})
+		if err != nil {
+			return added, err
+		}
+		if len(keys) == 0 {
+			return added, nil
+		}
+
+		for _, block := range blocks {
+			if block.IsGenesis() {
+				err = chain.AddGenesis(block)
+			} else {
+				err = chain.AddBlock(block)
+			}
+			if err != nil {
+				logrus.Warnf("Dropped downloaded block %X: %v\n", block.Hash, err)
+				continue
+			}
+			added++
+		}
+		err = chain.Store.Update(func(txn StoreTxn) error {
+			for _, key := range keys {
+				err := txn.Delete(key)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return added, err
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MedianTime returns the median timestamp of the last Params.MedianTimeBlocks
+// blocks of the chain ending at parent, fewer near genesis
+func MedianTime(chain ChainReader, parent *Block) (int64, error) {
+	var timestamps []int64
+	block := parent
+	for {
+		timestamps = append(timestamps, block.Timestamp)
+		if len(timestamps) == Params.MedianTimeBlocks || block.IsGenesis() {
+			break
+		}
+		var err error
+		block, err = chain.GetBlock(block.PrevHash)
+		if err != nil {
+			return 0, err
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return timestamps[len(timestamps)/2], nil
+}
+
+// checkMedianTime checks that block isn't earlier than the median time of
+// its parent chain
+func checkMedianTime(chain ChainReader, block, parent *Block) error {
+	median, err := MedianTime(chain, parent)
+	if err != nil {
+		return err
+	}
+	if block.Timestamp < median {
+		return &ChainError{
+			StatusCode: ErrorTimestampTooEarly,
+			Err: fmt.Errorf("Block timestamp %s is earlier than the median %s of the last %d blocks",
+				formatTimestamp(block.Timestamp), formatTimestamp(median), Params.MedianTimeBlocks),
+		}
+	}
+	return nil
+}
+
+// checkFutureTimestamp checks that block is at most Params.MaxFutureDrift
+// milliseconds ahead of the local clock
+func checkFutureTimestamp(block *Block) error {
+	limit := time.Now().Add(time.Duration(Params.MaxFutureDrift) * time.Millisecond)
+	if block.Timestamp > limit.UnixNano() {
+		return &ChainError{
+			StatusCode: ErrorTimestampInFuture,
+			Err: fmt.Errorf("Block timestamp %s is more than %dms ahead of local time",
+				formatTimestamp(block.Timestamp), Params.MaxFutureDrift),
+		}
+	}
+	return nil
+}
+
+// formatTimestamp formats a block timestamp for messages
+func formatTimestamp(timestamp int64) string {
+	return time.Unix(0, timestamp).UTC().Format(time.RFC3339Nano)
+}
@@ -2,23 +2,148 @@ package blockchain
 
 import (
 	"bytes"
-	"encoding/gob"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// ContentTypeJSON marks JSON encoded transaction data
+	ContentTypeJSON = "json"
+	// ContentTypeCBOR marks CBOR encoded transaction data
+	ContentTypeCBOR = "cbor"
+	// ContentTypeRaw marks opaque transaction data
+	ContentTypeRaw = "raw"
+
+	// TransactionTypeReading is a sensor reading
+	TransactionTypeReading = "reading"
+	// TransactionTypeEvent is a device event
+	TransactionTypeEvent = "event"
+	// TransactionTypeFirmware is a firmware notice
+	TransactionTypeFirmware = "firmware"
+	// TransactionTypeGenesis is the transaction of a genesis block
+	TransactionTypeGenesis = "genesis"
+
+	// MaxMetadataEntries is the maximum number of metadata entries of a transaction
+	MaxMetadataEntries = 16
 )
 
 // Transaction struct
 type Transaction struct {
-	Data []byte
+	ID          []byte
+	Type        string
+	Timestamp   int64
+	ContentType string
+	Metadata    map[string]string
+	Data        []byte
 }
 
-// Serialize sereilizes transactions
-func (tx *Transaction) Serialize() []byte {
+// NewTransaction creates a transaction stamped with the current time
+func NewTransaction(txType, contentType string, data []byte, metadata map[string]string) *Transaction {
+	tx := Transaction{
+		Type:        txType,
+		Timestamp:   time.Now().UnixNano(),
+		ContentType: contentType,
+		Metadata:    metadata,
+		Data:        data,
+	}
+	tx.ID = tx.Hash()
+	return &tx
+}
+
+// Hash returns the content hash of the transaction, the canonical encoding
+// of every field except ID. Metadata is encoded in key order.
+func (tx *Transaction) Hash() []byte {
 	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
-	err := encoder.Encode(tx)
+
+	writeField(&buffer, []byte(tx.Type))
+	buffer.Write(ToHex(tx.Timestamp))
+	writeField(&buffer, []byte(tx.ContentType))
+
+	keys := make([]string, 0, len(tx.Metadata))
+	for key := range tx.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buffer.Write(ToHex(int64(len(keys))))
+	for _, key := range keys {
+		writeField(&buffer, []byte(key))
+		writeField(&buffer, []byte(tx.Metadata[key]))
+	}
+	writeField(&buffer, tx.Data)
+
+	hash := sha256.Sum256(buffer.Bytes())
+	return hash[:]
+}
+
+// Validate checks that the transaction ID matches its content and that the
+// content type and metadata are acceptable
+func (tx *Transaction) Validate() error {
+	if !IsContentType(tx.ContentType) {
+		return fmt.Errorf("Unknown content type %q", tx.ContentType)
+	}
+	if tx.Type == "" {
+		return errors.New("Transaction type is not set")
+	}
+	if len(tx.Metadata) > MaxMetadataEntries {
+		return fmt.Errorf("Transaction has %d metadata entries, at most %d allowed", len(tx.Metadata), MaxMetadataEntries)
+	}
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return errors.New("Transaction ID doesn't match its content")
+	}
+	return nil
+}
+
+// IsContentType reports whether contentType is a known content type
+func IsContentType(contentType string) bool {
+	switch contentType {
+	case ContentTypeJSON, ContentTypeCBOR, ContentTypeRaw:
+		return true
+	}
+	return false
+}
+
+// Serialize sereilizes transaction into its protobuf message
+func (tx *Transaction) Serialize() []byte {
+	data, err := marshalDeterministic(tx.Proto())
 	if err != nil {
 		log.Panic(err)
 	}
 
-	return buffer.Bytes()
+	return data
+}
+
+// DeserializeTransaction deserializes transaction from its protobuf message
+func DeserializeTransaction(data []byte) (*Transaction, error) {
+	var msg TransactionMessage
+
+	err := proto.Unmarshal(data, &msg)
+	if err != nil {
+		return &Transaction{}, err
+	}
+	return TransactionFromProto(&msg), nil
+}
+
+// String prints the transaction
+func (tx *Transaction) String() string {
+	var metadata []string
+	for key, value := range tx.Metadata {
+		metadata = append(metadata, key+"="+value)
+	}
+	sort.Strings(metadata)
+
+	return fmt.Sprintf("%X type=%s content=%s time=%s meta=[%s] size=%d",
+		tx.ID,
+		tx.Type,
+		tx.ContentType,
+		time.Unix(0, tx.Timestamp).UTC().Format(time.RFC3339Nano),
+		strings.Join(metadata, ","),
+		len(tx.Data),
+	)
 }
@@ -51,7 +51,7 @@ func Checksum(payload []byte) []byte {
 	firstHash := sha256.Sum256(payload)
 	secondHash := sha256.Sum256(firstHash[:])
 
-	return secondHash[:checksumLength]
+	return secondHash[:Params.AddressChecksumLength]
 }
 
 // Base58Encode returns Base58Encoded bytes
@@ -61,6 +61,20 @@ func Base58Encode(input []byte) []byte {
 	return []byte(encode)
 }
 
+// ValidateAddress reports whether address is a well formed device address
+// with a matching checksum
+func ValidateAddress(address []byte) bool {
+	decoded, err := base58.Decode(string(address))
+	if err != nil || len(decoded) != 1+ripemd160.Size+Params.AddressChecksumLength {
+		return false
+	}
+	versionedHash := decoded[:1+ripemd160.Size]
+	if versionedHash[0] != Params.AddressVersion {
+		return false
+	}
+	return bytes.Equal(Checksum(versionedHash), decoded[1+ripemd160.Size:])
+}
+
 // Base58Decode decodes from base58 to bytes
 func Base58Decode(input []byte) []byte {
 	decode, err := base58.Decode(string(input[:]))
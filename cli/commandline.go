@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
@@ -13,7 +14,6 @@ import (
 
 	"github.com/TariqueNasrullah/iotchain/analysis"
 	"github.com/TariqueNasrullah/iotchain/blockchain"
-	"github.com/dgraph-io/badger"
 	"github.com/sirupsen/logrus"
 )
 
@@ -35,15 +35,81 @@ func (trans *transData) Set(value string) error {
 	return nil
 }
 
+type metaData map[string]string
+
+func (meta *metaData) String() string {
+	return fmt.Sprint(*meta)
+}
+
+func (meta *metaData) Set(value string) error {
+	if len(*meta) > 0 {
+		return errors.New("meta flag already set")
+	}
+	*meta = make(metaData)
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid metadata %q, expected key=value", pair)
+		}
+		(*meta)[kv[0]] = kv[1]
+	}
+	return nil
+}
+
 func (cli *CommandLine) printUsage() {
 	fmt.Println("Usage:")
-	fmt.Println(" node -addr ADDRESS -connect ADDRESS - RUN as node")
+	fmt.Println(" node -addr ADDRESS -connect ADDRESS -params FILE -workers N -jobs N -queue N -pool - RUN as node")
+	fmt.Println(" params -init - Print chain parameters, -init writes the defaults to the parameters file")
 	fmt.Println(" address -f ADDRESS - Get addresses from a node")
 	fmt.Println(" cleanup - Cleansup database")
+	fmt.Println(" migrate - Upgrade the database key layout and rewrite gob encoded blocks as protobuf")
 	fmt.Println(" populate - Populates DB with test data")
 	fmt.Println(" keygen - Generate Key")
-	fmt.Println(" print - Print Chain")
+	fmt.Println(" print -token TOKEN -forward -height N - Print Chain, from the tip back unless -forward")
 	fmt.Println(" client - Client options")
+	fmt.Println(" proof -f ADDRESS -block HASH -tx ID - Verify a transaction is included in a block")
+	fmt.Println(" checkpoint -f ADDRESS -token TOKEN -height N - Verify a device chain tip is committed to by a checkpoint, the last one without -height")
+	fmt.Println(" evidence -f ADDRESS -token TOKEN - Print equivocation evidence a miner recorded, all of it without -token")
+	fmt.Println(" job -f ADDRESS -id JOB -watch -cancel - Check back on a block submitted with client -b -async")
+	fmt.Println(" finality -f ADDRESS - Print the last checkpoint a quorum of miners finalized")
+	fmt.Println(" query -f ADDRESS -tx ID | -token TOKEN -height N | -token TOKEN -from TIME -to TIME - Look up blocks by transaction, height or RFC3339 time range")
+}
+
+// loadChainParams loads the chain parameters file into blockchain.Params.
+// Built-in defaults are kept when the default file is absent, a file named
+// explicitly must exist.
+func (cli *CommandLine) loadChainParams(paramsPath string, explicit bool) {
+	if _, err := os.Stat(paramsPath); os.IsNotExist(err) && !explicit {
+		logrus.Infof("No chain parameters file at %v, using defaults", paramsPath)
+		return
+	}
+	params, err := blockchain.LoadChainParams(paramsPath)
+	if err != nil {
+		logrus.Fatalf("Can't load chain parameters %v: %v\n", paramsPath, err)
+	}
+	blockchain.Params = params
+	logrus.Infof("Loaded chain parameters of network %q", params.NetworkID)
+}
+
+// loadNodeKey loads the key at blockchain.KEYPATH a node seals blocks and
+// signs evidence with, nil when there is none
+func (cli *CommandLine) loadNodeKey() *ecdsa.PrivateKey {
+	key, err := blockchain.LoadKey(blockchain.KEYPATH)
+	if err != nil {
+		logrus.Warnf("No key at %v, this node can't seal blocks or sign evidence", blockchain.KEYPATH)
+		return nil
+	}
+	return key.PrivateKey
+}
+
+// newEngine builds the consensus engine selected by the chain parameters,
+// sealKey may be nil on nodes that don't seal blocks
+func (cli *CommandLine) newEngine(sealKey *ecdsa.PrivateKey) blockchain.Engine {
+	engine, err := blockchain.NewEngine(blockchain.Params, sealKey)
+	if err != nil {
+		logrus.Fatalf("Can't create consensus engine: %v\n", err)
+	}
+	return engine
 }
 
 func (cli *CommandLine) validateArgs() {
@@ -60,11 +126,20 @@ func (cli *CommandLine) Run() {
 	runNodeCmd := flag.NewFlagSet("node", flag.ExitOnError)
 	nodeAddress := runNodeCmd.String("addr", "", "Node address")
 	remoteNodeAddress := runNodeCmd.String("connect", "", "Address of node to with to connecect to")
+	nodeParamsPath := runNodeCmd.String("params", "", "Chain parameters file (default "+blockchain.PARAMSPATH+")")
+	nodeWorkers := runNodeCmd.Int("workers", blockchain.MiningWorkers, "Number of goroutines to mine with")
+	nodeJobWorkers := runNodeCmd.Int("jobs", blockchain.JobWorkers, "Number of blocks to mine at a time")
+	nodeQueueSize := runNodeCmd.Int("queue", blockchain.JobQueueSize, "Number of blocks to queue before answering busy")
+	nodePool := runNodeCmd.Bool("pool", false, "Mine blocks together with the connected miners")
+
+	paramsCmd := flag.NewFlagSet("params", flag.ExitOnError)
+	paramsCmdInit := paramsCmd.Bool("init", false, "Write the default chain parameters to "+blockchain.PARAMSPATH)
 
 	addressListCmd := flag.NewFlagSet("address", flag.ExitOnError)
 	addressListCmdNodeAddress := addressListCmd.String("f", "", "Node address from which addresses are required")
 
 	clearDbCmd := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	migrateCmd := flag.NewFlagSet("migrate", flag.ExitOnError)
 	populateCmd := flag.NewFlagSet("populate", flag.ExitOnError)
 
 	keyGenCmd := flag.NewFlagSet("keygen", flag.ExitOnError)
@@ -74,6 +149,8 @@ func (cli *CommandLine) Run() {
 
 	printchainCmd := flag.NewFlagSet("print", flag.ExitOnError)
 	printchainCmdToken := printchainCmd.String("token", "", "Toekn")
+	printchainCmdForward := printchainCmd.Bool("forward", false, "Print from genesis to the tip")
+	printchainCmdHeight := printchainCmd.Int64("height", -1, "Start at the block at this height")
 
 	clientCmd := flag.NewFlagSet("client", flag.ExitOnError)
 	clientCmdSync := clientCmd.Bool("sync", false, "Sync Local Chain from a Miner")
@@ -82,7 +159,43 @@ func (cli *CommandLine) Run() {
 	clientCmdBlock := clientCmd.Bool("b", false, "Generate block")
 	var transactions transData
 	clientCmd.Var(&transactions, "t", "Comma seperated list of transactions")
+	clientCmdTransactionType := clientCmd.String("type", blockchain.TransactionTypeReading, "Transaction type (reading, event, firmware, ...)")
+	clientCmdContentType := clientCmd.String("content", blockchain.ContentTypeRaw, "Transaction content type (json, cbor, raw)")
+	var metadata metaData
+	clientCmd.Var(&metadata, "meta", "Comma seperated list of key=value transaction metadata")
 	clientCmdBlockCount := clientCmd.Int("count", 1, "Number of blocks")
+	clientCmdAsync := clientCmd.Bool("async", false, "Submit the block as a mining job and return its job ID")
+
+	proofCmd := flag.NewFlagSet("proof", flag.ExitOnError)
+	proofCmdServerAddr := proofCmd.String("f", "", "Miner address")
+	proofCmdBlockHash := proofCmd.String("block", "", "Hash of the block holding the transaction")
+	proofCmdTransactionID := proofCmd.String("tx", "", "Transaction ID")
+
+	checkpointCmd := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+	checkpointCmdServerAddr := checkpointCmd.String("f", "", "Miner address")
+	checkpointCmdToken := checkpointCmd.String("token", "", "Token")
+	checkpointCmdHeight := checkpointCmd.Int64("height", -1, "Checkpoint height")
+
+	evidenceCmd := flag.NewFlagSet("evidence", flag.ExitOnError)
+	evidenceCmdServerAddr := evidenceCmd.String("f", "", "Miner address")
+	evidenceCmdToken := evidenceCmd.String("token", "", "Token")
+
+	jobCmd := flag.NewFlagSet("job", flag.ExitOnError)
+	jobCmdServerAddr := jobCmd.String("f", "", "Miner address")
+	jobCmdID := jobCmd.String("id", "", "Job ID")
+	jobCmdWatch := jobCmd.Bool("watch", false, "Follow the job until it is done")
+	jobCmdCancel := jobCmd.Bool("cancel", false, "Cancel the job")
+
+	finalityCmd := flag.NewFlagSet("finality", flag.ExitOnError)
+	finalityCmdServerAddr := finalityCmd.String("f", "", "Miner address")
+
+	queryCmd := flag.NewFlagSet("query", flag.ExitOnError)
+	queryCmdServerAddr := queryCmd.String("f", "", "Miner address")
+	queryCmdTransactionID := queryCmd.String("tx", "", "Transaction ID")
+	queryCmdToken := queryCmd.String("token", "", "Token")
+	queryCmdHeight := queryCmd.Int64("height", -1, "Block height")
+	queryCmdFrom := queryCmd.String("from", "", "Start of the time range (RFC3339)")
+	queryCmdTo := queryCmd.String("to", "", "End of the time range (RFC3339)")
 
 	testCmd := flag.NewFlagSet("test", flag.ExitOnError)
 	testCmdAddr := testCmd.String("f", "", "address")
@@ -98,6 +211,11 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			log.Panic(err)
 		}
+	case "params":
+		err := paramsCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
 	case "address":
 		err := addressListCmd.Parse(os.Args[2:])
 		if err != nil {
@@ -108,6 +226,11 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			log.Panic(err)
 		}
+	case "migrate":
+		err := migrateCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
 	case "populate":
 		err := populateCmd.Parse(os.Args[2:])
 		if err != nil {
@@ -128,6 +251,36 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			log.Panic(err)
 		}
+	case "proof":
+		err := proofCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "checkpoint":
+		err := checkpointCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "evidence":
+		err := evidenceCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "job":
+		err := jobCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "finality":
+		err := finalityCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
+	case "query":
+		err := queryCmd.Parse(os.Args[2:])
+		if err != nil {
+			log.Panic(err)
+		}
 	case "test":
 		err := testCmd.Parse(os.Args[2:])
 		if err != nil {
@@ -143,18 +296,66 @@ func (cli *CommandLine) Run() {
 		os.Exit(0)
 	}
 
+	if runNodeCmd.Parsed() && *nodeParamsPath != "" {
+		cli.loadChainParams(*nodeParamsPath, true)
+	} else if !paramsCmd.Parsed() {
+		cli.loadChainParams(blockchain.PARAMSPATH, false)
+	}
+
+	if paramsCmd.Parsed() {
+		if *paramsCmdInit {
+			if _, err := os.Stat(blockchain.PARAMSPATH); err == nil {
+				logrus.Fatalf("Chain parameters file %v already exists", blockchain.PARAMSPATH)
+			}
+			err := blockchain.DefaultChainParams().SaveFile(blockchain.PARAMSPATH)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			logrus.Infof("Chain parameters written to %v", blockchain.PARAMSPATH)
+		}
+		cli.loadChainParams(blockchain.PARAMSPATH, false)
+		fmt.Printf("%s\n", blockchain.Params)
+	}
 	if runNodeCmd.Parsed() {
 		blockchain.NodeAddress = *nodeAddress
-		network := blockchain.Network{}
+		blockchain.MiningWorkers = *nodeWorkers
+		if *nodeJobWorkers < 1 || *nodeQueueSize < 0 {
+			runNodeCmd.Usage()
+			logrus.Fatal("Mining jobs must be positive and the queue size can't be negative")
+		}
 
 		chain, err := blockchain.InitBlockChain(blockchain.DBPATH)
 		if err != nil {
 			logrus.Fatalf("Can't Initialize blockchain database %v\n", err)
 		}
-		blockchain.Chain = chain
-		defer chain.Database.Close()
+		nodeKey := cli.loadNodeKey()
+		chain.Engine = cli.newEngine(nodeKey)
+		chain.NodeKey = nodeKey
+		defer chain.Close()
+		network := blockchain.Network{Chain: chain}
+		logrus.Infof("Consensus engine: %v", blockchain.Params.Consensus)
+		if *nodePool {
+			engine, ok := chain.Engine.(blockchain.ProofOfWorkEngine)
+			if !ok {
+				logrus.Fatal("Pooled mining needs proof of work")
+			}
+			chain.Engine = blockchain.PooledProofOfWorkEngine{ProofOfWorkEngine: engine}
+			logrus.Info("Pooled mining with connected miners")
+		}
+
+		migrated, err := chain.MigrateEncoding()
+		if err != nil {
+			logrus.Fatalf("Can't migrate blockchain database %v\n", err)
+		}
+		if migrated > 0 {
+			logrus.Infof("Migrated %d gob encoded blocks to protobuf", migrated)
+		}
 
-		go network.Serve(*nodeAddress)
+		server := blockchain.NewServer(chain, blockchain.NewMiningJobs(*nodeJobWorkers, *nodeQueueSize))
+		go network.Serve(*nodeAddress, server)
+		if nodeKey != nil {
+			go network.RunCheckpoints(nodeKey)
+		}
 
 		if *remoteNodeAddress == "" {
 			logrus.Infoln("Server starting as stand alone")
@@ -198,15 +399,27 @@ func (cli *CommandLine) Run() {
 		}
 		logrus.Info("Database Cleaned Up")
 	}
+	if migrateCmd.Parsed() {
+		chain, err := blockchain.InitBlockChain(blockchain.DBPATH)
+		if err != nil {
+			logrus.Fatalf("Can't Initialize blockchain database %v\n", err)
+		}
+		defer chain.Close()
+
+		migrated, err := chain.MigrateEncoding()
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		logrus.Infof("Migrated %d gob encoded blocks to protobuf", migrated)
+	}
 	if populateCmd.Parsed() {
 		chain, err := blockchain.InitBlockChain(blockchain.DBPATH)
 		if err != nil {
 			logrus.Fatalf("Can't Initialize blockchain database %v\n", err)
 		}
-		blockchain.Chain = chain
-		defer chain.Database.Close()
+		defer chain.Close()
 
-		err = populateDb()
+		err = populateDb(chain)
 		if err != nil {
 			logrus.Errorf("%v\n", err)
 		}
@@ -224,6 +437,7 @@ func (cli *CommandLine) Run() {
 			if err != nil {
 				logrus.Fatalf("%v\n", err)
 			}
+			fmt.Printf(" PubKey     : %x\n", key.PublicKey)
 			logrus.Infof("Key Generation Successfull")
 			return
 		}
@@ -243,7 +457,7 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			logrus.Fatalf("%v\n", err)
 		}
-		fmt.Printf("%s\n", key)
+		fmt.Printf(" PubKey     : %x\n Token      : %x\n", key.PublicKey, key.Token)
 		logrus.Infof("Key Generation Successfull")
 	}
 	if printchainCmd.Parsed() {
@@ -266,13 +480,16 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			logrus.Fatalf("Can't Initialize blockchain database %v\n", err)
 		}
-		blockchain.Chain = chain
-		defer chain.Database.Close()
+		defer chain.Close()
 
-		network := blockchain.Network{}
-		err = network.Printchain(token)
+		network := blockchain.Network{Chain: chain}
+		direction := blockchain.Backward
+		if *printchainCmdForward {
+			direction = blockchain.Forward
+		}
+		err = network.Printchain(token, direction, *printchainCmdHeight)
 		if err != nil {
-			if err == badger.ErrKeyNotFound {
+			if err == blockchain.ErrKeyNotFound {
 				log.Fatal("Token is invalid")
 			}
 			logrus.Fatalf("%v\n", err)
@@ -308,10 +525,10 @@ func (cli *CommandLine) Run() {
 			if err != nil {
 				logrus.Fatal(err)
 			}
-			defer chain.Database.Close()
-			blockchain.Chain = chain
+			defer chain.Close()
+			chain.Engine = cli.newEngine(nil)
 
-			network := blockchain.Network{}
+			network := blockchain.Network{Chain: chain}
 			err = network.DiscoverAndDownload(*clientCmdMinerAddr, token)
 			if err != nil {
 				logrus.Fatal(err)
@@ -321,17 +538,40 @@ func (cli *CommandLine) Run() {
 				clientCmd.Usage()
 				logrus.Fatal("No transaction data provided")
 			}
+			if !blockchain.IsContentType(*clientCmdContentType) {
+				clientCmd.Usage()
+				logrus.Fatalf("Unknown content type %q", *clientCmdContentType)
+			}
 			chain, err := blockchain.InitBlockChain(blockchain.DBPATH)
 			if err != nil {
 				logrus.Fatal(err)
 			}
-			defer chain.Database.Close()
-			blockchain.Chain = chain
+			defer chain.Close()
+			chain.Engine = cli.newEngine(nil)
 
-			network := blockchain.Network{}
+			network := blockchain.Network{Chain: chain}
+			if *clientCmdAsync {
+				if *clientCmdBlockCount != 1 {
+					logrus.Fatal("Only one block can be submitted at a time, the next block needs its parent")
+				}
+				var trans []*blockchain.Transaction
+				for _, data := range transactions {
+					trans = append(trans, blockchain.NewTransaction(*clientCmdTransactionType, *clientCmdContentType, []byte(data), metadata))
+				}
+				minerAddr, jobID, err := network.SubmitBlock(*clientCmdMinerAddr, token, trans)
+				if err != nil {
+					logrus.Fatal(err)
+				}
+				logrus.Infof("Block submitted, check back with: job -f %s -id %x", minerAddr, jobID)
+				return
+			}
 			for i := 1; i <= *clientCmdBlockCount; i++ {
 				logrus.Infof("Generting Block: %v\n", i)
-				err = network.CreateBlock(*clientCmdMinerAddr, token, transactions)
+				var trans []*blockchain.Transaction
+				for _, data := range transactions {
+					trans = append(trans, blockchain.NewTransaction(*clientCmdTransactionType, *clientCmdContentType, []byte(data), metadata))
+				}
+				err = network.CreateBlock(*clientCmdMinerAddr, token, trans)
 				if err != nil {
 					logrus.Fatal(err)
 				}
@@ -339,6 +579,223 @@ func (cli *CommandLine) Run() {
 			}
 		}
 	}
+	if proofCmd.Parsed() {
+		if *proofCmdServerAddr == "" || *proofCmdBlockHash == "" || *proofCmdTransactionID == "" {
+			proofCmd.Usage()
+			os.Exit(1)
+		}
+		blockHash, err := hex.DecodeString(*proofCmdBlockHash)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		txID, err := hex.DecodeString(*proofCmdTransactionID)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+
+		network := blockchain.Network{}
+		header, tx, err := network.GetTransactionProof(*proofCmdServerAddr, cli.newEngine(nil), blockHash, txID)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		fmt.Printf("%s\n", header)
+		fmt.Printf("   ├──Transaction  : %s\n", tx)
+		logrus.Info("Transaction inclusion verified")
+	}
+	if checkpointCmd.Parsed() {
+		if *checkpointCmdServerAddr == "" {
+			checkpointCmd.Usage()
+			os.Exit(1)
+		}
+		var token []byte
+		if *checkpointCmdToken == "" {
+			key, err := blockchain.LoadKey(blockchain.KEYPATH)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			token = key.Token
+		} else {
+			tkn, err := hex.DecodeString(*checkpointCmdToken)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			token = tkn
+		}
+
+		network := blockchain.Network{}
+		header, tip, err := network.GetCheckpointProof(*checkpointCmdServerAddr, token, *checkpointCmdHeight)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		fmt.Printf("%s\n", header)
+		fmt.Printf("   ├──Tip  : %s %X\n", tip.Address, tip.Hash)
+		logrus.Info("Device chain tip is committed to by the checkpoint")
+	}
+	if evidenceCmd.Parsed() {
+		if *evidenceCmdServerAddr == "" {
+			evidenceCmd.Usage()
+			os.Exit(1)
+		}
+		token, err := hex.DecodeString(*evidenceCmdToken)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+
+		network := blockchain.Network{}
+		evidenceList, err := network.GetEvidence(*evidenceCmdServerAddr, token)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		for _, evidence := range evidenceList {
+			fmt.Printf("%s\n", evidence)
+		}
+		logrus.Infof("%d verified evidence records", len(evidenceList))
+	}
+	if jobCmd.Parsed() {
+		if *jobCmdServerAddr == "" || *jobCmdID == "" {
+			jobCmd.Usage()
+			os.Exit(1)
+		}
+		jobID, err := hex.DecodeString(*jobCmdID)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+
+		chain, err := blockchain.InitBlockChain(blockchain.DBPATH)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer chain.Close()
+		chain.Engine = cli.newEngine(nil)
+
+		network := blockchain.Network{Chain: chain}
+		if *jobCmdCancel {
+			cancelled, err := network.CancelJob(*jobCmdServerAddr, jobID)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			if !cancelled {
+				logrus.Fatal("Job can't be cancelled, the block is already mined or the job is done")
+			}
+			logrus.Info("Job cancelled")
+		}
+
+		var last *blockchain.JobStatus
+		if *jobCmdWatch {
+			err = network.WatchJob(*jobCmdServerAddr, jobID, func(status *blockchain.JobStatus) error {
+				fmt.Printf("%s\n", status)
+				last = status
+				return nil
+			})
+		} else {
+			last, err = network.JobStatus(*jobCmdServerAddr, jobID)
+			if err == nil {
+				fmt.Printf("%s\n", last)
+			}
+		}
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		if last != nil && last.Block != nil {
+			err = network.AddJobBlock(jobID, last.Block)
+			if err != nil {
+				logrus.Fatalf("Returned block rejected: %v\n", err)
+			}
+		} else if last != nil && last.Done() {
+			err = network.DropJob(jobID)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+		}
+	}
+	if finalityCmd.Parsed() {
+		if *finalityCmdServerAddr == "" {
+			finalityCmd.Usage()
+			os.Exit(1)
+		}
+
+		network := blockchain.Network{}
+		header, votes, err := network.GetFinality(*finalityCmdServerAddr)
+		if err != nil {
+			logrus.Fatalf("%v\n", err)
+		}
+		if header == nil {
+			logrus.Info("No checkpoint is final yet")
+			return
+		}
+		fmt.Printf("%s\n", header)
+		for _, vote := range votes {
+			fmt.Printf("   ├──Vote : %X\n", vote.Signer)
+		}
+		logrus.Infof("Checkpoint %d is final, signed by %d of %d miners", header.Height, len(votes), len(blockchain.Params.Miners))
+	}
+	if queryCmd.Parsed() {
+		if *queryCmdServerAddr == "" {
+			queryCmd.Usage()
+			os.Exit(1)
+		}
+		network := blockchain.Network{}
+		engine := cli.newEngine(nil)
+
+		if *queryCmdTransactionID != "" {
+			txID, err := hex.DecodeString(*queryCmdTransactionID)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			block, err := network.GetBlockByTransaction(*queryCmdServerAddr, engine, txID)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			fmt.Printf("%s\n", block)
+			return
+		}
+
+		var token []byte
+		if *queryCmdToken == "" {
+			key, err := blockchain.LoadKey(blockchain.KEYPATH)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			token = key.Token
+		} else {
+			tkn, err := hex.DecodeString(*queryCmdToken)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			token = tkn
+		}
+
+		switch {
+		case *queryCmdHeight >= 0:
+			block, err := network.GetBlockByHeight(*queryCmdServerAddr, engine, token, *queryCmdHeight)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			fmt.Printf("%s\n", block)
+		case *queryCmdFrom != "" && *queryCmdTo != "":
+			from, err := time.Parse(time.RFC3339, *queryCmdFrom)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			to, err := time.Parse(time.RFC3339, *queryCmdTo)
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			count := 0
+			err = network.GetBlocksByTime(*queryCmdServerAddr, engine, token, from.UnixNano(), to.UnixNano(), func(block *blockchain.Block) error {
+				fmt.Printf("%s\n", block)
+				count++
+				return nil
+			})
+			if err != nil {
+				logrus.Fatalf("%v\n", err)
+			}
+			logrus.Infof("%d blocks between %s and %s", count, *queryCmdFrom, *queryCmdTo)
+		default:
+			queryCmd.Usage()
+			os.Exit(1)
+		}
+	}
 	if testCmd.Parsed() {
 		network := blockchain.Network{}
 		network.Test(*testCmdAddr)
@@ -360,20 +817,22 @@ func (cli *CommandLine) Run() {
 		if err != nil {
 			logrus.Fatal(err)
 		}
-		defer chain.Database.Close()
-		blockchain.Chain = chain
-		network := blockchain.Network{}
+		defer chain.Close()
+		chain.Engine = cli.newEngine(nil)
+		network := blockchain.Network{Chain: chain}
 
 		// generate fixed size transaction
 		randomByte := make([]byte, analysis.BlockSize)
 		rand.Read(randomByte)
-		var transactions []string
-		transactions = append(transactions, hex.EncodeToString(randomByte))
+		data := []byte(hex.EncodeToString(randomByte))
 		// end
 
 		for i := 1; i <= *analyzeCmdBlockCount; i++ {
 			logrus.Infof("Generting Block: %v\n", i)
-			err = network.CreateBlock(*analyzeCmdServerAddr, token, transactions)
+			trans := []*blockchain.Transaction{
+				blockchain.NewTransaction(blockchain.TransactionTypeReading, blockchain.ContentTypeRaw, data, nil),
+			}
+			err = network.CreateBlock(*analyzeCmdServerAddr, token, trans)
 			if err != nil {
 				logrus.Fatal(err)
 			}
@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"time"
 
 	"github.com/TariqueNasrullah/iotchain/blockchain"
 )
@@ -30,21 +31,23 @@ func handle(err error) {
 	}
 }
 
-func populateDb() error {
+func populateDb(chain *blockchain.BlockChain) error {
 	token, err := generateToken("admin", "pass")
 	handle(err)
 	key, err := blockchain.GenerateKey(keyPath)
 	handle(err)
 	key.Token = token
 
-	trans := blockchain.Transaction{
-		Data: []byte("Hello Transaction"),
-	}
+	trans := blockchain.NewTransaction(blockchain.TransactionTypeGenesis, blockchain.ContentTypeRaw, []byte("Hello Transaction"), nil)
 	block := blockchain.Block{
-		Transactions: []*blockchain.Transaction{&trans},
+		Difficulty:   blockchain.Params.Difficulty,
+		Version:      blockchain.BlockVersion,
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: []*blockchain.Transaction{trans},
 		Token:        key.Token,
 		PublicKey:    key.PublicKey,
 	}
+	block.MerkleRoot = block.HashTransactions()
 
 	err = block.Sign(key.PrivateKey)
 	handle(err)
@@ -54,7 +57,7 @@ func populateDb() error {
 	block.Nonce = nonce
 	block.Hash = hash
 
-	err = blockchain.Chain.AddGenesis(&block)
+	err = chain.AddGenesis(&block)
 	if err != nil {
 		return err
 	}
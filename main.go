@@ -48,6 +48,7 @@ func testBlockchain() {
 		Data: []byte("Hello Transaction"),
 	}
 	block := blockchain.Block{
+		Difficulty:   blockchain.Params.Difficulty,
 		Transactions: []*blockchain.Transaction{&trans},
 		Token:        key.Token,
 		PublicKey:    key.PublicKey,